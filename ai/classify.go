@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// VendorCategory is the category the model picked for one vendor, with its
+// confidence between 0 and 1.
+type VendorCategory struct {
+	Vendor     string  `json:"vendor"`
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+// VendorClassifier sorts vendor names into one of the given categories.
+// ClaudeClient implements it; tests use a fake.
+type VendorClassifier interface {
+	ClassifyVendors(vendors []string, categories []string) ([]VendorCategory, error)
+}
+
+const classifyPrompt = `You categorize merchants from Indian bank and card statements for a personal expense tracker.
+
+Pick exactly one category for each vendor from this list: %s.
+Use "Other" when none fits or the name is too vague to tell, such as a person's name on a UPI payment.
+
+Reply with only a JSON array, one object per vendor, in the form
+[{"vendor": "<vendor exactly as given>", "category": "<category>", "confidence": <0 to 1>}]
+Confidence is how sure you are: 0.9 or more only when the merchant is well known or its name says what it sells.
+
+Vendors:
+%s`
+
+// ClassifyVendors asks Claude for a category and confidence for each vendor
+// in a single request.
+func (c *ClaudeClient) ClassifyVendors(vendors []string, categories []string) ([]VendorCategory, error) {
+	if len(vendors) == 0 {
+		return nil, nil
+	}
+	vendorList, err := json.Marshal(vendors)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := c.client.Messages.New(context.Background(), anthropic.MessageNewParams{
+		Model:     anthropic.ModelClaudeSonnet4_6,
+		MaxTokens: 4096,
+		Messages: []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(
+			fmt.Sprintf(classifyPrompt, strings.Join(categories, ", "), vendorList),
+		))},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claude API error: %w", err)
+	}
+	log.Printf("claude classify vendors=%d input_tokens=%d output_tokens=%d", len(vendors), msg.Usage.InputTokens, msg.Usage.OutputTokens)
+
+	var raw string
+	for _, block := range msg.Content {
+		if block.Type == "text" {
+			raw = block.AsText().Text
+			break
+		}
+	}
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+
+	var results []VendorCategory
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &results); err != nil {
+		return nil, fmt.Errorf("failed to parse classification: %v", err)
+	}
+	return results, nil
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/anthropics/anthropic-sdk-go"
 	"github.com/anthropics/anthropic-sdk-go/option"
+
+	"github.com/yourusername/expense-tracker/utils"
 )
 
 const systemPrompt = `You are a personal finance assistant. You have tools to query the user's transaction data.
@@ -55,6 +57,7 @@ Rules:
 - Use ₹ for all amounts.
 - Be concise. Lead with the numbers, follow with brief insight.
 - If a question spans multiple periods or categories, call the relevant tool for each.
+- When the user says a vendor belongs in a different category, call set_vendor_category. Ask before changing their past transactions unless they already said to.
 
 Memory rules (call save_memory proactively):
 - User states a financial goal, e.g. "I want to spend less on food"
@@ -62,7 +65,7 @@ Memory rules (call save_memory proactively):
 - User reveals life context, e.g. income, job, family situation, city
 - You notice a strong spending pattern worth remembering
 - User expresses a preference for how they want analysis presented`,
-		time.Now().Format("2 Jan 2006"), memoryBlock)
+		time.Now().In(utils.UserLocation()).Format("2 Jan 2006"), memoryBlock)
 
 	// Seed messages from conversation history
 	messages := make([]anthropic.MessageParam, 0, len(history)+1)
@@ -9,13 +9,17 @@ type ToolExecutor func(name string, input map[string]any) (string, error)
 var toolSchemas = func() []anthropic.ToolUnionParam {
 	categorySpend := anthropic.ToolParam{
 		Name:        "get_category_spend",
-		Description: anthropic.String("Get total amount spent in a category between two dates. Use this to answer questions about how much was spent on food, travel, bills, etc. in any time period."),
+		Description: anthropic.String("Get total amount spent in a category between two dates. Use this to answer questions about how much was spent on food, travel, bills, etc. in any time period. A parent category includes its subcategories, which are listed with their own totals."),
 		InputSchema: anthropic.ToolInputSchemaParam{
 			Properties: map[string]interface{}{
 				"category": map[string]string{
 					"type":        "string",
 					"description": "Category name, e.g. Food, Travel, Bills, Grocery, Shopping, Entertainment, Healthcare, SIP, Subscription",
 				},
+				"tag": map[string]string{
+					"type":        "string",
+					"description": "Optional tag filter, e.g. goa-trip-2026 or reimbursable. Comma-separate several tags to require all of them.",
+				},
 				"from_date": map[string]string{
 					"type":        "string",
 					"description": "Start date in YYYY-MM-DD format (inclusive)",
@@ -34,6 +38,10 @@ var toolSchemas = func() []anthropic.ToolUnionParam {
 		Description: anthropic.String("Get a full spending breakdown for a specific month: total spend, per-category totals, and transaction count. Use this for month-level overviews or comparisons."),
 		InputSchema: anthropic.ToolInputSchemaParam{
 			Properties: map[string]interface{}{
+				"tag": map[string]string{
+					"type":        "string",
+					"description": "Optional tag filter, e.g. goa-trip-2026 or reimbursable. Comma-separate several tags to require all of them.",
+				},
 				"from_date": map[string]string{
 					"type":        "string",
 					"description": "First day of the month in YYYY-MM-DD format, e.g. 2026-04-01",
@@ -52,6 +60,10 @@ var toolSchemas = func() []anthropic.ToolUnionParam {
 		Description: anthropic.String("Get the top merchants/vendors ranked by total spend in a date range. Use this to answer questions about where most money is being spent."),
 		InputSchema: anthropic.ToolInputSchemaParam{
 			Properties: map[string]interface{}{
+				"tag": map[string]string{
+					"type":        "string",
+					"description": "Optional tag filter, e.g. goa-trip-2026 or reimbursable. Comma-separate several tags to require all of them.",
+				},
 				"from_date": map[string]string{
 					"type":        "string",
 					"description": "Start date in YYYY-MM-DD format (inclusive)",
@@ -78,6 +90,14 @@ var toolSchemas = func() []anthropic.ToolUnionParam {
 					"type":        "string",
 					"description": "Filter by category, e.g. Food, Travel, Grocery. Leave empty for all categories.",
 				},
+				"search": map[string]string{
+					"type":        "string",
+					"description": "Optional text to look for in the vendor, the user's notes or the original alert text, e.g. \"birthday\" or \"netflix\".",
+				},
+				"tag": map[string]string{
+					"type":        "string",
+					"description": "Optional tag filter, e.g. goa-trip-2026 or reimbursable. Comma-separate several tags to require all of them.",
+				},
 				"from_date": map[string]string{
 					"type":        "string",
 					"description": "Start date in YYYY-MM-DD format (inclusive)",
@@ -109,11 +129,34 @@ var toolSchemas = func() []anthropic.ToolUnionParam {
 		},
 	}
 
+	setVendorCategory := anthropic.ToolParam{
+		Name:        "set_vendor_category",
+		Description: anthropic.String("Remember the category the user wants for a vendor, so future transactions from it are categorized that way. Call this when the user corrects a vendor's category, using the vendor name exactly as get_transactions shows it. Only set apply_to_past when the user asks to fix their existing transactions too."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]interface{}{
+				"vendor": map[string]string{
+					"type":        "string",
+					"description": "Vendor name as it appears on the transactions, e.g. SWIGGY INSTAMART",
+				},
+				"category": map[string]string{
+					"type":        "string",
+					"description": "Category to use for the vendor, e.g. Grocery",
+				},
+				"apply_to_past": map[string]string{
+					"type":        "boolean",
+					"description": "Also recategorize the existing transactions from this vendor",
+				},
+			},
+			Required: []string{"vendor", "category"},
+		},
+	}
+
 	return []anthropic.ToolUnionParam{
 		{OfTool: &categorySpend},
 		{OfTool: &monthlySum},
 		{OfTool: &topMerchants},
 		{OfTool: &getTransactions},
 		{OfTool: &saveMemory},
+		{OfTool: &setVendorCategory},
 	}
 }()
@@ -13,6 +13,7 @@ function formatCurrency(value) {
 const dashboardState = {
     selectedCategory: null,
     categories: [],
+    categoryMembers: {},
     periodTransactions: [],
     lastTenDaysTransactions: [],
     txMap: {}
@@ -212,7 +213,25 @@ function getTxCategory(tx) {
 
 function getFilteredTransactionsByCategory(category) {
     const selected = String(category || '').trim().toLowerCase();
-    return dashboardState.periodTransactions.filter(tx => getTxCategory(tx).toLowerCase() === selected);
+    const members = dashboardState.categoryMembers[selected] || new Set([selected]);
+    return dashboardState.periodTransactions.filter(tx => members.has(getTxCategory(tx).toLowerCase()));
+}
+
+// collectCategoryMembers maps each top-level category to the lowercase names
+// of itself and every subcategory in the rollup, so selecting a parent shows
+// its subcategories' transactions too.
+function collectCategoryMembers(items) {
+    const members = {};
+    const walk = (item, set) => {
+        set.add(String(item.label || '').toLowerCase());
+        (item.children || []).forEach(child => walk(child, set));
+    };
+    items.forEach(item => {
+        const set = new Set();
+        walk(item, set);
+        members[String(item.label || '').toLowerCase()] = set;
+    });
+    return members;
 }
 
 function registerTxMap(transactions) {
@@ -330,7 +349,7 @@ async function loadDashboard() {
     try {
         const [summary, categories, trend, transactions, monthlyComparison, lastTenDays] = await Promise.all([
             fetchJSON(`/api/summary/total?period=${period}`),
-            fetchJSON(`/api/summary/category?period=${period}`),
+            fetchJSON(`/api/summary/category?period=${period}&depth=0`),
             fetchJSON('/api/summary/trend/last-10-days'),
             fetchJSON(`/api/transactions?period=${period}`),
             fetchJSON('/api/summary/monthly-comparison'),
@@ -343,6 +362,7 @@ async function loadDashboard() {
         document.getElementById('uncategorizedCount').textContent = formatCurrency(summary.credit_amount);
 
         dashboardState.categories = categories.items || [];
+        dashboardState.categoryMembers = collectCategoryMembers(dashboardState.categories);
         dashboardState.periodTransactions = transactions.transactions || [];
         dashboardState.lastTenDaysTransactions = lastTenDays.transactions || [];
         if (dashboardState.selectedCategory) {
@@ -386,17 +406,24 @@ function populateRangeCategoryDropdown(categories) {
     if (current) select.value = current;
 }
 
+// rangeState keeps the pages of the current range search loaded so far.
+const rangeState = { url: '', transactions: [], total: 0, nextCursor: '' };
+
 function renderRangeResults(data) {
     const summary = document.getElementById('rangeSummary');
     const table = document.getElementById('rangeTable');
     if (!summary || !table) return;
 
-    const transactions = data.transactions || [];
+    rangeState.transactions = rangeState.transactions.concat(data.transactions || []);
+    rangeState.total = data.total || 0;
+    rangeState.nextCursor = data.next_cursor || '';
+    const transactions = rangeState.transactions;
     const total = transactions.reduce((sum, tx) => sum + (tx.amount || 0), 0);
+    const shown = rangeState.nextCursor ? `${transactions.length} of ${rangeState.total}` : `${rangeState.total}`;
 
     summary.style.display = 'flex';
     summary.innerHTML = `
-        <div class="range-stat"><span>Transactions</span><strong>${data.count || 0}</strong></div>
+        <div class="range-stat"><span>Transactions</span><strong>${shown}</strong></div>
         <div class="range-stat"><span>Total Spend</span><strong>${formatCurrency(total)}</strong></div>
         <div class="range-stat"><span>Average</span><strong>${formatCurrency(transactions.length ? total / transactions.length : 0)}</strong></div>
     `;
@@ -425,7 +452,20 @@ function renderRangeResults(data) {
             </thead>
             <tbody>${rows}</tbody>
         </table>
+        ${rangeState.nextCursor ? '<button class="range-btn" id="rangeMore" type="button">Load more</button>' : ''}
     `;
+    document.getElementById('rangeMore')?.addEventListener('click', loadMoreRange);
+}
+
+async function loadMoreRange(event) {
+    event.target.disabled = true;
+    try {
+        const data = await fetchJSON(`${rangeState.url}&cursor=${encodeURIComponent(rangeState.nextCursor)}`);
+        if (data) renderRangeResults(data);
+    } catch (err) {
+        console.error(err);
+        event.target.disabled = false;
+    }
 }
 
 function renderGooglePayImportResult(summary) {
@@ -532,11 +572,15 @@ async function uploadGooglePayHistory() {
 
 function openEditModal(tx) {
     document.getElementById('editId').value = tx.id || '';
+    document.getElementById('editVersion').value = tx.version || 0;
     document.getElementById('editType').value = tx.type || 'Manual';
     document.getElementById('editVendor').value = tx.vendor || '';
     document.getElementById('editAmount').value = tx.amount || '';
     document.getElementById('editCategory').value = tx.category || '';
+    document.getElementById('editNotes').value = tx.notes || '';
+    document.getElementById('editApplyToPast').checked = false;
     document.getElementById('editResult').style.display = 'none';
+    document.getElementById('editHistory').style.display = 'none';
     const modal = document.getElementById('editModal');
     modal.style.display = 'flex';
 }
@@ -545,12 +589,107 @@ function closeEditModal() {
     document.getElementById('editModal').style.display = 'none';
 }
 
+async function loadTransactionHistory() {
+    const id = document.getElementById('editId').value;
+    const container = document.getElementById('editHistory');
+    container.style.display = 'block';
+    container.textContent = 'Loading…';
+    try {
+        const data = await fetchJSON(`/api/transactions/history?id=${encodeURIComponent(id)}`);
+        container.textContent = '';
+        if (!data.changes.length) {
+            container.innerHTML = '<p class="empty">No edits recorded yet.</p>';
+            return;
+        }
+        data.changes.slice().reverse().forEach(change => {
+            const row = document.createElement('div');
+            row.style.cssText = 'display:flex;justify-content:space-between;gap:8px;padding:6px 0;border-bottom:1px solid #e2e8f0;font-size:0.85rem;';
+            const text = document.createElement('span');
+            const who = change.actor || change.source;
+            const fields = change.fields.map(f => {
+                const before = change.before[f];
+                const after = change.after[f];
+                return typeof before === 'object' ? f : `${f}: ${before ?? ''} → ${after ?? ''}`;
+            });
+            text.textContent = `${formatDateTime(change.changed_at)} · ${change.action} by ${who} · ${fields.join(', ')}`;
+            const revert = document.createElement('button');
+            revert.type = 'button';
+            revert.className = 'range-btn';
+            revert.textContent = `Revert to v${change.before.version}`;
+            revert.addEventListener('click', () => revertTransaction(id, change.before.version));
+            row.append(text, revert);
+            container.appendChild(row);
+        });
+    } catch (err) {
+        container.innerHTML = '<p class="empty">Failed to load history.</p>';
+    }
+}
+
+async function revertTransaction(id, version) {
+    if (!confirm(`Revert this transaction to version ${version}?`)) return;
+    try {
+        const data = await sendJSON('/api/transactions/revert', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id, version })
+        });
+        openEditModal(data.transaction);
+        await loadDashboard();
+        await loadTransactionHistory();
+    } catch (err) {
+        alert('Failed to revert: ' + err.message);
+    }
+}
+
+async function loadTrash() {
+    const container = document.getElementById('trashTable');
+    if (!container) return;
+    try {
+        const data = await fetchJSON('/api/trash');
+        document.getElementById('trashNote').textContent =
+            `Deleted transactions can be restored for ${data.retention_days} days before they are purged.`;
+        container.textContent = '';
+        if (!data.transactions.length) {
+            container.innerHTML = '<p class="empty">Trash is empty.</p>';
+            return;
+        }
+        data.transactions.forEach(tx => {
+            const row = document.createElement('div');
+            row.style.cssText = 'display:flex;justify-content:space-between;align-items:center;gap:8px;padding:8px 0;border-bottom:1px solid #e2e8f0;';
+            const text = document.createElement('span');
+            text.textContent = `${formatDateTime(tx.date_time)} · ${tx.vendor} · ${formatCurrency(tx.amount)} · deleted ${formatDateTime(tx.deleted_at)}`;
+            const restore = document.createElement('button');
+            restore.type = 'button';
+            restore.className = 'range-btn';
+            restore.textContent = 'Restore';
+            restore.addEventListener('click', () => restoreTransaction(tx.id));
+            row.append(text, restore);
+            container.appendChild(row);
+        });
+    } catch (err) {
+        container.innerHTML = '<p class="empty">Failed to load trash.</p>';
+    }
+}
+
+async function restoreTransaction(id) {
+    try {
+        await sendJSON('/api/trash/restore', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id })
+        });
+        await Promise.all([loadDashboard(), loadTrash()]);
+    } catch (err) {
+        alert('Failed to restore: ' + err.message);
+    }
+}
+
 async function deleteTransaction(id) {
     if (!id) return;
-    if (!confirm('Delete this transaction? This cannot be undone.')) return;
+    if (!confirm('Move this transaction to the trash?')) return;
     try {
         await sendJSON(`/api/transactions/delete?id=${encodeURIComponent(id)}`, { method: 'DELETE' });
-        await loadDashboard();
+        await Promise.all([loadDashboard(), loadTrash()]);
     } catch (err) {
         alert('Failed to delete: ' + err.message);
     }
@@ -565,6 +704,9 @@ async function saveEditedTransaction() {
     const category = rawCategory === '__new__'
         ? document.getElementById('editCategoryNew')?.value.trim()
         : rawCategory;
+    const notes = document.getElementById('editNotes').value.trim();
+    const version = Number(document.getElementById('editVersion').value) || 0;
+    const applyToPast = document.getElementById('editApplyToPast').checked;
     const btn = document.getElementById('editSave');
     const result = document.getElementById('editResult');
 
@@ -575,13 +717,38 @@ async function saveEditedTransaction() {
     btn.textContent = 'Saving…';
 
     try {
-        await sendJSON('/api/transactions/update', {
-            method: 'PUT',
+        if (rawCategory === '__new__' && category) {
+            // New categories join the managed list first; an existing name
+            // is reported by the update below if it really is unknown.
+            await fetch('/api/categories', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ name: category })
+            });
+        }
+        const response = await fetch('/api/transactions/update', {
+            method: 'PATCH',
             headers: { 'Content-Type': 'application/json' },
-            body: JSON.stringify({ id, type, vendor, amount, category })
+            body: JSON.stringify({ id, version, type, vendor, amount, category, notes, apply_to_past: applyToPast })
         });
+        if (response.status === 412) {
+            const conflict = await response.json();
+            if (conflict.transaction) openEditModal(conflict.transaction);
+            result.style.display = 'block';
+            result.innerHTML = `<p class="empty">This transaction was changed elsewhere. The latest values are shown; review and save again.</p>`;
+            return;
+        }
+        const saved = await handleJSONResponse(response);
+        let message = 'Saved successfully.';
+        if (saved.mapping) {
+            message += ` Future transactions from ${saved.mapping.vendor} will use ${saved.mapping.category}.`;
+        }
+        if (saved.applied) {
+            message += ` Updated ${saved.applied.changed} past transaction(s).`;
+        }
         result.style.display = 'block';
-        result.innerHTML = `<p class="empty" style="color:#16a34a">Saved successfully.</p>`;
+        result.innerHTML = `<p class="empty" style="color:#16a34a"></p>`;
+        result.firstElementChild.textContent = message;
         await loadDashboard();
         setTimeout(closeEditModal, 800);
     } catch (err) {
@@ -641,6 +808,7 @@ async function searchByRange() {
     const from = document.getElementById('rangeFrom')?.value;
     const to = document.getElementById('rangeTo')?.value;
     const category = document.getElementById('rangeCategory')?.value || '';
+    const query = document.getElementById('rangeQuery')?.value.trim() || '';
     const btn = document.getElementById('rangeSearch');
 
     if (!from || !to) {
@@ -658,7 +826,10 @@ async function searchByRange() {
     try {
         let url = `/api/transactions/range?from=${from}&to=${to}`;
         if (category) url += `&category=${encodeURIComponent(category)}`;
+        if (query) url += `&q=${encodeURIComponent(query)}`;
 
+        rangeState.url = url;
+        rangeState.transactions = [];
         const data = await fetchJSON(url);
         if (data) renderRangeResults(data);
     } catch (err) {
@@ -738,6 +909,7 @@ document.addEventListener('DOMContentLoaded', () => {
     });
     document.getElementById('editModalClose')?.addEventListener('click', closeEditModal);
     document.getElementById('editSave')?.addEventListener('click', saveEditedTransaction);
+    document.getElementById('editHistoryBtn')?.addEventListener('click', loadTransactionHistory);
     document.getElementById('editDelete')?.addEventListener('click', () => {
         const id = document.getElementById('editId').value;
         closeEditModal();
@@ -772,6 +944,7 @@ document.addEventListener('DOMContentLoaded', () => {
     if (manualDate) manualDate.value = fmt(today);
 
     loadDashboard();
+    loadTrash();
     initChat();
 });
 
@@ -9,6 +9,7 @@ require (
 	go.mongodb.org/mongo-driver v1.17.4
 	golang.org/x/oauth2 v0.36.0
 	google.golang.org/api v0.274.0
+	google.golang.org/grpc v1.80.0
 )
 
 require (
@@ -57,7 +58,6 @@ require (
 	google.golang.org/genproto v0.0.0-20260319201613-d00831a3d3e7 // indirect
 	google.golang.org/genproto/googleapis/api v0.0.0-20260401024825-9d38bb4040a9 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20260401001100-f93e5f3e9f0f // indirect
-	google.golang.org/grpc v1.80.0 // indirect
 	google.golang.org/protobuf v1.36.11 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,11 +10,13 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/yourusername/expense-tracker/ai"
 	"github.com/yourusername/expense-tracker/models"
 	"github.com/yourusername/expense-tracker/services"
+	"github.com/yourusername/expense-tracker/utils"
 )
 
 func transactionsHandler(w http.ResponseWriter, r *http.Request) {
@@ -23,25 +26,28 @@ func transactionsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer cleanup()
 
-	limit := 50
-	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
-		parsed, err := strconv.Atoi(rawLimit)
-		if err == nil && parsed > 0 {
-			limit = parsed
-		}
+	page, err := pageRequestFromQuery(r, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := transactionFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	transactions, err := reporting.ListTransactions(
-		r.URL.Query().Get("period"),
-		r.URL.Query().Get("category"),
-		limit,
-	)
+	result, err := reporting.ListTransactionsPage(r.URL.Query().Get("period"), filter, page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"transactions": transactions})
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactions": result.Transactions,
+		"total":        result.Total,
+		"next_cursor":  result.NextCursor,
+	})
 }
 
 func transactionsByRangeHandler(w http.ResponseWriter, r *http.Request) {
@@ -59,27 +65,31 @@ func transactionsByRangeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	from, err := time.Parse("2006-01-02", fromStr)
+	from, err := utils.ParseDate(fromStr)
 	if err != nil {
 		http.Error(w, "invalid 'from' date, expected format: 2006-01-02", http.StatusBadRequest)
 		return
 	}
-	to, err := time.Parse("2006-01-02", toStr)
+	to, err := utils.ParseDate(toStr)
 	if err != nil {
 		http.Error(w, "invalid 'to' date, expected format: 2006-01-02", http.StatusBadRequest)
 		return
 	}
 	// include the full last day
-	to = to.Add(24*time.Hour - time.Nanosecond)
+	to = utils.EndOfDay(to)
 
-	limit := 500
-	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
-		if parsed, err := strconv.Atoi(rawLimit); err == nil && parsed > 0 {
-			limit = parsed
-		}
+	page, err := pageRequestFromQuery(r, 500)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := transactionFilterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	transactions, err := reporting.ListTransactionsByDateRange(from, to, r.URL.Query().Get("category"), limit)
+	result, err := reporting.ListTransactionsByDateRangePage(from, to, filter, page)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -88,8 +98,11 @@ func transactionsByRangeHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"from":         fromStr,
 		"to":           r.URL.Query().Get("to"),
-		"count":        len(transactions),
-		"transactions": transactions,
+		"timezone":     utils.UserLocation().String(),
+		"count":        len(result.Transactions),
+		"total":        result.Total,
+		"next_cursor":  result.NextCursor,
+		"transactions": result.Transactions,
 	})
 }
 
@@ -125,6 +138,9 @@ func totalSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, summary)
 }
 
+// categorySummaryHandler rolls spend up the category tree. Optional query
+// params: parent to drill into a category, and depth for the levels of
+// children to nest (default 1, 0 for all).
 func categorySummaryHandler(w http.ResponseWriter, r *http.Request) {
 	reporting, cleanup, ok := newReportingService(w)
 	if !ok {
@@ -132,7 +148,39 @@ func categorySummaryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer cleanup()
 
-	items, err := reporting.GetCategoryBreakdown(r.URL.Query().Get("period"))
+	query := r.URL.Query()
+	parent := strings.TrimSpace(query.Get("parent"))
+	depth := 1
+	if raw := query.Get("depth"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			http.Error(w, "depth must be a non-negative number", http.StatusBadRequest)
+			return
+		}
+		depth = value
+	}
+
+	items, err := reporting.GetCategoryRollup(query.Get("period"), parent, depth)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{"items": items}
+	if parent != "" {
+		response["parent"] = reporting.CategoryTree().Path(parent)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func tagSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	reporting, cleanup, ok := newReportingService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	items, err := reporting.GetTagBreakdown(r.URL.Query().Get("period"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -157,6 +205,9 @@ func sourceSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
 }
 
+// trendSummaryHandler buckets a period by granularity (day, week, month or
+// year) with split=category or split=source adding stacked series. Every
+// bucket has a point, zero when nothing was spent.
 func trendSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	reporting, cleanup, ok := newReportingService(w)
 	if !ok {
@@ -164,13 +215,14 @@ func trendSummaryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer cleanup()
 
-	points, err := reporting.GetDailyTrend(r.URL.Query().Get("period"))
+	query := r.URL.Query()
+	trend, err := reporting.GetTrend(query.Get("period"), query.Get("granularity"), query.Get("split"))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"items": points})
+	writeJSON(w, http.StatusOK, trend)
 }
 
 func lastTenDaysTrendHandler(w http.ResponseWriter, r *http.Request) {
@@ -186,7 +238,7 @@ func lastTenDaysTrendHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"items": points})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": points, "timezone": utils.UserLocation().String()})
 }
 
 func monthlyComparisonHandler(w http.ResponseWriter, r *http.Request) {
@@ -249,18 +301,95 @@ func migrateFieldNamesHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func migrateAmountsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return
+	}
+	defer dbClient.Close()
+
+	m, ok := dbClient.(models.AmountMigrator)
+	if !ok {
+		http.Error(w, "migration not supported for this database backend", http.StatusNotImplemented)
+		return
+	}
+
+	migrated, skipped, err := m.MigrateAmountsToPaise()
+	if err != nil {
+		log.Printf("amount migration failed migrated=%d skipped=%d err=%v", migrated, skipped, err)
+		http.Error(w, fmt.Sprintf("migration failed after %d documents: %v", migrated, err), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("amount migration complete migrated=%d skipped=%d", migrated, skipped)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "ok",
+		"migrated": migrated,
+		"skipped":  skipped,
+	})
+}
+
+// migrateIMPSTimestampsHandler moves IMPS transactions stored with UTC
+// wall-clock times into the user's time zone.
+func migrateIMPSTimestampsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return
+	}
+	defer dbClient.Close()
+
+	migrated, skipped, err := services.MigrateIMPSTimestamps(dbClient)
+	if err != nil {
+		log.Printf("imps timestamp migration failed migrated=%d skipped=%d err=%v", migrated, skipped, err)
+		http.Error(w, fmt.Sprintf("migration failed after %d documents: %v", migrated, err), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "ok",
+		"migrated": migrated,
+		"skipped":  skipped,
+	})
+}
+
+// updateTransactionHandler applies a partial update: only the fields present
+// in the body are written. The caller must send the version it last read,
+// either as an If-Match header (the ETag) or as "version" in the body; if the
+// transaction changed since, the update is rejected with 412 and the current
+// transaction so the client can reload.
 func updateTransactionHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Only PUT method allowed", http.StatusMethodNotAllowed)
+	if r.Method != http.MethodPatch && r.Method != http.MethodPut {
+		http.Error(w, "Only PATCH and PUT methods allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	var body struct {
-		ID       string  `json:"id"`
-		Type     string  `json:"type"`
-		Vendor   string  `json:"vendor"`
-		Amount   float64 `json:"amount"`
-		Category string  `json:"category"`
+		ID              string        `json:"id"`
+		Version         *int64        `json:"version"`
+		Type            *string       `json:"type"`
+		Vendor          *string       `json:"vendor"`
+		Amount          *models.Money `json:"amount"`
+		Category        *string       `json:"category"`
+		Notes           *string       `json:"notes"`
+		DateTime        *string       `json:"date_time"`
+		CardEnding      *string       `json:"card_ending"`
+		DebitedAccount  *string       `json:"debited_account"`
+		CreditedAccount *string       `json:"credited_account"`
+		// ApplyToPast also recategorizes earlier transactions from the
+		// vendor when the category changes.
+		ApplyToPast bool `json:"apply_to_past"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "invalid JSON body", http.StatusBadRequest)
@@ -270,16 +399,43 @@ func updateTransactionHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
-	if body.Vendor == "" || body.Amount == 0 {
-		http.Error(w, "vendor and non-zero amount are required", http.StatusBadRequest)
+
+	expectedVersion, err := expectedTransactionVersion(r, body.Version)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusPreconditionRequired)
 		return
 	}
 
-	tx := models.Transaction{
-		Type:     body.Type,
-		Vendor:   body.Vendor,
-		Amount:   body.Amount,
-		Category: body.Category,
+	if body.Vendor != nil && strings.TrimSpace(*body.Vendor) == "" {
+		http.Error(w, "vendor cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if body.Amount != nil && *body.Amount == 0 {
+		http.Error(w, "amount must be non-zero", http.StatusBadRequest)
+		return
+	}
+	if body.Notes != nil {
+		notes := strings.TrimSpace(*body.Notes)
+		body.Notes = &notes
+	}
+
+	patch := models.TransactionPatch{
+		Type:            body.Type,
+		Vendor:          body.Vendor,
+		Amount:          body.Amount,
+		Category:        body.Category,
+		Notes:           body.Notes,
+		CardEnding:      body.CardEnding,
+		DebitedAccount:  body.DebitedAccount,
+		CreditedAccount: body.CreditedAccount,
+	}
+	if body.DateTime != nil {
+		dt, err := parseTransactionDateTime(*body.DateTime)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		patch.DateTime = &dt
 	}
 
 	dbClient, err := models.NewDatabaseClient()
@@ -289,16 +445,111 @@ func updateTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer dbClient.Close()
 
-	if err := dbClient.UpdateTransaction(body.ID, tx); err != nil {
+	existing, err := dbClient.GetTransaction(body.ID)
+	if err != nil {
+		log.Printf("transaction update lookup failed id=%s err=%v", body.ID, err)
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if existing.IsTrashed() {
+		http.Error(w, "transaction is in the trash; restore it before editing", http.StatusConflict)
+		return
+	}
+	if len(existing.Splits) > 0 && patch.Amount != nil && *patch.Amount != existing.Amount {
+		http.Error(w, "transaction is split; update or clear the splits before changing its amount", http.StatusConflict)
+		return
+	}
+	// A new category must come from the managed list; resending the current
+	// one is fine even if it has not been cleaned up yet.
+	if patch.Category != nil && *patch.Category != existing.Category {
+		if store, ok := dbClient.(models.CategoryStore); ok {
+			category, err := services.NewCategoryService(dbClient, store).ResolveCategory(*patch.Category)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			patch.Category = &category
+		}
+	}
+	if patch.Vendor != nil && *patch.Vendor != existing.Vendor {
+		merchant := services.CanonicalMerchant(*patch.Vendor, dbClient)
+		patch.Merchant = &merchant
+	}
+
+	updated, err := dbClient.PatchTransaction(body.ID, patch, expectedVersion)
+	if errors.Is(err, models.ErrVersionConflict) {
+		current, getErr := dbClient.GetTransaction(body.ID)
+		if getErr != nil {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		log.Printf("transaction update conflict id=%s expected_version=%d current_version=%d", body.ID, expectedVersion, current.Version)
+		w.Header().Set("ETag", transactionETag(current.Version))
+		writeJSON(w, http.StatusPreconditionFailed, map[string]interface{}{
+			"error":       err.Error(),
+			"transaction": current,
+		})
+		return
+	}
+	if err != nil {
 		log.Printf("transaction update failed id=%s err=%v", body.ID, err)
 		http.Error(w, "Failed to update transaction", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("transaction updated id=%s vendor=%q amount=%.2f category=%q", body.ID, tx.Vendor, tx.Amount, tx.Category)
-	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	services.RecordTransactionChange(dbClient, *existing, *updated, sessionEmail(r), models.AuditSourceUI, models.AuditActionUpdate)
+	log.Printf("transaction updated id=%s version=%d vendor=%q amount=%s category=%q", body.ID, updated.Version, updated.Vendor, updated.Amount, updated.Category)
+
+	response := map[string]interface{}{"status": "ok", "transaction": updated}
+	if body.Category != nil && updated.Category != existing.Category && len(updated.Splits) == 0 {
+		mapping, applied := learnCategoryCorrection(dbClient, updated.Vendor, updated.Category, body.ApplyToPast, sessionEmail(r), models.AuditSourceUI)
+		if mapping != nil {
+			response["mapping"] = mapping
+		}
+		if applied != nil {
+			response["applied"] = applied
+		}
+	}
+	w.Header().Set("ETag", transactionETag(updated.Version))
+	writeJSON(w, http.StatusOK, response)
+}
+
+func transactionETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// expectedTransactionVersion reads the version a client based its edit on,
+// preferring the If-Match header over the body.
+func expectedTransactionVersion(r *http.Request, bodyVersion *int64) (int64, error) {
+	if ifMatch := strings.TrimSpace(r.Header.Get("If-Match")); ifMatch != "" {
+		tag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+		version, err := strconv.ParseInt(tag, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid If-Match header %q", ifMatch)
+		}
+		return version, nil
+	}
+	if bodyVersion != nil {
+		return *bodyVersion, nil
+	}
+	return 0, fmt.Errorf("If-Match header or version is required")
+}
+
+// parseTransactionDateTime accepts the datetime-local format used by the
+// dashboard or a plain date.
+func parseTransactionDateTime(value string) (time.Time, error) {
+	dt, err := time.ParseInLocation("2006-01-02T15:04", value, utils.UserLocation())
+	if err != nil {
+		dt, err = utils.ParseDate(value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date_time format, expected YYYY-MM-DD")
+		}
+	}
+	return dt, nil
 }
 
+// deleteTransactionHandler moves a transaction to the trash. It can be
+// restored until the trash is purged.
 func deleteTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		http.Error(w, "Only DELETE method allowed", http.StatusMethodNotAllowed)
@@ -309,19 +560,20 @@ func deleteTransactionHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
-	dbClient, err := models.NewDatabaseClient()
-	if err != nil {
-		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+
+	trash, cleanup, ok := newTrashService(w, nil)
+	if !ok {
 		return
 	}
-	defer dbClient.Close()
-	if err := dbClient.DeleteTransaction(id); err != nil {
+	defer cleanup()
+
+	if _, err := trash.Trash(id, sessionEmail(r), models.AuditSourceUI); err != nil {
 		log.Printf("transaction delete failed id=%s err=%v", id, err)
-		http.Error(w, "Failed to delete transaction", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Printf("transaction deleted id=%s", id)
-	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	log.Printf("transaction moved to trash id=%s", id)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "trashed": true})
 }
 
 func addManualTransactionHandler(w http.ResponseWriter, r *http.Request) {
@@ -331,11 +583,14 @@ func addManualTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		Type     string  `json:"type"`
-		Vendor   string  `json:"vendor"`
-		Amount   float64 `json:"amount"`
-		Category string  `json:"category"`
-		DateTime string  `json:"date_time"`
+		Type     string       `json:"type"`
+		Vendor   string       `json:"vendor"`
+		Amount   models.Money `json:"amount"`
+		Currency string       `json:"currency"`
+		Category string       `json:"category"`
+		DateTime string       `json:"date_time"`
+		Notes    string       `json:"notes"`
+		Tags     []string     `json:"tags"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "invalid JSON body", http.StatusBadRequest)
@@ -346,13 +601,16 @@ func addManualTransactionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dt, err := time.Parse("2006-01-02T15:04", body.DateTime)
+	dt, err := parseTransactionDateTime(body.DateTime)
 	if err != nil {
-		dt, err = time.Parse("2006-01-02", body.DateTime)
-		if err != nil {
-			http.Error(w, "invalid date_time format, expected YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	tags, err := services.NormalizeTags(body.Tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	txType := body.Type
@@ -360,11 +618,13 @@ func addManualTransactionHandler(w http.ResponseWriter, r *http.Request) {
 		txType = "Manual"
 	}
 	tx := models.Transaction{
-		Type:     txType,
-		Vendor:   body.Vendor,
-		Amount:   body.Amount,
-		Category: body.Category,
-		DateTime: dt,
+		Type:       txType,
+		Vendor:     body.Vendor,
+		Category:   body.Category,
+		DateTime:   dt,
+		Notes:      strings.TrimSpace(body.Notes),
+		Tags:       tags,
+		SourceKind: models.SourceKindManual,
 	}
 
 	dbClient, err := models.NewDatabaseClient()
@@ -374,13 +634,22 @@ func addManualTransactionHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer dbClient.Close()
 
+	services.SetTransactionAmount(&tx, body.Currency, body.Amount, dbClient)
+	tx.Merchant = services.CanonicalMerchant(tx.Vendor, dbClient)
+
+	if store, ok := dbClient.(models.TagStore); ok {
+		if err := services.NewTagService(dbClient, store).EnsureTags(tx.Tags); err != nil {
+			log.Printf("manual transaction tag list update failed tags=%v err=%v", tx.Tags, err)
+		}
+	}
+
 	if err := dbClient.SaveTransaction(tx); err != nil {
-		log.Printf("manual transaction save failed vendor=%q amount=%.2f err=%v", tx.Vendor, tx.Amount, err)
+		log.Printf("manual transaction save failed vendor=%q amount=%s err=%v", tx.Vendor, tx.Amount, err)
 		http.Error(w, "Failed to save transaction", http.StatusInternalServerError)
 		return
 	}
 
-	log.Printf("manual transaction saved vendor=%q amount=%.2f category=%q", tx.Vendor, tx.Amount, tx.Category)
+	log.Printf("manual transaction saved vendor=%q amount=%s category=%q", tx.Vendor, tx.Amount, tx.Category)
 	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "transaction": tx})
 }
 
@@ -503,6 +772,12 @@ func serveStaticFiles(w http.ResponseWriter, r *http.Request) {
 
 func StartAPIServer() {
 	InitWebAuth()
+	if dbClient, err := models.NewDatabaseClient(); err != nil {
+		log.Printf("startup migrations skipped err=%v", err)
+	} else {
+		models.RunStartupMigrations(dbClient)
+		dbClient.Close()
+	}
 
 	// Auth routes (no middleware)
 	http.HandleFunc("/auth/signin", loginPageHandler)
@@ -515,9 +790,12 @@ func StartAPIServer() {
 
 	// One-time admin migrations (auth protected)
 	http.HandleFunc("/api/admin/migrate-field-names", apiAuthMiddleware(migrateFieldNamesHandler))
+	http.HandleFunc("/api/admin/migrate-amounts", apiAuthMiddleware(migrateAmountsHandler))
+	http.HandleFunc("/api/admin/migrate-imps-timestamps", apiAuthMiddleware(migrateIMPSTimestampsHandler))
 
 	// Protected API routes
 	http.HandleFunc("/api/jobs/sync-hdfc", syncHDFCHandler)
+	http.HandleFunc("/api/jobs/purge-trash", jobAuthMiddleware(purgeTrashHandler))
 	http.HandleFunc("/api/transactions/manual", apiAuthMiddleware(addManualTransactionHandler))
 	http.HandleFunc("/api/transactions/update", apiAuthMiddleware(updateTransactionHandler))
 	http.HandleFunc("/api/transactions/delete", apiAuthMiddleware(deleteTransactionHandler))
@@ -525,12 +803,38 @@ func StartAPIServer() {
 	http.HandleFunc("/api/transactions", apiAuthMiddleware(transactionsHandler))
 	http.HandleFunc("/api/transactions/range", apiAuthMiddleware(transactionsByRangeHandler))
 	http.HandleFunc("/api/transactions/last-10-days", apiAuthMiddleware(lastTenDaysTransactionsHandler))
+	http.HandleFunc("/api/transactions/bulk", apiAuthMiddleware(bulkEditHandler))
+	http.HandleFunc("/api/transactions/tags", apiAuthMiddleware(transactionTagsHandler))
+	http.HandleFunc("/api/transactions/splits", apiAuthMiddleware(transactionSplitsHandler))
+	http.HandleFunc("/api/transactions/attachments", apiAuthMiddleware(transactionAttachmentsHandler))
+	http.HandleFunc("/api/transactions/history", apiAuthMiddleware(transactionHistoryHandler))
+	http.HandleFunc("/api/transactions/revert", apiAuthMiddleware(revertTransactionHandler))
+	http.HandleFunc("/api/trash", apiAuthMiddleware(trashHandler))
+	http.HandleFunc("/api/trash/restore", apiAuthMiddleware(restoreTransactionHandler))
+	http.HandleFunc("/api/tags", apiAuthMiddleware(tagsHandler))
+	http.HandleFunc("/api/rules", apiAuthMiddleware(rulesHandler))
+	http.HandleFunc("/api/merchants", apiAuthMiddleware(merchantsHandler))
+	http.HandleFunc("/api/searches", apiAuthMiddleware(savedSearchesHandler))
+	http.HandleFunc("/api/categories", apiAuthMiddleware(categoriesHandler))
+	http.HandleFunc("/api/categories/rename", apiAuthMiddleware(categoryRenameHandler))
+	http.HandleFunc("/api/categories/merge", apiAuthMiddleware(categoryMergeHandler))
+	http.HandleFunc("/api/categorize/explain", apiAuthMiddleware(categorizeExplainHandler))
+	http.HandleFunc("/api/categorize/ai/run", apiAuthMiddleware(aiCategorizeRunHandler))
+	http.HandleFunc("/api/categorize/review", apiAuthMiddleware(categorizeReviewHandler))
+	http.HandleFunc("/api/categorize/review/resolve", apiAuthMiddleware(categorizeResolveHandler))
+	http.HandleFunc("/api/categorize/recategorize", apiAuthMiddleware(recategorizeHandler))
 	http.HandleFunc("/api/summary/total", apiAuthMiddleware(totalSummaryHandler))
 	http.HandleFunc("/api/summary/category", apiAuthMiddleware(categorySummaryHandler))
+	http.HandleFunc("/api/summary/tag", apiAuthMiddleware(tagSummaryHandler))
 	http.HandleFunc("/api/summary/source", apiAuthMiddleware(sourceSummaryHandler))
 	http.HandleFunc("/api/summary/trend", apiAuthMiddleware(trendSummaryHandler))
 	http.HandleFunc("/api/summary/trend/last-10-days", apiAuthMiddleware(lastTenDaysTrendHandler))
 	http.HandleFunc("/api/summary/monthly-comparison", apiAuthMiddleware(monthlyComparisonHandler))
+	http.HandleFunc("/api/fx/rates", apiAuthMiddleware(fxRatesHandler))
+	http.HandleFunc("/api/fx/rates/import", apiAuthMiddleware(importFXRatesHandler))
+	http.HandleFunc("/api/reconcile/run", apiAuthMiddleware(reconcileRunHandler))
+	http.HandleFunc("/api/reconcile/review", apiAuthMiddleware(reconcileReviewHandler))
+	http.HandleFunc("/api/reconcile/review/resolve", apiAuthMiddleware(reconcileResolveHandler))
 	http.HandleFunc("/api/chat", apiAuthMiddleware(chatHandler))
 	http.HandleFunc("/api/evals/run", webAuthMiddleware(evalRunHandler))
 
@@ -583,7 +887,11 @@ func chatHandler(w http.ResponseWriter, r *http.Request) {
 	memories := memorySvc.LoadMemories()
 
 	claudeClient := ai.NewClaudeClient(apiKey)
-	executor := NewToolExecutor(reporting, memorySvc)
+	var categories *services.CategoryService
+	if store, ok := memDB.(models.CategoryStore); ok {
+		categories = services.NewCategoryService(memDB, store)
+	}
+	executor := NewToolExecutor(reporting, memorySvc, services.NewCategoryMappingService(memDB), categories, sessionEmail(r))
 	answer, usage, err := claudeClient.Chat(req.Question, req.History, memories, executor)
 	if err != nil {
 		log.Printf("chat handler: claude error: %v", err)
@@ -609,6 +917,90 @@ func newReportingService(w http.ResponseWriter) (*services.ReportingService, fun
 	}, true
 }
 
+// transactionFilterFromQuery reads the category, vendor (substring), type,
+// tag, q and search query params. Tags may be repeated or comma separated; a
+// transaction must carry all of them. q is a search query (see
+// services.ParseSearchQuery; plain words still search vendor, notes and raw
+// source text) and search names a saved search to apply as well.
+func transactionFilterFromQuery(r *http.Request) (services.TransactionFilter, error) {
+	filter := services.TransactionFilter{
+		Category:    r.URL.Query().Get("category"),
+		Vendor:      r.URL.Query().Get("vendor"),
+		Type:        r.URL.Query().Get("type"),
+		SavedSearch: r.URL.Query().Get("search"),
+	}
+
+	search, err := services.ParseSearchQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Search = search
+
+	var tags []string
+	for _, value := range r.URL.Query()["tag"] {
+		for _, tag := range strings.Split(value, ",") {
+			if strings.TrimSpace(tag) != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	normalized, err := services.NormalizeTags(tags)
+	if err != nil {
+		return filter, err
+	}
+	filter.Tags = normalized
+
+	if filter.MinAmount, err = amountParam(r, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = amountParam(r, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return filter, fmt.Errorf("min_amount is greater than max_amount")
+	}
+	return filter, nil
+}
+
+// amountParam reads an optional rupee amount such as "500" or "-120.50".
+func amountParam(r *http.Request, param string) (*models.Money, error) {
+	raw := r.URL.Query().Get(param)
+	if raw == "" {
+		return nil, nil
+	}
+	amount, err := models.ParseMoney(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", param, raw)
+	}
+	return &amount, nil
+}
+
+// maxPageLimit caps the limit a listing request can ask for.
+const maxPageLimit = 500
+
+// pageRequestFromQuery reads limit, sort, order and cursor. A missing or
+// invalid limit falls back to defaultLimit; larger limits are cut to
+// maxPageLimit.
+func pageRequestFromQuery(r *http.Request, defaultLimit int) (services.PageRequest, error) {
+	query := r.URL.Query()
+	page := services.PageRequest{Limit: defaultLimit, Cursor: query.Get("cursor")}
+	if rawLimit := query.Get("limit"); rawLimit != "" {
+		if parsed, err := strconv.Atoi(rawLimit); err == nil && parsed > 0 {
+			page.Limit = parsed
+		}
+	}
+	if page.Limit > maxPageLimit {
+		page.Limit = maxPageLimit
+	}
+
+	sorting, err := services.ParseTransactionSort(query.Get("sort"), query.Get("order"))
+	if err != nil {
+		return page, err
+	}
+	page.Sort = sorting
+	return page, nil
+}
+
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// transactionAttachmentsHandler uploads (POST multipart with id and file),
+// downloads (GET ?id=&attachment_id=) and deletes (DELETE ?id=&attachment_id=)
+// receipts and invoices.
+func transactionAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		uploadAttachmentHandler(w, r)
+	case http.MethodGet:
+		downloadAttachmentHandler(w, r)
+	case http.MethodDelete:
+		deleteAttachmentHandler(w, r)
+	default:
+		http.Error(w, "Only GET, POST and DELETE methods allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func uploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, services.MaxAttachmentSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		http.Error(w, "invalid upload, expected multipart form with id and file", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	id := r.FormValue("id")
+	if id == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file field is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	attachments, cleanup, ok := newAttachmentService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	attachment, err := attachments.Upload(id, header.Filename, file, sessionEmail(r), models.AuditSourceUI)
+	if err != nil {
+		log.Printf("attachment upload failed id=%s file=%q err=%v", id, header.Filename, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("attachment uploaded id=%s attachment_id=%s type=%s size=%d", id, attachment.ID, attachment.ContentType, attachment.Size)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "attachment": attachment})
+}
+
+func downloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, attachmentID := r.URL.Query().Get("id"), r.URL.Query().Get("attachment_id")
+	if id == "" || attachmentID == "" {
+		http.Error(w, "id and attachment_id are required", http.StatusBadRequest)
+		return
+	}
+
+	attachments, cleanup, ok := newAttachmentService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	attachment, content, err := attachments.Open(id, attachmentID)
+	if err != nil {
+		log.Printf("attachment download failed id=%s attachment_id=%s err=%v", id, attachmentID, err)
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.FileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err := io.Copy(w, content); err != nil {
+		log.Printf("attachment download interrupted id=%s attachment_id=%s err=%v", id, attachmentID, err)
+	}
+}
+
+func deleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, attachmentID := r.URL.Query().Get("id"), r.URL.Query().Get("attachment_id")
+	if id == "" || attachmentID == "" {
+		http.Error(w, "id and attachment_id are required", http.StatusBadRequest)
+		return
+	}
+
+	attachments, cleanup, ok := newAttachmentService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	if err := attachments.Delete(id, attachmentID, sessionEmail(r), models.AuditSourceUI); err != nil {
+		log.Printf("attachment delete failed id=%s attachment_id=%s err=%v", id, attachmentID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("attachment deleted id=%s attachment_id=%s", id, attachmentID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+}
+
+func newAttachmentService(w http.ResponseWriter) (*services.AttachmentService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.AttachmentStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "attachments not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	blobs, err := models.NewBlobStore()
+	if err != nil {
+		dbClient.Close()
+		log.Printf("attachment store init failed err=%v", err)
+		http.Error(w, "Attachment storage unavailable", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	return services.NewAttachmentService(dbClient, store, blobs), func() {
+		dbClient.Close()
+	}, true
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// transactionHistoryHandler returns the change log of a transaction
+// (GET ?id=), oldest change first.
+func transactionHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	audit, cleanup, ok := newAuditService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	changes, err := audit.History(id)
+	if err != nil {
+		log.Printf("transaction history failed id=%s err=%v", id, err)
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	if changes == nil {
+		changes = []models.TransactionChange{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transaction_id": id, "changes": changes})
+}
+
+// revertTransactionHandler restores a transaction to an earlier version from
+// its change log.
+func revertTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ID      string `json:"id"`
+		Version *int64 `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.ID == "" || body.Version == nil {
+		http.Error(w, "id and version are required", http.StatusBadRequest)
+		return
+	}
+
+	audit, cleanup, ok := newAuditService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	tx, err := audit.Revert(body.ID, *body.Version, sessionEmail(r), models.AuditSourceUI)
+	if errors.Is(err, models.ErrVersionConflict) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("transaction revert failed id=%s version=%d err=%v", body.ID, *body.Version, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("transaction reverted id=%s to_version=%d version=%d", body.ID, *body.Version, tx.Version)
+	w.Header().Set("ETag", transactionETag(tx.Version))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "transaction": tx})
+}
+
+func newAuditService(w http.ResponseWriter) (*services.AuditService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.AuditStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "transaction history not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewAuditService(dbClient, store), func() {
+		dbClient.Close()
+	}, true
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// bulkEditHandler recategorizes, tags, untags or deletes many transactions at
+// once. Transactions are selected by ids or by a filter; dry_run only
+// reports the counts.
+func bulkEditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		IDs    []string `json:"ids"`
+		Filter *struct {
+			Period   string `json:"period"`
+			Vendor   string `json:"vendor"`
+			Type     string `json:"type"`
+			Category string `json:"category"`
+			Query    string `json:"q"`
+		} `json:"filter"`
+		Action   string   `json:"action"` // category, tag, untag or delete
+		Category string   `json:"category"`
+		Tags     []string `json:"tags"`
+		DryRun   bool     `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	req := services.BulkEditRequest{
+		IDs:      body.IDs,
+		Action:   body.Action,
+		Category: body.Category,
+		Tags:     body.Tags,
+		DryRun:   body.DryRun,
+	}
+	if body.Filter != nil {
+		if body.Filter.Period == "" {
+			http.Error(w, "filter.period is required", http.StatusBadRequest)
+			return
+		}
+		req.Period = body.Filter.Period
+		search, err := services.ParseSearchQuery(body.Filter.Query)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req.Filter = services.TransactionFilter{
+			Vendor:   body.Filter.Vendor,
+			Type:     body.Filter.Type,
+			Category: body.Filter.Category,
+			Search:   search,
+		}
+	}
+
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return
+	}
+	defer dbClient.Close()
+
+	store, ok := dbClient.(models.BulkStore)
+	if !ok {
+		http.Error(w, "bulk edit not supported for this database backend", http.StatusNotImplemented)
+		return
+	}
+
+	result, err := services.NewBulkEditService(dbClient, store).Apply(req, sessionEmail(r), models.AuditSourceUI)
+	if err != nil {
+		log.Printf("bulk edit failed action=%q ids=%d dry_run=%t result=%+v err=%v", body.Action, len(body.IDs), body.DryRun, result, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("bulk edit completed action=%q ids=%d dry_run=%t result=%+v", body.Action, len(body.IDs), body.DryRun, result)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "result": result})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// categoriesHandler lists (GET, with usage counts), creates (POST), moves
+// (PUT, with id) and deletes (DELETE ?id=&reassign_to=) managed categories.
+// A deleted category's transactions, rules, mappings and merchants move to
+// reassign_to, which defaults to its parent and then to Other.
+func categoriesHandler(w http.ResponseWriter, r *http.Request) {
+	categories, cleanup, ok := newCategoryService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := categories.ListCategoryUsage()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"categories": list})
+
+	case http.MethodPost, http.MethodPut:
+		var category models.Category
+		if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			category.ID = ""
+		} else if category.ID == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		saved, err := categories.SaveCategory(category)
+		if err != nil {
+			log.Printf("category save failed id=%q name=%q err=%v", category.ID, category.Name, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("category saved id=%s name=%q parent=%q", saved.ID, saved.Name, saved.Parent)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "category": saved})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		reassignTo := r.URL.Query().Get("reassign_to")
+		result, err := categories.DeleteCategory(id, reassignTo, sessionEmail(r), models.AuditSourceUI)
+		if err != nil {
+			log.Printf("category delete failed id=%q reassign_to=%q result=%+v err=%v", id, reassignTo, result, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "reassigned": result})
+
+	default:
+		http.Error(w, "Only GET, POST, PUT and DELETE methods allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// categoryRenameHandler renames a category everywhere it is used.
+func categoryRenameHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.ID == "" || body.Name == "" {
+		http.Error(w, "id and name are required", http.StatusBadRequest)
+		return
+	}
+
+	categories, cleanup, ok := newCategoryService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	renamed, result, err := categories.RenameCategory(body.ID, body.Name, sessionEmail(r), models.AuditSourceUI)
+	if err != nil {
+		log.Printf("category rename failed id=%s name=%q result=%+v err=%v", body.ID, body.Name, result, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "category": renamed, "rewritten": result})
+}
+
+// categoryMergeHandler merges the source category, which may be an
+// unmanaged spelling still on some transactions, into the target category.
+func categoryMergeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Source string `json:"source"`
+		Target string `json:"target"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.Source == "" || body.Target == "" {
+		http.Error(w, "source and target are required", http.StatusBadRequest)
+		return
+	}
+
+	categories, cleanup, ok := newCategoryService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	result, err := categories.MergeCategory(body.Source, body.Target, sessionEmail(r), models.AuditSourceUI)
+	if err != nil {
+		log.Printf("category merge failed source=%q target=%q result=%+v err=%v", body.Source, body.Target, result, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "rewritten": result})
+}
+
+func newCategoryService(w http.ResponseWriter) (*services.CategoryService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.CategoryStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "categories not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewCategoryService(dbClient, store), func() {
+		dbClient.Close()
+	}, true
+}
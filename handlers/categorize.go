@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/expense-tracker/ai"
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+// aiCategorizeRunHandler asks the model to categorize the vendors of Other
+// transactions. Optional query params: min_confidence (0-1, default 0.8)
+// and dry_run=true.
+func aiCategorizeRunHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	minConfidence := services.DefaultAIConfidence
+	if raw := r.URL.Query().Get("min_confidence"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value <= 0 || value > 1 {
+			http.Error(w, "min_confidence must be between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		minConfidence = value
+	}
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	apiKey := os.Getenv("ANTHROPIC_API_KEY")
+	if apiKey == "" {
+		http.Error(w, "ANTHROPIC_API_KEY not configured", http.StatusInternalServerError)
+		return
+	}
+
+	categorizer, cleanup, ok := newAICategorizeService(w, ai.NewClaudeClient(apiKey))
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	result, err := categorizer.Run(minConfidence, dryRun)
+	if err != nil {
+		log.Printf("ai categorization failed result=%+v err=%v", result, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"job":    "ai-categorization",
+		"result": result,
+	})
+}
+
+func categorizeReviewHandler(w http.ResponseWriter, r *http.Request) {
+	categorizer, cleanup, ok := newAICategorizeService(w, nil)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.SuggestionPending
+	}
+
+	suggestions, err := categorizer.ListReview(status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"suggestions": suggestions})
+}
+
+func categorizeResolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ID       string `json:"id"`
+		Action   string `json:"action"`   // accept or reject
+		Category string `json:"category"` // overrides the suggestion on accept
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.ID == "" || body.Action == "" {
+		http.Error(w, "id and action are required", http.StatusBadRequest)
+		return
+	}
+
+	categorizer, cleanup, ok := newAICategorizeService(w, nil)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	changed, err := categorizer.ResolveSuggestion(body.ID, body.Action, body.Category, sessionEmail(r))
+	if err != nil {
+		log.Printf("category suggestion resolve failed id=%s action=%s err=%v", body.ID, body.Action, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("category suggestion resolved id=%s action=%s recategorized=%d", body.ID, body.Action, changed)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "recategorized": changed})
+}
+
+// recategorizeHandler re-runs categorization over past transactions. Query
+// params: from and to (2006-01-02, default all history), include_manual=true
+// to also overwrite categories set by hand, and dry_run=false to write the
+// changes; by default it only returns the diff.
+func recategorizeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	from, to := time.Time{}, time.Now()
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := utils.ParseDate(raw)
+		if err != nil {
+			http.Error(w, "invalid 'from' date, expected format: 2006-01-02", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := utils.ParseDate(raw)
+		if err != nil {
+			http.Error(w, "invalid 'to' date, expected format: 2006-01-02", http.StatusBadRequest)
+			return
+		}
+		// include the full last day
+		to = utils.EndOfDay(parsed)
+	}
+	if to.Before(from) {
+		http.Error(w, "'to' must not be before 'from'", http.StatusBadRequest)
+		return
+	}
+	includeManual := query.Get("include_manual") == "true"
+	dryRun := query.Get("dry_run") != "false"
+	if !dryRun && query.Get("preview_token") == "" {
+		http.Error(w, "preview_token from a dry run is required to apply changes", http.StatusBadRequest)
+		return
+	}
+
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return
+	}
+	defer dbClient.Close()
+
+	store, ok := dbClient.(models.BulkStore)
+	if !ok {
+		http.Error(w, "recategorizing not supported for this database backend", http.StatusNotImplemented)
+		return
+	}
+
+	result, err := services.NewRecategorizeService(dbClient, store).Run(from, to, includeManual, dryRun, query.Get("preview_token"))
+	if errors.Is(err, services.ErrRecategorizePreviewStale) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("recategorize failed from=%s to=%s changed=%d err=%v", from.Format("2006-01-02"), to.Format("2006-01-02"), result.Changed, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("recategorize completed from=%s to=%s dry_run=%t scanned=%d changed=%d skipped_manual=%d",
+		from.Format("2006-01-02"), to.Format("2006-01-02"), dryRun, result.Scanned, result.Changed, result.SkippedManual)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"job":    "recategorize",
+		"result": result,
+	})
+}
+
+// newAICategorizeService connects to the database; classifier may be nil for
+// the review endpoints, which never call the model.
+func newAICategorizeService(w http.ResponseWriter, classifier ai.VendorClassifier) (*services.AICategorizeService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.SuggestionStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "category suggestions not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewAICategorizeService(dbClient, store, classifier), func() {
+		dbClient.Close()
+	}, true
+}
@@ -7,11 +7,16 @@ import (
 	"time"
 
 	"github.com/yourusername/expense-tracker/ai"
+	"github.com/yourusername/expense-tracker/models"
 	"github.com/yourusername/expense-tracker/services"
+	"github.com/yourusername/expense-tracker/utils"
 )
 
 // NewToolExecutor builds a ToolExecutor backed by the given ReportingService and MemoryService.
-func NewToolExecutor(reporting *services.ReportingService, memory *services.MemoryService) ai.ToolExecutor {
+// mappings may be nil, in which case set_vendor_category fails; categories, when
+// set, limits it to the managed category list. actor is recorded on the
+// transactions it recategorizes.
+func NewToolExecutor(reporting *services.ReportingService, memory *services.MemoryService, mappings *services.CategoryMappingService, categories *services.CategoryService, actor string) ai.ToolExecutor {
 	return func(name string, input map[string]any) (string, error) {
 		switch name {
 		case "get_category_spend":
@@ -24,6 +29,8 @@ func NewToolExecutor(reporting *services.ReportingService, memory *services.Memo
 			return executeGetTransactions(reporting, input)
 		case "save_memory":
 			return executeSaveMemory(memory, input)
+		case "set_vendor_category":
+			return executeSetVendorCategory(mappings, categories, actor, input)
 		default:
 			return "", fmt.Errorf("unknown tool: %s", name)
 		}
@@ -42,29 +49,89 @@ func executeSaveMemory(m *services.MemoryService, input map[string]any) (string,
 	return fmt.Sprintf("Memory saved: [%s] %s", memType, content), nil
 }
 
+func executeSetVendorCategory(m *services.CategoryMappingService, categories *services.CategoryService, actor string, input map[string]any) (string, error) {
+	if m == nil {
+		return "", fmt.Errorf("changing categories is not available here")
+	}
+	vendor, _ := input["vendor"].(string)
+	category, _ := input["category"].(string)
+	applyToPast, _ := input["apply_to_past"].(bool)
+
+	if categories != nil {
+		resolved, err := categories.ResolveCategory(category)
+		if err != nil {
+			return "", err
+		}
+		category = resolved
+	}
+
+	mapping, err := m.LearnCorrection(vendor, category)
+	if err != nil {
+		return "", err
+	}
+	result := fmt.Sprintf("Future transactions from %q will be categorized as %s.", mapping.Vendor, mapping.Category)
+	if !applyToPast {
+		return result, nil
+	}
+
+	applied, err := m.ApplyToPast(vendor, mapping.Category, actor, models.AuditSourceChat, false)
+	if err != nil {
+		return "", fmt.Errorf("mapping saved but past transactions were not updated: %w", err)
+	}
+	result += fmt.Sprintf(" Recategorized %d past transactions", applied.Changed)
+	if applied.Skipped > 0 {
+		result += fmt.Sprintf(" (%d split transactions left alone)", applied.Skipped)
+	}
+	return result + ".", nil
+}
+
 func executeCategorySpend(r *services.ReportingService, input map[string]any) (string, error) {
 	category, _ := input["category"].(string)
 	from, to, err := parseDateRange(input)
 	if err != nil {
 		return "", err
 	}
+	tags, err := parseTagInput(input)
+	if err != nil {
+		return "", err
+	}
 
-	txs, err := r.ListTransactionsByDateRange(from, to, category, 1000)
+	groups, err := r.Aggregate(models.AggregateQuery{From: from, To: to, GroupBy: models.AggregateByCategory, Tags: tags, DebitsOnly: true})
 	if err != nil {
 		return "", err
 	}
 
-	var total float64
-	count := 0
-	for _, tx := range txs {
-		if !tx.IsCredit() {
-			total += tx.Amount
-			count++
+	tree := r.CategoryTree()
+	var total models.Money
+	var count int
+	for _, group := range groups {
+		matched := false
+		for _, line := range group.Lines {
+			if tree.Contains(category, line.Category) {
+				total += line.Amount
+				matched = true
+			}
+		}
+		if matched {
+			count += group.Count
 		}
 	}
 
-	return fmt.Sprintf("Category: %s | Period: %s to %s | Total spend: ₹%.2f | Transactions: %d",
-		category, input["from_date"], input["to_date"], total, count), nil
+	result := fmt.Sprintf("Category: %s | Period: %s to %s | Total spend: ₹%s | Transactions: %d",
+		category, input["from_date"], input["to_date"], total, count)
+	if len(tags) > 0 {
+		result += fmt.Sprintf(" | Tags: %s", strings.Join(tags, ", "))
+	}
+	if len(tree.Children(category)) > 0 {
+		var parts []string
+		for _, item := range r.CategoryRollup(groups, category, 1) {
+			parts = append(parts, fmt.Sprintf("%s ₹%s", item.Label, item.Amount))
+		}
+		if len(parts) > 0 {
+			result += " | By subcategory: " + strings.Join(parts, ", ")
+		}
+	}
+	return result, nil
 }
 
 func executeMonthlySum(r *services.ReportingService, input map[string]any) (string, error) {
@@ -72,49 +139,42 @@ func executeMonthlySum(r *services.ReportingService, input map[string]any) (stri
 	if err != nil {
 		return "", err
 	}
-
-	txs, err := r.ListTransactionsByDateRange(from, to, "", 2000)
+	tags, err := parseTagInput(input)
 	if err != nil {
 		return "", err
 	}
 
-	var total float64
-	categoryTotals := map[string]float64{}
-	count := 0
-
-	for _, tx := range txs {
-		if tx.IsCredit() {
-			continue
-		}
-		total += tx.Amount
-		count++
-		cat := tx.Category
-		if cat == "" {
-			cat = "Other"
-		}
-		categoryTotals[cat] += tx.Amount
+	groups, err := r.Aggregate(models.AggregateQuery{From: from, To: to, GroupBy: models.AggregateByCategory, Tags: tags, DebitsOnly: true})
+	if err != nil {
+		return "", err
 	}
 
-	type kv struct {
-		k string
-		v float64
+	var total models.Money
+	var count int
+	for _, group := range groups {
+		total += group.Amount
+		count += group.Count
 	}
-	var cats []kv
-	for k, v := range categoryTotals {
-		cats = append(cats, kv{k, v})
-	}
-	sort.Slice(cats, func(i, j int) bool { return cats[i].v > cats[j].v })
 
 	var sb strings.Builder
-	fmt.Fprintf(&sb, "Period: %s to %s | Total: ₹%.2f | Transactions: %d\nBy category:\n",
+	fmt.Fprintf(&sb, "Period: %s to %s | Total: ₹%s | Transactions: %d",
 		input["from_date"], input["to_date"], total, count)
-	for _, c := range cats {
-		fmt.Fprintf(&sb, "  %s: ₹%.2f\n", c.k, c.v)
+	if len(tags) > 0 {
+		fmt.Fprintf(&sb, " | Tags: %s", strings.Join(tags, ", "))
 	}
+	sb.WriteString("\nBy category (subcategories indented under their parent):\n")
+	writeCategoryRollup(&sb, r.CategoryRollup(groups, "", 0), 1)
 
 	return sb.String(), nil
 }
 
+func writeCategoryRollup(sb *strings.Builder, items []services.BreakdownItem, level int) {
+	for _, item := range items {
+		fmt.Fprintf(sb, "%s%s: ₹%s\n", strings.Repeat("  ", level), item.Label, item.Amount)
+		writeCategoryRollup(sb, item.Children, level+1)
+	}
+}
+
 func executeTopMerchants(r *services.ReportingService, input map[string]any) (string, error) {
 	from, to, err := parseDateRange(input)
 	if err != nil {
@@ -130,31 +190,41 @@ func executeTopMerchants(r *services.ReportingService, input map[string]any) (st
 			limit = n
 		}
 	}
+	tags, err := parseTagInput(input)
+	if err != nil {
+		return "", err
+	}
 
-	txs, err := r.ListTransactionsByDateRange(from, to, "", 2000)
+	groups, err := r.Aggregate(models.AggregateQuery{From: from, To: to, GroupBy: models.AggregateByVendor, Tags: tags, DebitsOnly: true})
 	if err != nil {
 		return "", err
 	}
 
-	totals := map[string]float64{}
+	totals := map[string]models.Money{}
 	counts := map[string]int{}
-	for _, tx := range txs {
-		if tx.IsCredit() || tx.Vendor == "" {
+	for _, group := range groups {
+		if group.Key == "" {
 			continue
 		}
-		totals[tx.Vendor] += tx.Amount
-		counts[tx.Vendor]++
+		merchant := r.MerchantName(models.Transaction{Vendor: group.Key, Merchant: group.Merchant})
+		totals[merchant] += group.Amount
+		counts[merchant] += group.Count
 	}
 
 	type kv struct {
 		k string
-		v float64
+		v models.Money
 	}
 	var vendors []kv
 	for k, v := range totals {
 		vendors = append(vendors, kv{k, v})
 	}
-	sort.Slice(vendors, func(i, j int) bool { return vendors[i].v > vendors[j].v })
+	sort.Slice(vendors, func(i, j int) bool {
+		if vendors[i].v != vendors[j].v {
+			return vendors[i].v > vendors[j].v
+		}
+		return vendors[i].k < vendors[j].k
+	})
 	if len(vendors) > limit {
 		vendors = vendors[:limit]
 	}
@@ -162,7 +232,7 @@ func executeTopMerchants(r *services.ReportingService, input map[string]any) (st
 	var sb strings.Builder
 	fmt.Fprintf(&sb, "Top merchants %s to %s:\n", input["from_date"], input["to_date"])
 	for i, v := range vendors {
-		fmt.Fprintf(&sb, "  %d. %s: ₹%.2f (%d txns)\n", i+1, v.k, v.v, counts[v.k])
+		fmt.Fprintf(&sb, "  %d. %s: ₹%s (%d txns)\n", i+1, v.k, v.v, counts[v.k])
 	}
 
 	return sb.String(), nil
@@ -170,12 +240,17 @@ func executeTopMerchants(r *services.ReportingService, input map[string]any) (st
 
 func executeGetTransactions(r *services.ReportingService, input map[string]any) (string, error) {
 	category, _ := input["category"].(string)
+	search, _ := input["search"].(string)
 	from, to, err := parseDateRange(input)
 	if err != nil {
 		return "", err
 	}
+	tags, err := parseTagInput(input)
+	if err != nil {
+		return "", err
+	}
 
-	txs, err := r.ListTransactionsByDateRange(from, to, category, 1000)
+	txs, err := r.ListTransactionsByDateRange(from, to, services.TransactionFilter{Category: category, Query: search, Tags: tags}, 1000)
 	if err != nil {
 		return "", err
 	}
@@ -185,34 +260,66 @@ func executeGetTransactions(r *services.ReportingService, input map[string]any)
 	if category != "" {
 		fmt.Fprintf(&sb, ", category: %s", category)
 	}
+	if search != "" {
+		fmt.Fprintf(&sb, ", matching: %q", search)
+	}
+	if len(tags) > 0 {
+		fmt.Fprintf(&sb, ", tags: %s", strings.Join(tags, ", "))
+	}
 	fmt.Fprintf(&sb, "):\n")
 
 	for _, tx := range txs {
 		if tx.IsCredit() {
 			continue
 		}
-		fmt.Fprintf(&sb, "  %s | %s | %s | ₹%.2f\n",
-			tx.DateTime.Format("2006-01-02 15:04 Mon"),
+		fmt.Fprintf(&sb, "  %s | %s | %s | ₹%s",
+			tx.DateTime.In(utils.UserLocation()).Format("2006-01-02 15:04 Mon"),
 			tx.Category,
 			tx.Vendor,
 			tx.Amount,
 		)
+		if tx.IsForeign() {
+			fmt.Fprintf(&sb, " (%s %s)", tx.Currency, tx.OriginalAmount)
+		}
+		if len(tx.Splits) > 0 {
+			lines := make([]string, len(tx.Splits))
+			for i, split := range tx.Splits {
+				lines[i] = fmt.Sprintf("%s ₹%s", split.Category, split.Amount)
+			}
+			fmt.Fprintf(&sb, " | split: %s", strings.Join(lines, ", "))
+		}
+		if len(tx.Tags) > 0 {
+			fmt.Fprintf(&sb, " | tags: %s", strings.Join(tx.Tags, ", "))
+		}
+		if tx.Notes != "" {
+			fmt.Fprintf(&sb, " | note: %s", tx.Notes)
+		}
+		sb.WriteString("\n")
 	}
 	return sb.String(), nil
 }
 
+// parseTagInput reads the optional comma-separated "tag" tool input.
+func parseTagInput(input map[string]any) ([]string, error) {
+	raw, _ := input["tag"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	return services.NormalizeTags(strings.Split(raw, ","))
+}
+
 func parseDateRange(input map[string]any) (from, to time.Time, err error) {
 	fromStr, _ := input["from_date"].(string)
 	toStr, _ := input["to_date"].(string)
 
-	from, err = time.Parse("2006-01-02", fromStr)
+	from, err = utils.ParseDate(fromStr)
 	if err != nil {
 		return from, to, fmt.Errorf("invalid from_date %q: %w", fromStr, err)
 	}
-	to, err = time.Parse("2006-01-02", toStr)
+	to, err = utils.ParseDate(toStr)
 	if err != nil {
 		return from, to, fmt.Errorf("invalid to_date %q: %w", toStr, err)
 	}
-	to = to.Add(24*time.Hour - time.Nanosecond)
+	to = utils.EndOfDay(to)
 	return from, to, nil
 }
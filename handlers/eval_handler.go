@@ -90,7 +90,7 @@ func runMemoryCreationEval(db *models.FirestoreClient, reportingSvc *services.Re
 
 	// Wrap the real executor to track which tools Claude actually calls.
 	var toolsCalled []string
-	inner := NewToolExecutor(reportingSvc, memorySvc)
+	inner := NewToolExecutor(reportingSvc, memorySvc, nil, nil, "")
 	tracked := ai.ToolExecutor(func(name string, input map[string]any) (string, error) {
 		toolsCalled = append(toolsCalled, name)
 		return inner(name, input)
@@ -158,7 +158,7 @@ func runPersonalisationEval(apiKey string, db *models.FirestoreClient, reporting
 	}
 
 	memBlock := memorySvc.LoadMemories()
-	answer, _, err := claudeClient.Chat(question, nil, memBlock, NewToolExecutor(reportingSvc, memorySvc))
+	answer, _, err := claudeClient.Chat(question, nil, memBlock, NewToolExecutor(reportingSvc, memorySvc, nil, nil, ""))
 	if err != nil {
 		return evalResult{Name: name, Passed: false, Score: 0, Details: "chat failed: " + err.Error(), Elapsed: time.Since(start)}
 	}
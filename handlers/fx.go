@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+func fxRatesHandler(w http.ResponseWriter, r *http.Request) {
+	fx, cleanup, ok := newFXService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	rates, err := fx.ListRates(r.URL.Query().Get("currency"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"base_currency": models.BaseCurrency,
+		"rates":         rates,
+	})
+}
+
+func importFXRatesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 5<<20)
+	if err := r.ParseMultipartForm(5 << 20); err != nil {
+		http.Error(w, "invalid upload, expected multipart form with CSV file", http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "file field is required", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	fx, cleanup, ok := newFXService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	summary, err := fx.ImportCSV(file)
+	if err != nil {
+		log.Printf("fx rate import failed summary=%+v err=%v", summary, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("fx rate import completed imported=%d skipped=%d reconverted=%d", summary.Imported, summary.Skipped, summary.Reconverted)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"job":     "fx_rate_import",
+		"summary": summary,
+	})
+}
+
+func newFXService(w http.ResponseWriter) (*services.FXService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.FXRateStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "fx rates not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewFXService(dbClient, store), func() {
+		dbClient.Close()
+	}, true
+}
@@ -74,7 +74,7 @@ func (m *googlePayImportManager) run(job *googlePayImportJob, content []byte) {
 	}
 	defer dbClient.Close()
 
-	summary, err := services.ImportGooglePayHTMLWithProgress(bytes.NewReader(content), dbClient, func(summary services.GooglePayImportSummary) {
+	summary, err := services.ImportGooglePayHTMLForJob(job.ID, bytes.NewReader(content), dbClient, func(summary services.GooglePayImportSummary) {
 		m.mu.Lock()
 		job.Summary = summary
 		m.mu.Unlock()
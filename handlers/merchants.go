@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// merchantsHandler lists (GET), creates (POST), replaces (PUT, with id) and
+// deletes (DELETE ?id=) registry merchants. Every change relinks the stored
+// transactions to their canonical merchant.
+func merchantsHandler(w http.ResponseWriter, r *http.Request) {
+	merchants, cleanup, ok := newMerchantService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := merchants.ListMerchants()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"merchants": list})
+
+	case http.MethodPost, http.MethodPut:
+		var merchant models.Merchant
+		if err := json.NewDecoder(r.Body).Decode(&merchant); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			merchant.ID = ""
+		} else if merchant.ID == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		saved, err := merchants.SaveMerchant(merchant)
+		if err != nil {
+			log.Printf("merchant save failed id=%q name=%q err=%v", merchant.ID, merchant.Name, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("merchant saved id=%s name=%q aliases=%d patterns=%d", saved.ID, saved.Name, len(saved.Aliases), len(saved.Patterns))
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "merchant": saved, "relinked": relinkMerchants(merchants)})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if err := merchants.DeleteMerchant(id); err != nil {
+			log.Printf("merchant delete failed id=%q err=%v", id, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("merchant deleted id=%s", id)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "relinked": relinkMerchants(merchants)})
+
+	default:
+		http.Error(w, "Only GET, POST, PUT and DELETE methods allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// relinkMerchants updates stored merchants after a registry change. A
+// failure is logged; the registry change itself already succeeded.
+func relinkMerchants(merchants *services.MerchantService) int {
+	relinked, err := merchants.Relink()
+	if err != nil {
+		log.Printf("merchant relink failed relinked=%d err=%v", relinked, err)
+	}
+	return relinked
+}
+
+func newMerchantService(w http.ResponseWriter) (*services.MerchantService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.MerchantStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "merchants not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewMerchantService(dbClient, store), func() {
+		dbClient.Close()
+	}, true
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+func reconcileRunHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reconciler, cleanup, ok := newReconciliationService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	var (
+		summary services.ReconciliationSummary
+		err     error
+	)
+	fromStr, toStr := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if fromStr != "" && toStr != "" {
+		from, parseErr := utils.ParseDate(fromStr)
+		if parseErr != nil {
+			http.Error(w, "invalid 'from' date, expected format: 2006-01-02", http.StatusBadRequest)
+			return
+		}
+		to, parseErr := utils.ParseDate(toStr)
+		if parseErr != nil {
+			http.Error(w, "invalid 'to' date, expected format: 2006-01-02", http.StatusBadRequest)
+			return
+		}
+		summary, err = reconciler.Reconcile(from, utils.EndOfDay(to))
+	} else {
+		summary, err = reconciler.ReconcilePeriod(r.URL.Query().Get("period"))
+	}
+	if err != nil {
+		log.Printf("reconciliation failed err=%v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "ok",
+		"job":     "reconciliation",
+		"summary": summary,
+	})
+}
+
+func reconcileReviewHandler(w http.ResponseWriter, r *http.Request) {
+	reconciler, cleanup, ok := newReconciliationService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ReconciliationPending
+	}
+
+	items, err := reconciler.ListReview(status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"items": items})
+}
+
+func reconcileResolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ID     string `json:"id"`
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.ID == "" || body.Action == "" {
+		http.Error(w, "id and action are required", http.StatusBadRequest)
+		return
+	}
+
+	reconciler, cleanup, ok := newReconciliationService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	if err := reconciler.ResolveMatch(body.ID, body.Action); err != nil {
+		log.Printf("reconciliation resolve failed id=%s action=%s err=%v", body.ID, body.Action, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("reconciliation match resolved id=%s action=%s", body.ID, body.Action)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+}
+
+func newReconciliationService(w http.ResponseWriter) (*services.ReconciliationService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.ReconciliationStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "reconciliation not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewReconciliationService(dbClient, store), func() {
+		dbClient.Close()
+	}, true
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// rulesHandler lists (GET), creates (POST), replaces (PUT, with id) and
+// deletes (DELETE ?id=) categorization rules. Rule changes only affect
+// transactions categorized afterwards.
+func rulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, cleanup, ok := newRuleService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := rules.ListRules()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"rules": list})
+
+	case http.MethodPost, http.MethodPut:
+		var rule models.CategoryRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			rule.ID = ""
+			rule.Source = ""
+		} else if rule.ID == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		saved, err := rules.SaveRule(rule)
+		if err != nil {
+			log.Printf("category rule save failed id=%q category=%q err=%v", rule.ID, rule.Category, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("category rule saved id=%s category=%q priority=%d", saved.ID, saved.Category, saved.Priority)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "rule": saved})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if err := rules.DeleteRule(id); err != nil {
+			log.Printf("category rule delete failed id=%q err=%v", id, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("category rule deleted id=%s", id)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+
+	default:
+		http.Error(w, "Only GET, POST, PUT and DELETE methods allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func newRuleService(w http.ResponseWriter) (*services.RuleService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.RuleStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "category rules not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewRuleService(store), func() {
+		dbClient.Close()
+	}, true
+}
+
+// categorizeExplainHandler shows which rule or mapping categorizes a vendor
+// and why. amount, type, card and account are optional and let rules with
+// those conditions take part.
+func categorizeExplainHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	tx := models.Transaction{
+		Vendor:         strings.TrimSpace(query.Get("vendor")),
+		Type:           query.Get("type"),
+		CardEnding:     query.Get("card"),
+		DebitedAccount: query.Get("account"),
+	}
+	if tx.Vendor == "" {
+		http.Error(w, "vendor is required", http.StatusBadRequest)
+		return
+	}
+	if raw := query.Get("amount"); raw != "" {
+		amount, err := models.ParseMoney(raw)
+		if err != nil {
+			http.Error(w, "invalid amount", http.StatusBadRequest)
+			return
+		}
+		tx.Amount = amount
+	}
+
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return
+	}
+	defer dbClient.Close()
+
+	writeJSON(w, http.StatusOK, services.ExplainCategory(tx, dbClient))
+}
+
+// learnCategoryCorrection saves a manual vendor mapping for a category the
+// user corrected and, with applyToPast, recategorizes the vendor's earlier
+// transactions. Failures are logged rather than failing the edit that
+// triggered them.
+func learnCategoryCorrection(dbClient models.DatabaseClient, vendor, category string, applyToPast bool, actor, source string) (*models.CategoryMapping, *services.BulkEditResult) {
+	mappings := services.NewCategoryMappingService(dbClient)
+	mapping, err := mappings.LearnCorrection(vendor, category)
+	if err != nil {
+		log.Printf("category mapping learn failed vendor=%q category=%q err=%v", vendor, category, err)
+		return nil, nil
+	}
+	log.Printf("category mapping learned vendor=%q category=%q", mapping.Vendor, mapping.Category)
+	if !applyToPast {
+		return mapping, nil
+	}
+
+	result, err := mappings.ApplyToPast(vendor, category, actor, source, false)
+	if err != nil {
+		log.Printf("category mapping apply failed vendor=%q category=%q result=%+v err=%v", vendor, category, result, err)
+		return mapping, nil
+	}
+	log.Printf("category mapping applied vendor=%q category=%q result=%+v", vendor, category, result)
+	return mapping, &result
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// savedSearchesHandler lists (GET), creates (POST), replaces (PUT, with id)
+// and deletes (DELETE ?id=) saved searches. Listings apply one with
+// ?search=<id or name>.
+func savedSearchesHandler(w http.ResponseWriter, r *http.Request) {
+	searches, cleanup, ok := newSavedSearchService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := searches.ListSavedSearches()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"searches": list})
+
+	case http.MethodPost, http.MethodPut:
+		var search models.SavedSearch
+		if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if r.Method == http.MethodPost {
+			search.ID = ""
+		} else if search.ID == "" {
+			http.Error(w, "id is required", http.StatusBadRequest)
+			return
+		}
+
+		saved, err := searches.SaveSavedSearch(search)
+		if err != nil {
+			log.Printf("saved search save failed id=%q name=%q err=%v", search.ID, search.Name, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("saved search saved id=%s name=%q", saved.ID, saved.Name)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "search": saved})
+
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if err := searches.DeleteSavedSearch(id); err != nil {
+			log.Printf("saved search delete failed id=%q err=%v", id, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("saved search deleted id=%s", id)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+
+	default:
+		http.Error(w, "Only GET, POST, PUT and DELETE methods allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func newSavedSearchService(w http.ResponseWriter) (*services.SavedSearchService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.SavedSearchStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "saved searches not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewSavedSearchService(store), func() {
+		dbClient.Close()
+	}, true
+}
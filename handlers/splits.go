@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// transactionSplitsHandler replaces the split lines of a transaction. An
+// empty splits list turns it back into a single-category transaction.
+func transactionSplitsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Only PUT method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ID     string         `json:"id"`
+		Splits []models.Split `json:"splits"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.ID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return
+	}
+	defer dbClient.Close()
+
+	store, ok := dbClient.(models.SplitStore)
+	if !ok {
+		http.Error(w, "splits not supported for this database backend", http.StatusNotImplemented)
+		return
+	}
+
+	before, err := dbClient.GetTransaction(body.ID)
+	if err != nil {
+		log.Printf("transaction split lookup failed id=%s err=%v", body.ID, err)
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+
+	tx, err := services.NewSplitService(dbClient, store).SetSplits(body.ID, body.Splits)
+	if err != nil {
+		log.Printf("transaction split failed id=%s lines=%d err=%v", body.ID, len(body.Splits), err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	services.RecordTransactionChange(dbClient, *before, *tx, sessionEmail(r), models.AuditSourceUI, models.AuditActionSplit)
+
+	log.Printf("transaction split updated id=%s lines=%d", body.ID, len(tx.Splits))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "transaction": tx})
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// tagsHandler lists (GET), creates (POST), renames (PUT) and deletes (DELETE)
+// tags. Renaming and deleting also update every transaction carrying the tag.
+func tagsHandler(w http.ResponseWriter, r *http.Request) {
+	tags, cleanup, ok := newTagService(w)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	switch r.Method {
+	case http.MethodGet:
+		list, err := tags.ListTags()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"tags": list})
+
+	case http.MethodPost:
+		var body struct {
+			Name        string `json:"name"`
+			Description string `json:"description"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		tag, err := tags.CreateTag(body.Name, body.Description)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("tag saved name=%s", tag.Name)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "tag": tag})
+
+	case http.MethodPut:
+		var body struct {
+			Name    string `json:"name"`
+			NewName string `json:"new_name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		updated, err := tags.RenameTag(body.Name, body.NewName, sessionEmail(r), models.AuditSourceUI)
+		if err != nil {
+			log.Printf("tag rename failed name=%q new_name=%q err=%v", body.Name, body.NewName, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("tag renamed name=%q new_name=%q transactions=%d", body.Name, body.NewName, updated)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "updated": updated})
+
+	case http.MethodDelete:
+		name := r.URL.Query().Get("name")
+		updated, err := tags.DeleteTag(name, sessionEmail(r), models.AuditSourceUI)
+		if err != nil {
+			log.Printf("tag delete failed name=%q err=%v", name, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("tag deleted name=%q transactions=%d", name, updated)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "updated": updated})
+
+	default:
+		http.Error(w, "Only GET, POST, PUT and DELETE methods allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// transactionTagsHandler adds or removes tags on many transactions at once.
+func transactionTagsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		IDs    []string `json:"ids"`
+		Tags   []string `json:"tags"`
+		Action string   `json:"action"` // "add" (default) or "remove"
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	action := services.BulkActionTag
+	switch body.Action {
+	case "", "add":
+	case "remove":
+		action = services.BulkActionUntag
+	default:
+		http.Error(w, "action must be add or remove", http.StatusBadRequest)
+		return
+	}
+
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return
+	}
+	defer dbClient.Close()
+
+	store, ok := dbClient.(models.BulkStore)
+	if !ok {
+		http.Error(w, "tags not supported for this database backend", http.StatusNotImplemented)
+		return
+	}
+
+	// Goes through bulk edit so every changed transaction is recorded in its
+	// change log with who made the change.
+	req := services.BulkEditRequest{IDs: body.IDs, Action: action, Tags: body.Tags}
+	result, err := services.NewBulkEditService(dbClient, store).Apply(req, sessionEmail(r), models.AuditSourceUI)
+	if err != nil {
+		log.Printf("bulk tag failed action=%q ids=%d err=%v", body.Action, len(body.IDs), err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("bulk tag completed action=%q ids=%d tags=%v updated=%d", body.Action, len(body.IDs), body.Tags, result.Changed)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "updated": result.Changed})
+}
+
+func newTagService(w http.ResponseWriter) (*services.TagService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.TagStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "tags not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewTagService(dbClient, store), func() {
+		dbClient.Close()
+	}, true
+}
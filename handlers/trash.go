@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/services"
+)
+
+// trashHandler lists deleted transactions that can still be restored.
+func trashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trash, cleanup, ok := newTrashService(w, nil)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	transactions, err := trash.List()
+	if err != nil {
+		log.Printf("trash list failed err=%v", err)
+		http.Error(w, "Failed to load trash", http.StatusInternalServerError)
+		return
+	}
+	if transactions == nil {
+		transactions = []models.Transaction{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transactions":   transactions,
+		"retention_days": int(trashRetention().Hours() / 24),
+	})
+}
+
+func restoreTransactionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.ID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	trash, cleanup, ok := newTrashService(w, nil)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	tx, err := trash.Restore(body.ID, sessionEmail(r), models.AuditSourceUI)
+	if err != nil {
+		log.Printf("transaction restore failed id=%s err=%v", body.ID, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("transaction restored id=%s", body.ID)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ok", "transaction": tx})
+}
+
+// purgeTrashHandler permanently deletes transactions that have been in the
+// trash longer than TRASH_RETENTION_DAYS. It is meant to be called by a
+// scheduler sending JOB_SECRET (see jobAuthMiddleware). A retention of 0
+// empties the whole trash and has to be confirmed with force=true.
+func purgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	log.Printf("trash purge requested remote_addr=%s user_agent=%q", r.RemoteAddr, r.UserAgent())
+
+	retention := trashRetention()
+	if retention == 0 && r.URL.Query().Get("force") != "true" {
+		http.Error(w, "TRASH_RETENTION_DAYS=0 purges the whole trash; pass force=true to confirm", http.StatusBadRequest)
+		return
+	}
+
+	blobs, err := models.NewBlobStore()
+	if err != nil {
+		log.Printf("trash purge failed during attachment store init err=%v", err)
+		http.Error(w, "Attachment storage unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	trash, cleanup, ok := newTrashService(w, blobs)
+	if !ok {
+		return
+	}
+	defer cleanup()
+
+	purged, err := trash.Purge(retention, time.Now().UTC())
+	if err != nil {
+		log.Printf("trash purge failed purged=%d err=%v", purged, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("trash purge completed purged=%d retention=%s", purged, retention)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"job":    "trash_purge",
+		"purged": purged,
+	})
+}
+
+// jobAuthMiddleware lets signed-in users and schedulers through. A scheduler
+// authenticates with "Authorization: Bearer <JOB_SECRET>"; without JOB_SECRET
+// set, only signed-in users can call the job.
+func jobAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if isAuthenticated(r) || hasJobSecret(r) {
+			next(w, r)
+			return
+		}
+		log.Printf("job request rejected path=%s remote_addr=%s", r.URL.Path, r.RemoteAddr)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+	}
+}
+
+func hasJobSecret(r *http.Request) bool {
+	secret := os.Getenv("JOB_SECRET")
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return secret != "" && ok && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// trashRetention reads TRASH_RETENTION_DAYS, falling back to the default for
+// missing or invalid values.
+func trashRetention() time.Duration {
+	if raw := os.Getenv("TRASH_RETENTION_DAYS"); raw != "" {
+		if days, err := strconv.Atoi(raw); err == nil && days >= 0 {
+			return time.Duration(days) * 24 * time.Hour
+		}
+		log.Printf("invalid TRASH_RETENTION_DAYS=%q, using default", raw)
+	}
+	return services.DefaultTrashRetention
+}
+
+func newTrashService(w http.ResponseWriter, blobs models.BlobStore) (*services.TrashService, func(), bool) {
+	dbClient, err := models.NewDatabaseClient()
+	if err != nil {
+		http.Error(w, "Database connection failed", http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	store, ok := dbClient.(models.TrashStore)
+	if !ok {
+		dbClient.Close()
+		http.Error(w, "trash not supported for this database backend", http.StatusNotImplemented)
+		return nil, nil, false
+	}
+
+	return services.NewTrashService(dbClient, store, blobs), func() {
+		dbClient.Close()
+	}, true
+}
@@ -204,6 +204,22 @@ func isAuthenticated(r *http.Request) bool {
 	return true
 }
 
+// sessionEmail returns the email of the signed-in user, or "" without a
+// valid session.
+func sessionEmail(r *http.Request) string {
+	cookie, err := r.Cookie("session")
+	if err != nil {
+		return ""
+	}
+	sessionsMu.RLock()
+	data, ok := sessions[cookie.Value]
+	sessionsMu.RUnlock()
+	if !ok || time.Now().After(data.ExpiresAt) {
+		return ""
+	}
+	return data.Email
+}
+
 func generateSessionToken() string {
 	b := make([]byte, 24)
 	rand.Read(b)
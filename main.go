@@ -30,6 +30,8 @@ func main() {
 	}
 	defer dbClient.Close()
 
+	models.RunStartupMigrations(dbClient)
+
 	stats, err := services.ProcessEmails(srv, "me", dbClient)
 	if err != nil {
 		log.Fatalf("Email sync failed: %v", err)
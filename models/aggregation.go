@@ -0,0 +1,684 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/utils"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/firestore/apiv1/firestorepb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/api/iterator"
+)
+
+// AggregateGroup is what AggregateTransactions groups by.
+type AggregateGroup string
+
+const (
+	// AggregateByNone returns a single total for the whole range.
+	AggregateByNone AggregateGroup = ""
+	// AggregateByCategory groups transactions with the same category lines
+	// (one line unless the transaction is split). Each group carries its
+	// lines with the amounts summed, so rollups stay exact for splits.
+	AggregateByCategory AggregateGroup = "category"
+	AggregateByType     AggregateGroup = "type"
+	// AggregateByVendor groups by vendor and stored canonical merchant.
+	AggregateByVendor AggregateGroup = "vendor"
+	// AggregateByDay groups by calendar day (YYYY-MM-DD) in the query's
+	// location.
+	AggregateByDay AggregateGroup = "day"
+	// AggregateByWeek, AggregateByMonth and AggregateByYear group by the
+	// Monday, first of the month or first of the year (YYYY-MM-DD) a
+	// transaction falls in, in the query's location.
+	AggregateByWeek  AggregateGroup = "week"
+	AggregateByMonth AggregateGroup = "month"
+	AggregateByYear  AggregateGroup = "year"
+	// AggregateByTag counts a transaction once for every tag it carries and
+	// leaves untagged transactions out.
+	AggregateByTag AggregateGroup = "tag"
+)
+
+// AggregateQuery selects the transactions to aggregate. Transactions linked
+// to a canonical record by reconciliation and those in the trash are always
+// left out.
+type AggregateQuery struct {
+	From    time.Time
+	To      time.Time
+	GroupBy AggregateGroup
+	// Location is the zone days are bucketed in, the user's zone when nil.
+	Location *time.Location
+	// Tags must all be present on a transaction for it to count.
+	Tags []string
+	// DebitsOnly leaves out credits.
+	DebitsOnly bool
+	// Split further divides day, week, month and year groups into series by
+	// category or type. Splitting by category counts a transaction once for
+	// every category line, with that line's amount.
+	Split AggregateGroup
+}
+
+// TransactionAggregate sums the transactions of one group.
+type TransactionAggregate struct {
+	// Key is the type, vendor, day or tag of the group; for categories it
+	// joins the line categories. Empty for AggregateByNone.
+	Key string `json:"key"`
+	// Merchant is the stored canonical merchant of a vendor group.
+	Merchant string `json:"merchant,omitempty"`
+	// Series is the category or type of a split group.
+	Series string `json:"series,omitempty"`
+	// Lines are the category lines of a category group.
+	Lines         []Split `json:"lines,omitempty"`
+	Count         int     `json:"count"`
+	Amount        Money   `json:"amount"`
+	Expense       Money   `json:"expense"`
+	Credit        Money   `json:"credit"`
+	PendingFX     int     `json:"pending_fx"` // foreign rows waiting for a rate, left out of the other figures
+	Uncategorized int     `json:"uncategorized"`
+}
+
+// AggregateStore is implemented by database backends that can total and
+// group transactions without returning every row.
+type AggregateStore interface {
+	AggregateTransactions(query AggregateQuery) ([]TransactionAggregate, error)
+}
+
+func (q AggregateQuery) location() *time.Location {
+	if q.Location != nil {
+		return q.Location
+	}
+	return utils.UserLocation()
+}
+
+// IsTimeGroup reports whether g buckets transactions by date.
+func (g AggregateGroup) IsTimeGroup() bool {
+	switch g {
+	case AggregateByDay, AggregateByWeek, AggregateByMonth, AggregateByYear:
+		return true
+	}
+	return false
+}
+
+// BucketStart returns the start of the day, week (Monday), month or year
+// that t falls in, in loc. Other groups return t unchanged.
+func (g AggregateGroup) BucketStart(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch g {
+	case AggregateByDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	case AggregateByWeek:
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, loc)
+	case AggregateByMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	case AggregateByYear:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, loc)
+	}
+	return t
+}
+
+// NextBucket returns the start of the bucket after the one starting at start.
+func (g AggregateGroup) NextBucket(start time.Time) time.Time {
+	switch g {
+	case AggregateByWeek:
+		return start.AddDate(0, 0, 7)
+	case AggregateByMonth:
+		return start.AddDate(0, 1, 0)
+	case AggregateByYear:
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+func (q AggregateQuery) validate() error {
+	switch q.GroupBy {
+	case AggregateByNone, AggregateByCategory, AggregateByType, AggregateByVendor, AggregateByTag:
+	default:
+		if !q.GroupBy.IsTimeGroup() {
+			return fmt.Errorf("unknown aggregate group %q", q.GroupBy)
+		}
+	}
+	switch q.Split {
+	case AggregateByNone:
+	case AggregateByCategory, AggregateByType:
+		if !q.GroupBy.IsTimeGroup() {
+			return fmt.Errorf("cannot split %q groups", q.GroupBy)
+		}
+	default:
+		return fmt.Errorf("cannot split by %q, expected category or type", q.Split)
+	}
+	return nil
+}
+
+// includes reports whether tx falls in the query.
+func (q AggregateQuery) includes(tx Transaction) bool {
+	if tx.DateTime.Before(q.From) || tx.DateTime.After(q.To) || tx.IsDuplicate() || tx.IsTrashed() {
+		return false
+	}
+	if q.DebitsOnly && tx.IsCredit() {
+		return false
+	}
+	for _, tag := range q.Tags {
+		if !tx.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupTransactions aggregates transactions already in memory the way
+// AggregateTransactions does in the database.
+func GroupTransactions(txs []Transaction, query AggregateQuery) []TransactionAggregate {
+	loc := query.location()
+	groups := aggregateGroups{}
+	for _, tx := range txs {
+		if !query.includes(tx) {
+			continue
+		}
+		// Rows waiting for a rate have no rupee amount yet; only the overall
+		// total reports them, as PendingFX.
+		if tx.NeedsConversion() && query.GroupBy != AggregateByNone {
+			continue
+		}
+		if query.GroupBy.IsTimeGroup() {
+			key := query.GroupBy.BucketStart(tx.DateTime, loc).Format("2006-01-02")
+			switch query.Split {
+			case AggregateByCategory:
+				for _, line := range tx.CategoryLines() {
+					lineTx := tx
+					lineTx.Amount = line.Amount
+					category := line.Category
+					if category == "" {
+						category = "Other"
+					}
+					groups.split(key, category).add(lineTx)
+				}
+			case AggregateByType:
+				groups.split(key, tx.Type).add(tx)
+			default:
+				groups.get(key).add(tx)
+			}
+			continue
+		}
+		switch query.GroupBy {
+		case AggregateByCategory:
+			lines := tx.CategoryLines()
+			categories := make([]string, len(lines))
+			for i, line := range lines {
+				categories[i] = line.Category
+			}
+			group := groups.category(categories)
+			for i, line := range lines {
+				group.Lines[i].Amount += line.Amount
+			}
+			group.add(tx)
+		case AggregateByTag:
+			for _, tag := range tx.Tags {
+				groups.get(tag).add(tx)
+			}
+		case AggregateByType:
+			groups.get(tx.Type).add(tx)
+		case AggregateByVendor:
+			groups.vendor(tx.Vendor, tx.Merchant).add(tx)
+		default:
+			groups.get("").add(tx)
+		}
+	}
+	return groups.list()
+}
+
+func (a *TransactionAggregate) add(tx Transaction) {
+	if tx.NeedsConversion() {
+		a.PendingFX++
+		return
+	}
+	a.Count++
+	a.Amount += tx.Amount
+	if tx.Amount > 0 {
+		a.Expense += tx.Amount
+	} else {
+		a.Credit -= tx.Amount
+	}
+	if IsUncategorized(tx.Category) {
+		a.Uncategorized++
+	}
+}
+
+// IsUncategorized reports whether category is the Other bucket: blank, or
+// Other in any case and with any surrounding space. Every backend counts
+// uncategorized transactions this way.
+func IsUncategorized(category string) bool {
+	category = strings.TrimSpace(category)
+	return category == "" || strings.EqualFold(category, "Other")
+}
+
+// mongoPendingFX is Transaction.NeedsConversion as an aggregation expression.
+var mongoPendingFX = bson.M{"$and": bson.A{
+	bson.M{"$not": bson.A{bson.M{"$in": bson.A{bson.M{"$ifNull": bson.A{"$currency", ""}}, bson.A{"", BaseCurrency}}}}},
+	bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$fxrate", 0}}, 0}},
+}}
+
+// mongoUncategorized is IsUncategorized as an aggregation expression.
+var mongoUncategorized = bson.M{"$in": bson.A{
+	bson.M{"$toLower": bson.M{"$trim": bson.M{"input": bson.M{"$ifNull": bson.A{"$category", ""}}}}},
+	bson.A{"", "other"},
+}}
+
+func (a *TransactionAggregate) merge(other TransactionAggregate) {
+	a.Count += other.Count
+	a.Amount += other.Amount
+	a.Expense += other.Expense
+	a.Credit += other.Credit
+	a.PendingFX += other.PendingFX
+	a.Uncategorized += other.Uncategorized
+}
+
+// aggregateGroups collects groups by key; vendor groups are also keyed by
+// merchant and split groups by series.
+type aggregateGroups map[[2]string]*TransactionAggregate
+
+func (g aggregateGroups) get(key string) *TransactionAggregate {
+	return g.vendor(key, "")
+}
+
+func (g aggregateGroups) vendor(vendor, merchant string) *TransactionAggregate {
+	group, ok := g[[2]string{vendor, merchant}]
+	if !ok {
+		group = &TransactionAggregate{Key: vendor, Merchant: merchant}
+		g[[2]string{vendor, merchant}] = group
+	}
+	return group
+}
+
+func (g aggregateGroups) split(key, series string) *TransactionAggregate {
+	group, ok := g[[2]string{key, series}]
+	if !ok {
+		group = &TransactionAggregate{Key: key, Series: series}
+		g[[2]string{key, series}] = group
+	}
+	return group
+}
+
+// category returns the group of transactions with these line categories;
+// empty categories count as "Other", as in CategoryLines.
+func (g aggregateGroups) category(categories []string) *TransactionAggregate {
+	normalized := make([]string, len(categories))
+	for i, category := range categories {
+		if category == "" {
+			category = "Other"
+		}
+		normalized[i] = category
+	}
+	group := g.get(strings.Join(normalized, ", "))
+	if group.Lines == nil {
+		group.Lines = make([]Split, len(normalized))
+		for i, category := range normalized {
+			group.Lines[i].Category = category
+		}
+	}
+	return group
+}
+
+func (g aggregateGroups) list() []TransactionAggregate {
+	list := make([]TransactionAggregate, 0, len(g))
+	for _, group := range g {
+		list = append(list, *group)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Key != list[j].Key {
+			return list[i].Key < list[j].Key
+		}
+		if list[i].Series != list[j].Series {
+			return list[i].Series < list[j].Series
+		}
+		return list[i].Merchant < list[j].Merchant
+	})
+	return list
+}
+
+// mongoAggregateRow is one row of the aggregation pipeline. Category rows are
+// per line; the transaction totals are taken from the first line's row.
+type mongoAggregateRow struct {
+	ID            bson.RawValue `bson:"_id"`
+	Count         int           `bson:"count"`
+	Amount        Money         `bson:"amount"`
+	Expense       Money         `bson:"expense"`
+	Credit        Money         `bson:"credit"`
+	PendingFX     int           `bson:"pendingfx"`
+	Uncategorized int           `bson:"uncategorized"`
+	LineAmount    Money         `bson:"lineamount"`
+}
+
+// AggregateTransactions totals and groups transactions with an aggregation
+// pipeline, so only one row per group leaves the database.
+func (m *MongoClient) AggregateTransactions(query AggregateQuery) ([]TransactionAggregate, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	match := bson.M{
+		"datetime":    bson.M{"$gte": query.From, "$lte": query.To},
+		"duplicateof": bson.M{"$in": bson.A{nil, ""}},
+		"deletedat":   nil,
+	}
+	if len(query.Tags) > 0 {
+		match["tags"] = bson.M{"$all": query.Tags}
+	}
+	if query.DebitsOnly {
+		match["amountpaise"] = bson.M{"$gte": 0}
+	}
+	if query.GroupBy != AggregateByNone {
+		// Rows waiting for a rate only count towards the overall total's
+		// PendingFX, as in GroupTransactions.
+		match["$nor"] = bson.A{bson.M{
+			"currency": bson.M{"$nin": bson.A{nil, "", BaseCurrency}},
+			"fxrate":   bson.M{"$in": bson.A{nil, 0}},
+		}}
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+
+	group := bson.M{
+		"count":     bson.M{"$sum": bson.M{"$cond": bson.A{mongoPendingFX, 0, 1}}},
+		"amount":    bson.M{"$sum": "$amountpaise"},
+		"expense":   bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$amountpaise", 0}}, "$amountpaise", 0}}},
+		"credit":    bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{"$amountpaise", 0}}, bson.M{"$subtract": bson.A{0, "$amountpaise"}}, 0}}},
+		"pendingfx": bson.M{"$sum": bson.M{"$cond": bson.A{mongoPendingFX, 1, 0}}},
+		"uncategorized": bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$and": bson.A{mongoUncategorized, bson.M{"$not": bson.A{mongoPendingFX}}}}, 1, 0,
+		}}},
+	}
+
+	unwindLines := []bson.D{
+		{{Key: "$set", Value: bson.M{"lines": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$splits", bson.A{}}}}, 0}},
+			"$splits",
+			bson.A{bson.M{"category": "$category", "amountpaise": "$amountpaise"}},
+		}}}}},
+		{{Key: "$set", Value: bson.M{"linekey": "$lines.category"}}},
+		{{Key: "$unwind", Value: bson.M{"path": "$lines", "includeArrayIndex": "line"}}},
+	}
+
+	switch query.GroupBy {
+	case AggregateByCategory:
+		for _, stage := range unwindLines {
+			pipeline = append(pipeline, stage)
+		}
+		group["_id"] = bson.M{"key": "$linekey", "line": "$line", "category": "$lines.category"}
+		group["lineamount"] = bson.M{"$sum": "$lines.amountpaise"}
+	case AggregateByTag:
+		pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: "$tags"}})
+		group["_id"] = "$tags"
+	case AggregateByType:
+		group["_id"] = "$type"
+	case AggregateByVendor:
+		group["_id"] = bson.M{"vendor": "$vendor", "merchant": "$merchant"}
+	case AggregateByDay, AggregateByWeek, AggregateByMonth, AggregateByYear:
+		timezone := query.location().String()
+		date := interface{}("$datetime")
+		if query.GroupBy != AggregateByDay {
+			date = bson.M{"$dateTrunc": bson.M{"date": "$datetime", "unit": string(query.GroupBy), "timezone": timezone, "startOfWeek": "monday"}}
+		}
+		key := bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": date, "timezone": timezone}}
+		switch query.Split {
+		case AggregateByCategory:
+			// Each line counts as a transaction with the line's amount.
+			for _, stage := range unwindLines {
+				pipeline = append(pipeline, stage)
+			}
+			pipeline = append(pipeline, bson.D{{Key: "$set", Value: bson.M{"amountpaise": "$lines.amountpaise"}}})
+			group["_id"] = bson.M{"key": key, "series": "$lines.category"}
+		case AggregateByType:
+			group["_id"] = bson.M{"key": key, "series": "$type"}
+		default:
+			group["_id"] = key
+		}
+	case AggregateByNone:
+		group["_id"] = nil
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$group", Value: group}})
+
+	cursor, err := m.Database.Collection("transactions").Aggregate(m.Ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate transactions: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var rows []mongoAggregateRow
+	if err := cursor.All(m.Ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction aggregates: %v", err)
+	}
+
+	groups := aggregateGroups{}
+	for _, row := range rows {
+		totals := TransactionAggregate{
+			Count: row.Count, Amount: row.Amount, Expense: row.Expense, Credit: row.Credit,
+			PendingFX: row.PendingFX, Uncategorized: row.Uncategorized,
+		}
+		switch query.GroupBy {
+		case AggregateByCategory:
+			var id struct {
+				Key      []string `bson:"key"`
+				Line     int      `bson:"line"`
+				Category string   `bson:"category"`
+			}
+			if err := row.ID.Unmarshal(&id); err != nil || id.Line >= len(id.Key) {
+				return nil, fmt.Errorf("failed to decode category aggregate: %v", err)
+			}
+			group := groups.category(id.Key)
+			group.Lines[id.Line].Amount += row.LineAmount
+			if id.Line == 0 {
+				group.merge(totals)
+			}
+		case AggregateByVendor:
+			var id struct {
+				Vendor   string `bson:"vendor"`
+				Merchant string `bson:"merchant"`
+			}
+			if err := row.ID.Unmarshal(&id); err != nil {
+				return nil, fmt.Errorf("failed to decode vendor aggregate: %v", err)
+			}
+			groups.vendor(id.Vendor, id.Merchant).merge(totals)
+		default:
+			if query.Split != AggregateByNone {
+				var id struct {
+					Key    string `bson:"key"`
+					Series string `bson:"series"`
+				}
+				if err := row.ID.Unmarshal(&id); err != nil {
+					return nil, fmt.Errorf("failed to decode split aggregate: %v", err)
+				}
+				if query.Split == AggregateByCategory && id.Series == "" {
+					id.Series = "Other"
+				}
+				groups.split(id.Key, id.Series).merge(totals)
+				continue
+			}
+			key, _ := row.ID.StringValueOK()
+			groups.get(key).merge(totals)
+		}
+	}
+	return groups.list(), nil
+}
+
+// aggregateFields are the transaction fields aggregation reads.
+var aggregateFields = []string{
+	"type", "vendor", "merchant", "amountpaise", "currency", "fxrate", "datetime",
+	"category", "splits", "tags", "duplicateof", "deletedat",
+}
+
+// AggregateTransactions totals and groups transactions. Firestore
+// aggregation queries can only count and sum, not group, so a plain total is
+// computed with them once every transaction stores deletedat and duplicateof
+// (see BackfillAggregateFields). Groups, tags and debits-only totals stream
+// the range with only the fields aggregation needs and group here, without a
+// row limit.
+func (f *FirestoreClient) AggregateTransactions(query AggregateQuery) ([]TransactionAggregate, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	if query.GroupBy == AggregateByNone && len(query.Tags) == 0 && !query.DebitsOnly {
+		backfilled, err := f.aggregateFieldsBackfilled()
+		if err != nil {
+			return nil, err
+		}
+		if backfilled {
+			total, err := f.aggregateTotal(query)
+			if err != nil {
+				return nil, err
+			}
+			if total.Count == 0 {
+				return []TransactionAggregate{}, nil
+			}
+			return []TransactionAggregate{total}, nil
+		}
+	}
+
+	iter := f.Client.Collection("transactions").
+		Where("datetime", ">=", query.From).
+		Where("datetime", "<=", query.To).
+		Select(aggregateFields...).
+		Documents(f.Ctx)
+	defer iter.Stop()
+
+	var txs []Transaction
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch transactions: %v", err)
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		txs = append(txs, tx)
+	}
+	return GroupTransactions(txs, query), nil
+}
+
+// aggregateTotal totals the live transactions of the range with aggregation
+// queries for the count and sums. The uncategorized and pending FX counts
+// come from one pass over just the category and currency fields, since
+// Firestore cannot compare a category case-insensitively.
+func (f *FirestoreClient) aggregateTotal(query AggregateQuery) (TransactionAggregate, error) {
+	live := f.Client.Collection("transactions").
+		Where("datetime", ">=", query.From).
+		Where("datetime", "<=", query.To).
+		Where("duplicateof", "==", "").
+		Where("deletedat", "==", nil)
+
+	var total TransactionAggregate
+	result, err := live.NewAggregationQuery().WithCount("count").WithSum("amountpaise", "amount").Get(f.Ctx)
+	if err != nil {
+		return total, fmt.Errorf("failed to total transactions: %v", err)
+	}
+	total.Count = int(aggregateValue(result["count"]))
+	total.Amount = Money(aggregateValue(result["amount"]))
+
+	credits := live.Where("amountpaise", "<", 0)
+	result, err = credits.NewAggregationQuery().WithSum("amountpaise", "credit").Get(f.Ctx)
+	if err != nil {
+		return total, fmt.Errorf("failed to total credits: %v", err)
+	}
+	total.Credit = -Money(aggregateValue(result["credit"]))
+	total.Expense = total.Amount + total.Credit
+
+	iter := live.Select("category", "currency", "fxrate").Documents(f.Ctx)
+	defer iter.Stop()
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return total, fmt.Errorf("failed to count uncategorized transactions: %v", err)
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return total, fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		if tx.NeedsConversion() {
+			total.PendingFX++
+		} else if IsUncategorized(tx.Category) {
+			total.Uncategorized++
+		}
+	}
+	// Rows waiting for a rate are reported as PendingFX rather than counted.
+	total.Count -= total.PendingFX
+	return total, nil
+}
+
+// aggregateValue reads a count or sum from an aggregation result. Sums come
+// back as doubles once they no longer fit an integer.
+func aggregateValue(value interface{}) int64 {
+	v, ok := value.(*firestorepb.Value)
+	if !ok {
+		return 0
+	}
+	if d, ok := v.GetValueType().(*firestorepb.Value_DoubleValue); ok {
+		return int64(math.Round(d.DoubleValue))
+	}
+	return v.GetIntegerValue()
+}
+
+// aggregateFieldsBackfilled reports whether every transaction stores
+// deletedat and duplicateof, so queries can filter on them.
+func (f *FirestoreClient) aggregateFieldsBackfilled() (bool, error) {
+	setting, err := f.GetSetting(SettingAggregateFieldsBackfilled)
+	return setting != nil, err
+}
+
+// BackfillAggregateFields stores an empty deletedat and duplicateof on
+// transactions written before they were kept, so aggregation queries that
+// filter on them see every transaction. It returns how many were updated.
+func (f *FirestoreClient) BackfillAggregateFields() (int, error) {
+	iter := f.Client.Collection("transactions").Documents(f.Ctx)
+	defer iter.Stop()
+
+	updated := 0
+	batch := f.Client.Batch()
+	batchSize := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return updated, fmt.Errorf("failed to iterate transactions: %v", err)
+		}
+
+		raw := doc.Data()
+		var updates []firestore.Update
+		if _, ok := raw["deletedat"]; !ok {
+			updates = append(updates, firestore.Update{Path: "deletedat", Value: nil})
+		}
+		if _, ok := raw["duplicateof"]; !ok {
+			updates = append(updates, firestore.Update{Path: "duplicateof", Value: ""})
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		batch.Update(doc.Ref, updates)
+		batchSize++
+		updated++
+
+		if batchSize >= 400 {
+			if _, err := batch.Commit(f.Ctx); err != nil {
+				return updated, fmt.Errorf("batch commit failed: %v", err)
+			}
+			batch = f.Client.Batch()
+			batchSize = 0
+		}
+	}
+	if batchSize > 0 {
+		if _, err := batch.Commit(f.Ctx); err != nil {
+			return updated, fmt.Errorf("batch commit failed: %v", err)
+		}
+	}
+	return updated, nil
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestGroupTransactionsByCategoryKeepsSplitLines(t *testing.T) {
+	day := time.Date(2026, 4, 19, 12, 0, 0, 0, time.UTC)
+	trashed := day
+	txs := []Transaction{
+		{Vendor: "BIGBASKET", Category: "Groceries", Amount: FromRupees(500), DateTime: day},
+		{Vendor: "DMART", Category: "Groceries", Amount: FromRupees(300), DateTime: day, Tags: []string{"home"}},
+		{Vendor: "AMAZON", Category: "Shopping", Amount: FromRupees(1000), DateTime: day, Splits: []Split{
+			{Category: "Shopping", Amount: FromRupees(600)},
+			{Category: "", Amount: FromRupees(400)},
+		}},
+		{Vendor: "REFUND", Category: "", Amount: FromRupees(-200), DateTime: day},
+		{Vendor: "GPAY", Category: "Groceries", Amount: FromRupees(500), DateTime: day, DuplicateOf: "bank-1"},
+		{Vendor: "OLD", Category: "Groceries", Amount: FromRupees(90), DateTime: day, DeletedAt: &trashed},
+		{Vendor: "LATER", Category: "Groceries", Amount: FromRupees(70), DateTime: day.AddDate(0, 1, 0)},
+	}
+	query := AggregateQuery{From: day.Add(-time.Hour), To: day.Add(time.Hour), GroupBy: AggregateByCategory}
+
+	groups := GroupTransactions(txs, query)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 category groups, got %+v", groups)
+	}
+	groceries, other, split := groups[0], groups[1], groups[2]
+	if groceries.Key != "Groceries" || groceries.Count != 2 || groceries.Lines[0].Amount != FromRupees(800) {
+		t.Fatalf("unexpected groceries group %+v", groceries)
+	}
+	if other.Key != "Other" || other.Credit != FromRupees(200) || other.Uncategorized != 1 {
+		t.Fatalf("unexpected credit group %+v", other)
+	}
+	if split.Key != "Shopping, Other" || split.Count != 1 || split.Lines[1].Amount != FromRupees(400) || split.Expense != FromRupees(1000) {
+		t.Fatalf("unexpected split group %+v", split)
+	}
+
+	query.GroupBy = AggregateByNone
+	query.Tags = []string{"home"}
+	total := GroupTransactions(txs, query)
+	if len(total) != 1 || total[0].Count != 1 || total[0].Amount != FromRupees(300) {
+		t.Fatalf("expected only the tagged transaction, got %+v", total)
+	}
+}
+
+func TestGroupTransactionsByDayUsesLocation(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+30*60)
+	lateNight := time.Date(2026, 4, 19, 1, 0, 0, 0, ist)
+	txs := []Transaction{{Vendor: "TOIT", Amount: FromRupees(2000), DateTime: lateNight}}
+
+	groups := GroupTransactions(txs, AggregateQuery{From: lateNight.Add(-time.Hour), To: lateNight, GroupBy: AggregateByDay, Location: ist})
+	if len(groups) != 1 || groups[0].Key != "2026-04-19" {
+		t.Fatalf("expected the dinner on 2026-04-19, got %+v", groups)
+	}
+}
+
+// TestUncategorizedCountAgreesAcrossBackends checks the in-memory count, the
+// predicate Firestore counts with and the Mongo expression agree.
+func TestUncategorizedCountAgreesAcrossBackends(t *testing.T) {
+	day := time.Date(2026, 4, 19, 12, 0, 0, 0, time.UTC)
+	categories := []string{"", "  ", "Other", "other", " OTHER ", "Food", "Others", "other stuff"}
+	want := map[string]bool{"": true, "  ": true, "Other": true, "other": true, " OTHER ": true}
+
+	var txs []Transaction
+	for _, category := range categories {
+		if IsUncategorized(category) != want[category] {
+			t.Fatalf("IsUncategorized(%q) = %t, want %t", category, !want[category], want[category])
+		}
+		doc := bson.M{"category": category}
+		if got := evalMongoExpr(t, mongoUncategorized, doc); got != want[category] {
+			t.Fatalf("mongo expression for %q = %v, want %t", category, got, want[category])
+		}
+		txs = append(txs, Transaction{Category: category, Amount: FromRupees(10), DateTime: day})
+	}
+	if got := evalMongoExpr(t, mongoUncategorized, bson.M{}); got != true {
+		t.Fatalf("expected a missing category to count as uncategorized in mongo, got %v", got)
+	}
+
+	totals := GroupTransactions(txs, AggregateQuery{From: day.Add(-time.Hour), To: day.Add(time.Hour), GroupBy: AggregateByNone})
+	if len(totals) != 1 || totals[0].Uncategorized != len(want) {
+		t.Fatalf("expected %d uncategorized in memory, got %+v", len(want), totals)
+	}
+}
+
+// evalMongoExpr evaluates the few aggregation operators the expressions in
+// this package use against doc. $let variables are kept in doc as "$name".
+func evalMongoExpr(t *testing.T, expr interface{}, doc bson.M) interface{} {
+	t.Helper()
+	switch e := expr.(type) {
+	case string:
+		if strings.HasPrefix(e, "$") {
+			return doc[strings.TrimPrefix(e, "$")]
+		}
+		return e
+	case bson.A:
+		values := make([]interface{}, len(e))
+		for i, item := range e {
+			values[i] = evalMongoExpr(t, item, doc)
+		}
+		return values
+	case int, float64:
+		return toFloat(e)
+	case bson.M:
+		for op, arg := range e {
+			switch op {
+			case "$let":
+				let := arg.(bson.M)
+				scope := bson.M{}
+				for k, v := range doc {
+					scope[k] = v
+				}
+				for name, value := range let["vars"].(bson.M) {
+					scope["$"+name] = evalMongoExpr(t, value, doc)
+				}
+				return evalMongoExpr(t, let["in"], scope)
+			case "$cond":
+				args := arg.(bson.A)
+				if evalMongoExpr(t, args[0], doc).(bool) {
+					return evalMongoExpr(t, args[1], doc)
+				}
+				return evalMongoExpr(t, args[2], doc)
+			case "$multiply", "$add", "$subtract", "$gte", "$lt":
+				args := evalMongoExpr(t, arg, doc).([]interface{})
+				a, b := toFloat(args[0]), toFloat(args[1])
+				switch op {
+				case "$multiply":
+					return a * b
+				case "$add":
+					return a + b
+				case "$subtract":
+					return a - b
+				case "$gte":
+					return a >= b
+				}
+				return a < b
+			case "$trunc":
+				return math.Trunc(toFloat(evalMongoExpr(t, arg, doc)))
+			case "$abs":
+				return math.Abs(toFloat(evalMongoExpr(t, arg, doc)))
+			case "$ifNull":
+				args := arg.(bson.A)
+				if value := evalMongoExpr(t, args[0], doc); value != nil {
+					return value
+				}
+				return evalMongoExpr(t, args[1], doc)
+			case "$trim":
+				return strings.TrimSpace(evalMongoExpr(t, arg.(bson.M)["input"], doc).(string))
+			case "$toLower":
+				return strings.ToLower(evalMongoExpr(t, arg, doc).(string))
+			case "$in":
+				args := arg.(bson.A)
+				value := evalMongoExpr(t, args[0], doc)
+				for _, candidate := range evalMongoExpr(t, args[1], doc).([]interface{}) {
+					if candidate == value {
+						return true
+					}
+				}
+				return false
+			}
+			t.Fatalf("unsupported operator %s", op)
+		}
+	}
+	t.Fatalf("unsupported expression %#v", expr)
+	return nil
+}
+
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return math.NaN()
+}
+
+func TestGroupTransactionsLeavesOutRowsWaitingForARate(t *testing.T) {
+	day := time.Date(2026, 4, 19, 12, 0, 0, 0, time.UTC)
+	txs := []Transaction{
+		{Category: "Food", Amount: FromRupees(300), DateTime: day},
+		{Category: "Travel", Currency: "USD", OriginalAmount: FromRupees(50), DateTime: day},
+		{Category: "Other", Currency: "EUR", OriginalAmount: FromRupees(20), FXRate: 90, Amount: FromRupees(1800), DateTime: day},
+	}
+	query := AggregateQuery{From: day.Add(-time.Hour), To: day.Add(time.Hour)}
+
+	totals := GroupTransactions(txs, query)
+	if len(totals) != 1 || totals[0].Count != 2 || totals[0].PendingFX != 1 || totals[0].Uncategorized != 1 {
+		t.Fatalf("expected the pending row only in PendingFX, got %+v", totals)
+	}
+
+	query.GroupBy = AggregateByCategory
+	for _, group := range GroupTransactions(txs, query) {
+		if group.Key == "Travel" {
+			t.Fatalf("expected no group for the pending row, got %+v", group)
+		}
+	}
+}
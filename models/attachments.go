@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment describes a receipt or invoice file. The content lives in a
+// BlobStore under Key; the metadata is embedded in the transaction.
+type Attachment struct {
+	ID          string    `bson:"id" firestore:"id" json:"id"`
+	FileName    string    `bson:"file_name" firestore:"file_name" json:"file_name"`
+	ContentType string    `bson:"content_type" firestore:"content_type" json:"content_type"`
+	Size        int64     `bson:"size" firestore:"size" json:"size"`
+	Key         string    `bson:"key" firestore:"key" json:"-"`
+	UploadedAt  time.Time `bson:"uploaded_at" firestore:"uploaded_at" json:"uploaded_at"`
+}
+
+// AttachmentStore is implemented by database backends that can keep
+// attachment metadata on a transaction.
+type AttachmentStore interface {
+	AddTransactionAttachment(id string, attachment Attachment) error
+	RemoveTransactionAttachment(id, attachmentID string) error
+}
+
+func (m *MongoClient) AddTransactionAttachment(id string, attachment Attachment) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %v", err)
+	}
+	update := bson.M{"$push": bson.M{"attachments": attachment}, "$inc": bson.M{"version": 1}}
+	if _, err := m.Database.Collection("transactions").UpdateOne(m.Ctx, bson.M{"_id": objID}, update); err != nil {
+		return fmt.Errorf("failed to add attachment: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) RemoveTransactionAttachment(id, attachmentID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %v", err)
+	}
+	update := bson.M{"$pull": bson.M{"attachments": bson.M{"id": attachmentID}}, "$inc": bson.M{"version": 1}}
+	if _, err := m.Database.Collection("transactions").UpdateOne(m.Ctx, bson.M{"_id": objID}, update); err != nil {
+		return fmt.Errorf("failed to remove attachment: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) AddTransactionAttachment(id string, attachment Attachment) error {
+	_, err := f.Client.Collection("transactions").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "attachments", Value: firestore.ArrayUnion(attachment)},
+		{Path: "version", Value: firestore.Increment(1)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add attachment: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) RemoveTransactionAttachment(id, attachmentID string) error {
+	ref := f.Client.Collection("transactions").Doc(id)
+	return f.Client.RunTransaction(f.Ctx, func(ctx context.Context, t *firestore.Transaction) error {
+		doc, err := t.Get(ref)
+		if err != nil {
+			return fmt.Errorf("failed to load transaction: %v", err)
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return fmt.Errorf("failed to decode transaction: %v", err)
+		}
+
+		remaining := make([]Attachment, 0, len(tx.Attachments))
+		for _, attachment := range tx.Attachments {
+			if attachment.ID != attachmentID {
+				remaining = append(remaining, attachment)
+			}
+		}
+		return t.Update(ref, []firestore.Update{
+			{Path: "attachments", Value: remaining},
+			{Path: "version", Value: firestore.Increment(1)},
+		})
+	})
+}
@@ -0,0 +1,195 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// Audit sources say where a change came from
+const (
+	AuditSourceUI     = "ui"
+	AuditSourceChat   = "chat"
+	AuditSourceRule   = "rule"
+	AuditSourceSystem = "system"
+)
+
+// Audit actions name the kind of change
+const (
+	AuditActionUpdate     = "update"
+	AuditActionSplit      = "split"
+	AuditActionTag        = "tag"
+	AuditActionAttachment = "attachment"
+	AuditActionMerge      = "merge"
+	AuditActionRevert     = "revert"
+	AuditActionDelete     = "delete"
+	AuditActionRestore    = "restore"
+)
+
+// TransactionChange is one entry of a transaction's append-only change log.
+// Before and After are full snapshots, so any recorded version can be
+// restored.
+type TransactionChange struct {
+	ID            string      `bson:"_id" firestore:"-" json:"id"`
+	TransactionID string      `bson:"transaction_id" firestore:"transaction_id" json:"transaction_id"`
+	Version       int64       `bson:"version" firestore:"version" json:"version"`
+	Action        string      `bson:"action" firestore:"action" json:"action"`
+	Source        string      `bson:"source" firestore:"source" json:"source"`
+	Actor         string      `bson:"actor,omitempty" firestore:"actor,omitempty" json:"actor,omitempty"`
+	Fields        []string    `bson:"fields" firestore:"fields" json:"fields"`
+	Before        Transaction `bson:"before" firestore:"before" json:"before"`
+	After         Transaction `bson:"after" firestore:"after" json:"after"`
+	ChangedAt     time.Time   `bson:"changed_at" firestore:"changed_at" json:"changed_at"`
+}
+
+// AuditStore is implemented by database backends that keep the change log.
+// Entries are only ever appended.
+type AuditStore interface {
+	SaveTransactionChange(change TransactionChange) error
+	ListTransactionChanges(transactionID string) ([]TransactionChange, error)
+	// ListTransactionChangesFor returns the change logs of several
+	// transactions by ID, each oldest first, in as few queries as possible.
+	ListTransactionChangesFor(transactionIDs []string) (map[string][]TransactionChange, error)
+}
+
+// firestoreInLimit is the most values a Firestore "in" filter accepts.
+const firestoreInLimit = 30
+
+func (m *MongoClient) SaveTransactionChange(change TransactionChange) error {
+	if change.ID == "" {
+		change.ID = primitive.NewObjectID().Hex()
+	}
+	if _, err := m.Database.Collection("transaction_changes").InsertOne(m.Ctx, change); err != nil {
+		return fmt.Errorf("failed to save transaction change: %v", err)
+	}
+	return nil
+}
+
+// ListTransactionChanges returns the change log of a transaction, oldest first
+func (m *MongoClient) ListTransactionChanges(transactionID string) ([]TransactionChange, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: 1}})
+	cursor, err := m.Database.Collection("transaction_changes").Find(m.Ctx, bson.M{"transaction_id": transactionID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction changes: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var changes []TransactionChange
+	if err := cursor.All(m.Ctx, &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction changes: %v", err)
+	}
+	for i := range changes {
+		changes[i].Before.ID = transactionID
+		changes[i].After.ID = transactionID
+	}
+	return changes, nil
+}
+
+// ListTransactionChangesFor returns the change logs of several transactions
+// with one query.
+func (m *MongoClient) ListTransactionChangesFor(transactionIDs []string) (map[string][]TransactionChange, error) {
+	byID := make(map[string][]TransactionChange, len(transactionIDs))
+	if len(transactionIDs) == 0 {
+		return byID, nil
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "changed_at", Value: 1}})
+	cursor, err := m.Database.Collection("transaction_changes").Find(m.Ctx, bson.M{"transaction_id": bson.M{"$in": transactionIDs}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction changes: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var changes []TransactionChange
+	if err := cursor.All(m.Ctx, &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction changes: %v", err)
+	}
+	for _, change := range changes {
+		change.Before.ID = change.TransactionID
+		change.After.ID = change.TransactionID
+		byID[change.TransactionID] = append(byID[change.TransactionID], change)
+	}
+	return byID, nil
+}
+
+func (f *FirestoreClient) SaveTransactionChange(change TransactionChange) error {
+	collection := f.Client.Collection("transaction_changes")
+	ref := collection.NewDoc()
+	if change.ID != "" {
+		ref = collection.Doc(change.ID)
+	}
+	if _, err := ref.Create(f.Ctx, change); err != nil {
+		return fmt.Errorf("failed to save transaction change: %v", err)
+	}
+	return nil
+}
+
+// ListTransactionChanges returns the change log of a transaction, oldest
+// first. Sorting happens here so no composite index is needed.
+func (f *FirestoreClient) ListTransactionChanges(transactionID string) ([]TransactionChange, error) {
+	iter := f.Client.Collection("transaction_changes").Where("transaction_id", "==", transactionID).Documents(f.Ctx)
+	defer iter.Stop()
+
+	var changes []TransactionChange
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch transaction changes: %v", err)
+		}
+		var change TransactionChange
+		if err := doc.DataTo(&change); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction change: %v", err)
+		}
+		change.ID = doc.Ref.ID
+		change.Before.ID = transactionID
+		change.After.ID = transactionID
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+	})
+	return changes, nil
+}
+
+// ListTransactionChangesFor returns the change logs of several transactions,
+// querying firestoreInLimit IDs at a time.
+func (f *FirestoreClient) ListTransactionChangesFor(transactionIDs []string) (map[string][]TransactionChange, error) {
+	byID := make(map[string][]TransactionChange, len(transactionIDs))
+	for start := 0; start < len(transactionIDs); start += firestoreInLimit {
+		chunk := transactionIDs[start:min(start+firestoreInLimit, len(transactionIDs))]
+		iter := f.Client.Collection("transaction_changes").Where("transaction_id", "in", chunk).Documents(f.Ctx)
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				iter.Stop()
+				return nil, fmt.Errorf("failed to fetch transaction changes: %v", err)
+			}
+			var change TransactionChange
+			if err := doc.DataTo(&change); err != nil {
+				iter.Stop()
+				return nil, fmt.Errorf("failed to decode transaction change: %v", err)
+			}
+			change.ID = doc.Ref.ID
+			change.Before.ID = change.TransactionID
+			change.After.ID = change.TransactionID
+			byID[change.TransactionID] = append(byID[change.TransactionID], change)
+		}
+		iter.Stop()
+	}
+	for _, changes := range byID {
+		sort.Slice(changes, func(i, j int) bool {
+			return changes[i].ChangedAt.Before(changes[j].ChangedAt)
+		})
+	}
+	return byID, nil
+}
@@ -0,0 +1,171 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Get for unknown keys.
+var ErrBlobNotFound = fmt.Errorf("blob not found")
+
+// BlobStore keeps attachment contents. Keys are slash-separated paths
+// such as "<transaction id>/<attachment id>".
+type BlobStore interface {
+	Put(key, contentType string, r io.Reader) (int64, error)
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// NewBlobStore creates the attachment store: a GCS bucket when
+// ATTACHMENTS_BUCKET is set, the local filesystem under ATTACHMENTS_DIR
+// (default data/attachments) otherwise. STORAGE_EMULATOR_HOST points the GCS
+// store at a compatible server such as fake-gcs-server.
+func NewBlobStore() (BlobStore, error) {
+	if bucket := os.Getenv("ATTACHMENTS_BUCKET"); bucket != "" {
+		return NewGCSBlobStore(context.Background(), bucket, os.Getenv("STORAGE_EMULATOR_HOST"))
+	}
+
+	dir := os.Getenv("ATTACHMENTS_DIR")
+	if dir == "" {
+		dir = filepath.Join("data", "attachments")
+	}
+	return NewLocalBlobStore(dir)
+}
+
+// LocalBlobStore stores blobs as files below a root directory
+type LocalBlobStore struct {
+	Root string
+}
+
+func NewLocalBlobStore(root string) (*LocalBlobStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create attachment directory: %v", err)
+	}
+	return &LocalBlobStore{Root: root}, nil
+}
+
+func (l *LocalBlobStore) path(key string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(key))
+	if cleaned == "." || filepath.IsAbs(cleaned) || strings.HasPrefix(cleaned, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(l.Root, cleaned), nil
+}
+
+func (l *LocalBlobStore) Put(key, contentType string, r io.Reader) (int64, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, fmt.Errorf("failed to create blob directory: %v", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create blob: %v", err)
+	}
+	size, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, fmt.Errorf("failed to write blob: %v", err)
+	}
+	return size, nil
+}
+
+func (l *LocalBlobStore) Get(key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %v", err)
+	}
+	return file, nil
+}
+
+func (l *LocalBlobStore) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete blob: %v", err)
+	}
+	return nil
+}
+
+// GCSBlobStore stores blobs as objects in a Google Cloud Storage bucket
+type GCSBlobStore struct {
+	Bucket  string
+	service *storage.Service
+	ctx     context.Context
+}
+
+// NewGCSBlobStore uses application default credentials, or no credentials
+// when an emulator endpoint is given.
+func NewGCSBlobStore(ctx context.Context, bucket, emulatorHost string) (*GCSBlobStore, error) {
+	var opts []option.ClientOption
+	if emulatorHost != "" {
+		if !strings.HasPrefix(emulatorHost, "http") {
+			emulatorHost = "http://" + emulatorHost
+		}
+		opts = append(opts,
+			option.WithEndpoint(strings.TrimSuffix(emulatorHost, "/")+"/storage/v1/"),
+			option.WithoutAuthentication(),
+		)
+	}
+
+	service, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage client: %v", err)
+	}
+	return &GCSBlobStore{Bucket: bucket, service: service, ctx: ctx}, nil
+}
+
+func (g *GCSBlobStore) Put(key, contentType string, r io.Reader) (int64, error) {
+	object := &storage.Object{Name: key, ContentType: contentType}
+	created, err := g.service.Objects.Insert(g.Bucket, object).Media(r, googleapi.ContentType(contentType)).Context(g.ctx).Do()
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload object %s: %v", key, err)
+	}
+	return int64(created.Size), nil
+}
+
+func (g *GCSBlobStore) Get(key string) (io.ReadCloser, error) {
+	resp, err := g.service.Objects.Get(g.Bucket, key).Context(g.ctx).Download()
+	if err != nil {
+		if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+			return nil, ErrBlobNotFound
+		}
+		return nil, fmt.Errorf("failed to download object %s: %v", key, err)
+	}
+	return resp.Body, nil
+}
+
+func (g *GCSBlobStore) Delete(key string) error {
+	err := g.service.Objects.Delete(g.Bucket, key).Context(g.ctx).Do()
+	if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %v", key, err)
+	}
+	return nil
+}
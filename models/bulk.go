@@ -0,0 +1,50 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// BulkStore is implemented by database backends that can update many
+// transactions in one batched write. The int results are the number of
+// transactions written.
+type BulkStore interface {
+	SetTransactionsCategory(ids []string, category string) (int, error)
+	TrashTransactions(ids []string, deletedAt time.Time) (int, error)
+}
+
+func (m *MongoClient) SetTransactionsCategory(ids []string, category string) (int, error) {
+	return m.updateTransactionsByID(ids, bson.M{"$set": bson.M{"category": category}, "$inc": bson.M{"version": 1}})
+}
+
+func (m *MongoClient) TrashTransactions(ids []string, deletedAt time.Time) (int, error) {
+	return m.updateTransactionsByID(ids, bson.M{"$set": bson.M{"deletedat": deletedAt}, "$inc": bson.M{"version": 1}})
+}
+
+func (m *MongoClient) updateTransactionsByID(ids []string, update bson.M) (int, error) {
+	objIDs, err := mongoObjectIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+	result, err := m.Database.Collection("transactions").UpdateMany(m.Ctx, bson.M{"_id": bson.M{"$in": objIDs}}, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update transactions: %v", err)
+	}
+	return int(result.MatchedCount), nil
+}
+
+func (f *FirestoreClient) SetTransactionsCategory(ids []string, category string) (int, error) {
+	if err := f.updateTransactionsByID(ids, "category", category); err != nil {
+		return 0, fmt.Errorf("failed to update transaction categories: %v", err)
+	}
+	return len(ids), nil
+}
+
+func (f *FirestoreClient) TrashTransactions(ids []string, deletedAt time.Time) (int, error) {
+	if err := f.updateTransactionsByID(ids, "deletedat", deletedAt); err != nil {
+		return 0, fmt.Errorf("failed to trash transactions: %v", err)
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// Category is a node in the category tree, such as "Dining Out" under
+// "Food". Transactions and rules name categories by Name; categories that are
+// not stored are treated as top-level categories without children.
+type Category struct {
+	ID   string `bson:"_id" firestore:"-" json:"id"`
+	Name string `bson:"name" firestore:"name" json:"name"`
+	// Parent is the name of the parent category, empty for a top-level one.
+	Parent    string    `bson:"parent,omitempty" firestore:"parent,omitempty" json:"parent,omitempty"`
+	CreatedAt time.Time `bson:"created_at" firestore:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" firestore:"updated_at" json:"updated_at"`
+}
+
+// CategoryStore is implemented by database backends that keep the category
+// tree. ListCategoryMappings lets category edits find the vendor mappings
+// they have to rewrite.
+type CategoryStore interface {
+	ListCategories() ([]Category, error)
+	SaveCategory(category Category) error
+	DeleteCategory(id string) error
+	ListCategoryMappings() ([]CategoryMapping, error)
+}
+
+func (m *MongoClient) ListCategories() ([]Category, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := m.Database.Collection("categories").Find(m.Ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch categories: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var categories []Category
+	if err := cursor.All(m.Ctx, &categories); err != nil {
+		return nil, fmt.Errorf("failed to decode categories: %v", err)
+	}
+	return categories, nil
+}
+
+// SaveCategory creates or replaces a category
+func (m *MongoClient) SaveCategory(category Category) error {
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.Database.Collection("categories").ReplaceOne(m.Ctx, bson.M{"_id": category.ID}, category, opts); err != nil {
+		return fmt.Errorf("failed to save category: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) DeleteCategory(id string) error {
+	result, err := m.Database.Collection("categories").DeleteOne(m.Ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("category %s not found", id)
+	}
+	return nil
+}
+
+func (m *MongoClient) ListCategoryMappings() ([]CategoryMapping, error) {
+	cursor, err := m.Database.Collection("category_mappings").Find(m.Ctx, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch category mappings: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var mappings []CategoryMapping
+	if err := cursor.All(m.Ctx, &mappings); err != nil {
+		return nil, fmt.Errorf("failed to decode category mappings: %v", err)
+	}
+	return mappings, nil
+}
+
+func (f *FirestoreClient) ListCategories() ([]Category, error) {
+	iter := f.Client.Collection("categories").OrderBy("name", firestore.Asc).Documents(f.Ctx)
+	defer iter.Stop()
+
+	var categories []Category
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch categories: %v", err)
+		}
+		var category Category
+		if err := doc.DataTo(&category); err != nil {
+			return nil, fmt.Errorf("failed to decode category: %v", err)
+		}
+		category.ID = doc.Ref.ID
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+// SaveCategory creates or replaces a category
+func (f *FirestoreClient) SaveCategory(category Category) error {
+	if _, err := f.Client.Collection("categories").Doc(category.ID).Set(f.Ctx, category); err != nil {
+		return fmt.Errorf("failed to save category: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) DeleteCategory(id string) error {
+	ref := f.Client.Collection("categories").Doc(id)
+	if _, err := ref.Get(f.Ctx); err != nil {
+		return fmt.Errorf("category %s not found", id)
+	}
+	if _, err := ref.Delete(f.Ctx); err != nil {
+		return fmt.Errorf("failed to delete category: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) ListCategoryMappings() ([]CategoryMapping, error) {
+	iter := f.Client.Collection("category_mappings").Documents(f.Ctx)
+	defer iter.Stop()
+
+	var mappings []CategoryMapping
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch category mappings: %v", err)
+		}
+		var mapping CategoryMapping
+		if err := doc.DataTo(&mapping); err != nil {
+			return nil, fmt.Errorf("failed to decode category mapping: %v", err)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
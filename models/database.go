@@ -1,15 +1,24 @@
 package models
 
 import (
+	"errors"
 	"os"
 	"strings"
 	"time"
 )
 
+// ErrVersionConflict is returned by PatchTransaction when the stored
+// transaction no longer has the version the caller read.
+var ErrVersionConflict = errors.New("transaction was modified by someone else")
+
 // DatabaseClient interface defines the common operations for database access
 type DatabaseClient interface {
 	SaveTransaction(txn Transaction) error
+	GetTransaction(id string) (*Transaction, error)
 	UpdateTransaction(id string, txn Transaction) error
+	// PatchTransaction writes only the fields set in patch if the stored
+	// version still equals expectedVersion, and returns the updated transaction.
+	PatchTransaction(id string, patch TransactionPatch, expectedVersion int64) (*Transaction, error)
 	DeleteTransaction(id string) error
 	FetchTransactionsByDateRange(from, to time.Time) ([]Transaction, error)
 	GetLatestTransactionTimeByType(txType string) (*time.Time, error)
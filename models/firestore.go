@@ -76,6 +76,20 @@ func (f *FirestoreClient) SaveTransactions(txns []Transaction) error {
 	return nil
 }
 
+// GetTransaction fetches a single transaction by ID
+func (f *FirestoreClient) GetTransaction(id string) (*Transaction, error) {
+	doc, err := f.Client.Collection("transactions").Doc(id).Get(f.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find transaction: %v", err)
+	}
+
+	var tx Transaction
+	if err := doc.DataTo(&tx); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %v", err)
+	}
+	tx.ID = doc.Ref.ID
+	return &tx, nil
+}
 
 func (f *FirestoreClient) FetchTransactionsByDateRange(from, to time.Time) ([]Transaction, error) {
 	var txs []Transaction
@@ -107,8 +121,11 @@ func (f *FirestoreClient) UpdateTransaction(id string, tx Transaction) error {
 	_, err := f.Client.Collection("transactions").Doc(id).Update(f.Ctx, []firestore.Update{
 		{Path: "type", Value: tx.Type},
 		{Path: "vendor", Value: tx.Vendor},
-		{Path: "amount", Value: tx.Amount},
+		{Path: "merchant", Value: tx.Merchant},
+		{Path: "amountpaise", Value: tx.Amount},
 		{Path: "category", Value: tx.Category},
+		{Path: "notes", Value: tx.Notes},
+		{Path: "version", Value: firestore.Increment(1)},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %v", err)
@@ -116,6 +133,41 @@ func (f *FirestoreClient) UpdateTransaction(id string, tx Transaction) error {
 	return nil
 }
 
+// PatchTransaction updates the given fields if the version still matches
+func (f *FirestoreClient) PatchTransaction(id string, patch TransactionPatch, expectedVersion int64) (*Transaction, error) {
+	ref := f.Client.Collection("transactions").Doc(id)
+	var updated Transaction
+	err := f.Client.RunTransaction(f.Ctx, func(ctx context.Context, t *firestore.Transaction) error {
+		doc, err := t.Get(ref)
+		if err != nil {
+			return fmt.Errorf("failed to find transaction: %v", err)
+		}
+		if err := doc.DataTo(&updated); err != nil {
+			return fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		if updated.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		updates := []firestore.Update{{Path: "version", Value: expectedVersion + 1}}
+		for path, value := range patch.Fields() {
+			if value == nil {
+				value = firestore.Delete
+			}
+			updates = append(updates, firestore.Update{Path: path, Value: value})
+		}
+		patch.Apply(&updated)
+		updated.Version = expectedVersion + 1
+		return t.Update(ref, updates)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated.ID = id
+	return &updated, nil
+}
+
 func (f *FirestoreClient) DeleteTransaction(id string) error {
 	_, err := f.Client.Collection("transactions").Doc(id).Delete(f.Ctx)
 	if err != nil {
@@ -250,6 +302,77 @@ func (f *FirestoreClient) MigrateFieldNames() (int, int, error) {
 	return migrated, skipped, nil
 }
 
+// MigrateAmountsToPaise converts the legacy float rupee "amount" field into the
+// integer "amountpaise" field. Run MigrateFieldNames first for documents that
+// still use "Amount".
+func (f *FirestoreClient) MigrateAmountsToPaise() (int, int, error) {
+	iter := f.Client.Collection("transactions").Documents(f.Ctx)
+	defer iter.Stop()
+
+	migrated, skipped := 0, 0
+	batch := f.Client.Batch()
+	batchSize := 0
+
+	flush := func() error {
+		if batchSize == 0 {
+			return nil
+		}
+		if _, err := batch.Commit(f.Ctx); err != nil {
+			return fmt.Errorf("batch commit failed: %v", err)
+		}
+		batch = f.Client.Batch()
+		batchSize = 0
+		return nil
+	}
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return migrated, skipped, fmt.Errorf("failed to iterate transactions: %v", err)
+		}
+
+		raw := doc.Data()
+		legacy, hasLegacy := raw["amount"]
+		if _, hasPaise := raw["amountpaise"]; hasPaise || !hasLegacy {
+			skipped++
+			continue
+		}
+
+		var rupees float64
+		switch v := legacy.(type) {
+		case float64:
+			rupees = v
+		case int64:
+			rupees = float64(v)
+		default:
+			skipped++
+			continue
+		}
+
+		batch.Update(doc.Ref, []firestore.Update{
+			{Path: "amountpaise", Value: FromRupees(rupees)},
+			{Path: "amount", Value: firestore.Delete},
+		})
+		batchSize++
+		migrated++
+
+		if batchSize >= 400 {
+			if err := flush(); err != nil {
+				return migrated, skipped, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return migrated, skipped, err
+	}
+
+	return migrated, skipped, nil
+}
+
 // GetCategoryMapping retrieves a vendor-to-category mapping from Firestore
 func (f *FirestoreClient) GetCategoryMapping(vendor string) (*CategoryMapping, error) {
 	vendor = strings.ToLower(vendor)
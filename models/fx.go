@@ -0,0 +1,210 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// BaseCurrency is the currency all reporting aggregates in.
+const BaseCurrency = "INR"
+
+// FXRate is the number of rupees one unit of Currency was worth from Date on.
+type FXRate struct {
+	Currency string    `bson:"currency" firestore:"currency" json:"currency"`
+	Date     time.Time `bson:"date" firestore:"date" json:"date"`
+	Rate     float64   `bson:"rate" firestore:"rate" json:"rate"`
+}
+
+// FXRateStore is implemented by database backends that keep the local FX
+// rate table and can rewrite a transaction's converted amount.
+type FXRateStore interface {
+	SaveFXRates(rates []FXRate) error
+	GetFXRate(currency string, on time.Time) (*FXRate, error)
+	ListFXRates(currency string) ([]FXRate, error)
+	SetConvertedAmount(id string, amount Money, rate float64) error
+}
+
+// NormalizeCurrency maps the currency markers used in bank alerts to ISO codes.
+func NormalizeCurrency(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(strings.TrimSuffix(code, ".")))
+	switch code {
+	case "", "RS", "INR", "₹":
+		return BaseCurrency
+	case "$":
+		return "USD"
+	case "€":
+		return "EUR"
+	case "£":
+		return "GBP"
+	}
+	return code
+}
+
+func fxRateDocID(rate FXRate) string {
+	return rate.Currency + "_" + rate.Date.Format("2006-01-02")
+}
+
+// SaveFXRates upserts rates keyed by currency and date
+func (m *MongoClient) SaveFXRates(rates []FXRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	writes := make([]mongo.WriteModel, 0, len(rates))
+	for _, rate := range rates {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": fxRateDocID(rate)}).
+			SetUpdate(bson.M{"$set": rate}).
+			SetUpsert(true))
+	}
+
+	if _, err := m.Database.Collection("fx_rates").BulkWrite(m.Ctx, writes); err != nil {
+		return fmt.Errorf("failed to save fx rates: %v", err)
+	}
+	return nil
+}
+
+// GetFXRate returns the most recent rate on or before the given time
+func (m *MongoClient) GetFXRate(currency string, on time.Time) (*FXRate, error) {
+	filter := bson.M{"currency": currency, "date": bson.M{"$lte": on}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "date", Value: -1}})
+
+	var rate FXRate
+	if err := m.Database.Collection("fx_rates").FindOne(m.Ctx, filter, opts).Decode(&rate); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to find fx rate for %s: %v", currency, err)
+	}
+	return &rate, nil
+}
+
+func (m *MongoClient) ListFXRates(currency string) ([]FXRate, error) {
+	filter := bson.M{}
+	if currency != "" {
+		filter["currency"] = currency
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "currency", Value: 1}, {Key: "date", Value: -1}})
+	cursor, err := m.Database.Collection("fx_rates").Find(m.Ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch fx rates: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var rates []FXRate
+	if err := cursor.All(m.Ctx, &rates); err != nil {
+		return nil, fmt.Errorf("failed to decode fx rates: %v", err)
+	}
+	return rates, nil
+}
+
+// SetConvertedAmount stores the rupee amount of a foreign-currency transaction
+func (m *MongoClient) SetConvertedAmount(id string, amount Money, rate float64) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %v", err)
+	}
+	update := bson.M{"$set": bson.M{"amountpaise": amount, "fxrate": rate}}
+	if _, err := m.Database.Collection("transactions").UpdateOne(m.Ctx, bson.M{"_id": objID}, update); err != nil {
+		return fmt.Errorf("failed to update converted amount: %v", err)
+	}
+	return nil
+}
+
+// SaveFXRates writes rates keyed by currency and date
+func (f *FirestoreClient) SaveFXRates(rates []FXRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	batch := f.Client.Batch()
+	batchSize := 0
+	for _, rate := range rates {
+		batch.Set(f.Client.Collection("fx_rates").Doc(fxRateDocID(rate)), rate)
+		batchSize++
+		if batchSize >= 400 {
+			if _, err := batch.Commit(f.Ctx); err != nil {
+				return fmt.Errorf("failed to save fx rates: %v", err)
+			}
+			batch = f.Client.Batch()
+			batchSize = 0
+		}
+	}
+	if batchSize > 0 {
+		if _, err := batch.Commit(f.Ctx); err != nil {
+			return fmt.Errorf("failed to save fx rates: %v", err)
+		}
+	}
+	return nil
+}
+
+// GetFXRate returns the most recent rate on or before the given time
+func (f *FirestoreClient) GetFXRate(currency string, on time.Time) (*FXRate, error) {
+	iter := f.Client.Collection("fx_rates").
+		Where("currency", "==", currency).
+		Where("date", "<=", on).
+		OrderBy("date", firestore.Desc).
+		Limit(1).
+		Documents(f.Ctx)
+	defer iter.Stop()
+
+	doc, err := iter.Next()
+	if err == iterator.Done {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find fx rate for %s: %v", currency, err)
+	}
+
+	var rate FXRate
+	if err := doc.DataTo(&rate); err != nil {
+		return nil, fmt.Errorf("failed to decode fx rate: %v", err)
+	}
+	return &rate, nil
+}
+
+func (f *FirestoreClient) ListFXRates(currency string) ([]FXRate, error) {
+	query := f.Client.Collection("fx_rates").Query
+	if currency != "" {
+		query = query.Where("currency", "==", currency)
+	}
+	iter := query.Documents(f.Ctx)
+	defer iter.Stop()
+
+	var rates []FXRate
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch fx rates: %v", err)
+		}
+		var rate FXRate
+		if err := doc.DataTo(&rate); err != nil {
+			return nil, fmt.Errorf("failed to decode fx rate: %v", err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// SetConvertedAmount stores the rupee amount of a foreign-currency transaction
+func (f *FirestoreClient) SetConvertedAmount(id string, amount Money, rate float64) error {
+	_, err := f.Client.Collection("transactions").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "amountpaise", Value: amount},
+		{Path: "fxrate", Value: rate},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update converted amount: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// Merchant is a canonical merchant that many raw vendor strings resolve to,
+// such as "Myntra" for "WWW MYNTRA COM" and "MYNTRA DESIGNS".
+type Merchant struct {
+	ID   string `bson:"_id" firestore:"-" json:"id"`
+	Name string `bson:"name" firestore:"name" json:"name"`
+	// Aliases are vendor strings that name the merchant exactly, compared
+	// case-insensitively and ignoring punctuation.
+	Aliases []string `bson:"aliases,omitempty" firestore:"aliases,omitempty" json:"aliases,omitempty"`
+	// Patterns are case-insensitive regular expressions matched against the
+	// vendor.
+	Patterns []string `bson:"patterns,omitempty" firestore:"patterns,omitempty" json:"patterns,omitempty"`
+	// DefaultCategory is used for the merchant's transactions when no rule
+	// or manual mapping decides their category.
+	DefaultCategory string    `bson:"default_category,omitempty" firestore:"default_category,omitempty" json:"default_category,omitempty"`
+	CreatedAt       time.Time `bson:"created_at" firestore:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `bson:"updated_at" firestore:"updated_at" json:"updated_at"`
+}
+
+// MerchantStore is implemented by database backends that keep the merchant
+// registry. SetTransactionsMerchant returns the number of transactions
+// written; the merchant is derived from the vendor, so it leaves their
+// version alone.
+type MerchantStore interface {
+	ListMerchants() ([]Merchant, error)
+	SaveMerchant(merchant Merchant) error
+	DeleteMerchant(id string) error
+	SetTransactionsMerchant(ids []string, merchant string) (int, error)
+}
+
+func (m *MongoClient) ListMerchants() ([]Merchant, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := m.Database.Collection("merchants").Find(m.Ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch merchants: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var merchants []Merchant
+	if err := cursor.All(m.Ctx, &merchants); err != nil {
+		return nil, fmt.Errorf("failed to decode merchants: %v", err)
+	}
+	return merchants, nil
+}
+
+// SaveMerchant creates or replaces a merchant
+func (m *MongoClient) SaveMerchant(merchant Merchant) error {
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.Database.Collection("merchants").ReplaceOne(m.Ctx, bson.M{"_id": merchant.ID}, merchant, opts); err != nil {
+		return fmt.Errorf("failed to save merchant: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) DeleteMerchant(id string) error {
+	result, err := m.Database.Collection("merchants").DeleteOne(m.Ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete merchant: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("merchant %s not found", id)
+	}
+	return nil
+}
+
+func (m *MongoClient) SetTransactionsMerchant(ids []string, merchant string) (int, error) {
+	return m.updateTransactionsByID(ids, bson.M{"$set": bson.M{"merchant": merchant}})
+}
+
+func (f *FirestoreClient) ListMerchants() ([]Merchant, error) {
+	iter := f.Client.Collection("merchants").OrderBy("name", firestore.Asc).Documents(f.Ctx)
+	defer iter.Stop()
+
+	var merchants []Merchant
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch merchants: %v", err)
+		}
+		var merchant Merchant
+		if err := doc.DataTo(&merchant); err != nil {
+			return nil, fmt.Errorf("failed to decode merchant: %v", err)
+		}
+		merchant.ID = doc.Ref.ID
+		merchants = append(merchants, merchant)
+	}
+	return merchants, nil
+}
+
+// SaveMerchant creates or replaces a merchant
+func (f *FirestoreClient) SaveMerchant(merchant Merchant) error {
+	if _, err := f.Client.Collection("merchants").Doc(merchant.ID).Set(f.Ctx, merchant); err != nil {
+		return fmt.Errorf("failed to save merchant: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) DeleteMerchant(id string) error {
+	ref := f.Client.Collection("merchants").Doc(id)
+	if _, err := ref.Get(f.Ctx); err != nil {
+		return fmt.Errorf("merchant %s not found", id)
+	}
+	if _, err := ref.Delete(f.Ctx); err != nil {
+		return fmt.Errorf("failed to delete merchant: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) SetTransactionsMerchant(ids []string, merchant string) (int, error) {
+	if err := f.applyTransactionUpdates(ids, []firestore.Update{{Path: "merchant", Value: merchant}}); err != nil {
+		return 0, fmt.Errorf("failed to update transaction merchants: %v", err)
+	}
+	return len(ids), nil
+}
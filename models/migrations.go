@@ -0,0 +1,93 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// RunStartupMigrations converts legacy float amounts so they do not read as
+// zero and backfills the fields Firestore totals filter on. Failures are
+// logged rather than returned, so the caller still starts; amounts can be
+// retried through /api/admin/migrate-amounts and the backfill runs again on
+// the next start.
+func RunStartupMigrations(client DatabaseClient) {
+	if err := EnsureAmountsMigrated(client); err != nil {
+		log.Printf("amount migration failed err=%v", err)
+	}
+	if err := EnsureAggregateFieldsBackfilled(client); err != nil {
+		log.Printf("aggregate field backfill failed err=%v", err)
+	}
+}
+
+// AmountMigrator is implemented by database backends that can convert the
+// legacy float rupee "amount" field into "amountpaise".
+type AmountMigrator interface {
+	MigrateAmountsToPaise() (int, int, error)
+}
+
+// EnsureAmountsMigrated converts legacy amounts unless the settings record
+// that it already finished. Transactions are only read from "amountpaise",
+// so unconverted documents would otherwise count as ₹0. Backends without
+// settings are migrated every time; the migration skips converted documents.
+func EnsureAmountsMigrated(client DatabaseClient) error {
+	migrator, ok := client.(AmountMigrator)
+	if !ok {
+		return nil
+	}
+	settings, _ := client.(SettingsStore)
+	if settings != nil {
+		done, err := settings.GetSetting(SettingAmountsMigrated)
+		if err != nil {
+			return err
+		}
+		if done != nil {
+			return nil
+		}
+	}
+
+	migrated, skipped, err := migrator.MigrateAmountsToPaise()
+	if err != nil {
+		return fmt.Errorf("amount migration failed after %d documents: %v", migrated, err)
+	}
+	log.Printf("amount migration complete migrated=%d skipped=%d", migrated, skipped)
+	if settings != nil {
+		return settings.SaveSetting(Setting{Key: SettingAmountsMigrated, Value: "true", UpdatedAt: time.Now().UTC()})
+	}
+	return nil
+}
+
+// AggregateFieldsBackfiller is implemented by database backends whose
+// aggregation queries need deletedat and duplicateof stored on every
+// transaction.
+type AggregateFieldsBackfiller interface {
+	BackfillAggregateFields() (int, error)
+}
+
+// EnsureAggregateFieldsBackfilled stores the empty fields on older
+// transactions unless the settings record that it already finished. Totals
+// only use aggregation queries once that record exists.
+func EnsureAggregateFieldsBackfilled(client DatabaseClient) error {
+	backfiller, ok := client.(AggregateFieldsBackfiller)
+	if !ok {
+		return nil
+	}
+	settings, ok := client.(SettingsStore)
+	if !ok {
+		return nil
+	}
+	done, err := settings.GetSetting(SettingAggregateFieldsBackfilled)
+	if err != nil {
+		return err
+	}
+	if done != nil {
+		return nil
+	}
+
+	updated, err := backfiller.BackfillAggregateFields()
+	if err != nil {
+		return fmt.Errorf("aggregate field backfill failed after %d documents: %v", updated, err)
+	}
+	log.Printf("aggregate field backfill complete updated=%d", updated)
+	return settings.SaveSetting(Setting{Key: SettingAggregateFieldsBackfilled, Value: "true", UpdatedAt: time.Now().UTC()})
+}
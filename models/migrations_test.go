@@ -0,0 +1,60 @@
+package models
+
+import "testing"
+
+type migrationTestDB struct {
+	DatabaseClient
+	runs      int
+	backfills int
+	settings  map[string]Setting
+}
+
+func (d *migrationTestDB) MigrateAmountsToPaise() (int, int, error) {
+	d.runs++
+	return 3, 0, nil
+}
+
+func (d *migrationTestDB) BackfillAggregateFields() (int, error) {
+	d.backfills++
+	return 2, nil
+}
+
+func (d *migrationTestDB) GetSetting(key string) (*Setting, error) {
+	setting, ok := d.settings[key]
+	if !ok {
+		return nil, nil
+	}
+	return &setting, nil
+}
+
+func (d *migrationTestDB) SaveSetting(setting Setting) error {
+	d.settings[setting.Key] = setting
+	return nil
+}
+
+func TestEnsureAmountsMigratedRunsOnce(t *testing.T) {
+	db := &migrationTestDB{settings: map[string]Setting{}}
+	for i := 0; i < 2; i++ {
+		if err := EnsureAmountsMigrated(db); err != nil {
+			t.Fatalf("EnsureAmountsMigrated returned error: %v", err)
+		}
+	}
+	if db.runs != 1 {
+		t.Fatalf("expected the migration to run once, ran %d times", db.runs)
+	}
+}
+
+func TestEnsureAggregateFieldsBackfilledRunsOnce(t *testing.T) {
+	db := &migrationTestDB{settings: map[string]Setting{}}
+	for i := 0; i < 2; i++ {
+		if err := EnsureAggregateFieldsBackfilled(db); err != nil {
+			t.Fatalf("EnsureAggregateFieldsBackfilled returned error: %v", err)
+		}
+	}
+	if db.backfills != 1 {
+		t.Fatalf("expected the backfill to run once, ran %d times", db.backfills)
+	}
+	if _, ok := db.settings[SettingAggregateFieldsBackfilled]; !ok {
+		t.Fatalf("expected the backfill to be recorded in settings")
+	}
+}
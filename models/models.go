@@ -5,21 +5,196 @@ import (
 )
 
 type Transaction struct {
-	ID              string    `bson:"-" firestore:"-" json:"id"`
-	Type            string    `bson:"type" firestore:"type" json:"type"`
-	CardEnding      string    `bson:"cardending" firestore:"cardending" json:"card_ending"`
-	DebitedAccount  string    `bson:"debitedaccount" firestore:"debitedaccount" json:"debited_account"`
-	CreditedAccount string    `bson:"creditedaccount" firestore:"creditedaccount" json:"credited_account"`
-	Amount          float64   `bson:"amount" firestore:"amount" json:"amount"`
-	Vendor          string    `bson:"vendor" firestore:"vendor" json:"vendor"`
-	DateTime        time.Time `bson:"datetime" firestore:"datetime" json:"date_time"`
-	Category        string    `bson:"category" firestore:"category" json:"category"`
+	ID              string       `bson:"-" firestore:"-" json:"id"`
+	Type            string       `bson:"type" firestore:"type" json:"type"`
+	CardEnding      string       `bson:"cardending" firestore:"cardending" json:"card_ending"`
+	DebitedAccount  string       `bson:"debitedaccount" firestore:"debitedaccount" json:"debited_account"`
+	CreditedAccount string       `bson:"creditedaccount" firestore:"creditedaccount" json:"credited_account"`
+	Amount          Money        `bson:"amountpaise" firestore:"amountpaise" json:"amount"`
+	Currency        string       `bson:"currency,omitempty" firestore:"currency,omitempty" json:"currency,omitempty"`
+	OriginalAmount  Money        `bson:"originalamountminor,omitempty" firestore:"originalamountminor,omitempty" json:"original_amount,omitempty"`
+	FXRate          float64      `bson:"fxrate,omitempty" firestore:"fxrate,omitempty" json:"fx_rate,omitempty"`
+	Vendor          string       `bson:"vendor" firestore:"vendor" json:"vendor"`
+	Merchant        string       `bson:"merchant,omitempty" firestore:"merchant,omitempty" json:"merchant,omitempty"` // canonical name for Vendor
+	DateTime        time.Time    `bson:"datetime" firestore:"datetime" json:"date_time"`
+	Category        string       `bson:"category" firestore:"category" json:"category"`
+	DuplicateOf     string       `bson:"duplicateof,omitempty" firestore:"duplicateof" json:"duplicate_of,omitempty"`
+	SourceKind      string       `bson:"sourcekind,omitempty" firestore:"sourcekind,omitempty" json:"source_kind,omitempty"`
+	SourceID        string       `bson:"sourceid,omitempty" firestore:"sourceid,omitempty" json:"source_id,omitempty"`
+	ParserRule      string       `bson:"parserrule,omitempty" firestore:"parserrule,omitempty" json:"parser_rule,omitempty"`
+	RawText         string       `bson:"rawtext,omitempty" firestore:"rawtext,omitempty" json:"raw_text,omitempty"`
+	Notes           string       `bson:"notes,omitempty" firestore:"notes,omitempty" json:"notes,omitempty"`
+	Tags            []string     `bson:"tags,omitempty" firestore:"tags,omitempty" json:"tags,omitempty"`
+	Splits          []Split      `bson:"splits,omitempty" firestore:"splits,omitempty" json:"splits,omitempty"`
+	Attachments     []Attachment `bson:"attachments,omitempty" firestore:"attachments,omitempty" json:"attachments,omitempty"`
+	// DeletedAt is set while the transaction sits in the trash. Firestore
+	// keeps it and DuplicateOf even when empty, so aggregation queries can
+	// filter on them.
+	DeletedAt *time.Time `bson:"deletedat,omitempty" firestore:"deletedat" json:"deleted_at,omitempty"`
+	// Version is incremented on every update and used for optimistic
+	// concurrency; documents written before it existed read as 0.
+	Version int64 `bson:"version" firestore:"version" json:"version"`
 }
 
+// TransactionPatch is a partial update. Only non-nil fields are written.
+type TransactionPatch struct {
+	Type            *string
+	Vendor          *string
+	Merchant        *string
+	Amount          *Money
+	Category        *string
+	Notes           *string
+	DateTime        *time.Time
+	CardEnding      *string
+	DebitedAccount  *string
+	CreditedAccount *string
+	// Tags and Splits replace the whole list; an empty list clears it.
+	Tags   *[]string
+	Splits *[]Split
+}
+
+// Fields returns the patched fields keyed by their stored name, which is the
+// same in MongoDB and Firestore. A nil value means the field is removed.
+func (p TransactionPatch) Fields() map[string]interface{} {
+	fields := make(map[string]interface{})
+	setString := func(name string, value *string) {
+		if value != nil {
+			fields[name] = *value
+		}
+	}
+	setString("type", p.Type)
+	setString("vendor", p.Vendor)
+	setString("merchant", p.Merchant)
+	setString("category", p.Category)
+	setString("notes", p.Notes)
+	setString("cardending", p.CardEnding)
+	setString("debitedaccount", p.DebitedAccount)
+	setString("creditedaccount", p.CreditedAccount)
+	if p.Amount != nil {
+		fields["amountpaise"] = *p.Amount
+	}
+	if p.DateTime != nil {
+		fields["datetime"] = *p.DateTime
+	}
+	// Empty lists remove the field, as clearing splits or tags always has.
+	if p.Tags != nil {
+		fields["tags"] = nil
+		if len(*p.Tags) > 0 {
+			fields["tags"] = *p.Tags
+		}
+	}
+	if p.Splits != nil {
+		fields["splits"] = nil
+		if len(*p.Splits) > 0 {
+			fields["splits"] = *p.Splits
+		}
+	}
+	return fields
+}
+
+// Apply writes the patched fields to tx.
+func (p TransactionPatch) Apply(tx *Transaction) {
+	apply := func(dst *string, value *string) {
+		if value != nil {
+			*dst = *value
+		}
+	}
+	apply(&tx.Type, p.Type)
+	apply(&tx.Vendor, p.Vendor)
+	apply(&tx.Merchant, p.Merchant)
+	apply(&tx.Category, p.Category)
+	apply(&tx.Notes, p.Notes)
+	apply(&tx.CardEnding, p.CardEnding)
+	apply(&tx.DebitedAccount, p.DebitedAccount)
+	apply(&tx.CreditedAccount, p.CreditedAccount)
+	if p.Amount != nil {
+		tx.Amount = *p.Amount
+	}
+	if p.DateTime != nil {
+		tx.DateTime = *p.DateTime
+	}
+	if p.Tags != nil {
+		tx.Tags = append([]string(nil), *p.Tags...)
+	}
+	if p.Splits != nil {
+		tx.Splits = append([]Split(nil), *p.Splits...)
+	}
+}
+
+// Split is one category line of a transaction that covers several kinds of
+// spend. The split amounts of a transaction always sum to its Amount.
+type Split struct {
+	Amount   Money  `bson:"amountpaise" firestore:"amountpaise" json:"amount"`
+	Category string `bson:"category" firestore:"category" json:"category"`
+	Note     string `bson:"note,omitempty" firestore:"note,omitempty" json:"note,omitempty"`
+}
+
+// Source kinds record where a transaction came from. SourceID then holds the
+// Gmail message ID or the import job ID.
+const (
+	SourceKindGmail     = "gmail"
+	SourceKindGooglePay = "google_pay"
+	SourceKindManual    = "manual"
+)
+
 func (t Transaction) IsCredit() bool {
 	return t.Amount < 0
 }
 
+// IsForeign reports whether the transaction was charged in a currency other
+// than BaseCurrency. Amount always holds the converted rupee value.
+func (t Transaction) IsForeign() bool {
+	return t.Currency != "" && t.Currency != BaseCurrency
+}
+
+// NeedsConversion reports whether a foreign-currency transaction is still
+// waiting for an FX rate, in which case Amount is zero.
+func (t Transaction) NeedsConversion() bool {
+	return t.IsForeign() && t.FXRate == 0
+}
+
+// IsDuplicate reports whether the transaction was linked to a canonical
+// transaction by reconciliation and should be left out of totals.
+func (t Transaction) IsDuplicate() bool {
+	return t.DuplicateOf != ""
+}
+
+// IsTrashed reports whether the transaction was deleted and waits in the
+// trash to be restored or purged.
+func (t Transaction) IsTrashed() bool {
+	return t.DeletedAt != nil
+}
+
+// CategoryLines returns the split lines of the transaction, or a single line
+// with the whole amount when it is not split. Empty categories become "Other".
+func (t Transaction) CategoryLines() []Split {
+	if len(t.Splits) == 0 {
+		category := t.Category
+		if category == "" {
+			category = "Other"
+		}
+		return []Split{{Amount: t.Amount, Category: category}}
+	}
+
+	lines := make([]Split, len(t.Splits))
+	for i, split := range t.Splits {
+		if split.Category == "" {
+			split.Category = "Other"
+		}
+		lines[i] = split
+	}
+	return lines
+}
+
+func (t Transaction) HasTag(tag string) bool {
+	for _, existing := range t.Tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
 // CategoryMapping represents a vendor-to-category mapping stored in MongoDB
 type CategoryMapping struct {
 	Vendor   string    `bson:"vendor" json:"vendor"`
@@ -28,6 +203,12 @@ type CategoryMapping struct {
 	Created  time.Time `bson:"created" json:"created"`
 }
 
+// Category mapping sources
+const (
+	MappingSourceManual = "manual"
+	MappingSourceAI     = "ai"
+)
+
 // VendorCategoryMapping maps vendor names to categories
 var VendorCategoryMapping = map[string]string{
 	// Food & Dining
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionPatchOnlyTouchesSetFields(t *testing.T) {
+	paidAt := time.Date(2026, 4, 19, 8, 31, 30, 0, time.UTC)
+	tx := Transaction{
+		Type:           "HDFCCreditCard",
+		Vendor:         "WWW MYNTRA COM",
+		Amount:         FromRupees(3241),
+		Category:       "Other",
+		CardEnding:     "4207",
+		DebitedAccount: "XX1234",
+		DateTime:       paidAt,
+	}
+
+	category := "Shopping"
+	patch := TransactionPatch{Category: &category}
+
+	fields := patch.Fields()
+	if len(fields) != 1 || fields["category"] != "Shopping" {
+		t.Fatalf("expected only category to be written, got %v", fields)
+	}
+
+	patch.Apply(&tx)
+	if tx.Category != "Shopping" {
+		t.Fatalf("expected category to be patched, got %q", tx.Category)
+	}
+	if !tx.DateTime.Equal(paidAt) || tx.CardEnding != "4207" || tx.DebitedAccount != "XX1234" {
+		t.Fatalf("expected untouched fields to be kept, got %+v", tx)
+	}
+}
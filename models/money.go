@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in paise (1/100 of a rupee). Storing integer minor units
+// keeps sums exact; JSON still carries rupees as a decimal number so the API
+// format is unchanged.
+type Money int64
+
+// FromRupees converts a float rupee amount, rounding to the nearest paisa.
+// Only use it at boundaries (legacy data, JSON numbers), never for arithmetic.
+func FromRupees(rupees float64) Money {
+	return Money(math.Round(rupees * 100))
+}
+
+// ParseMoney parses a rupee amount such as "1,23,456.78" without going
+// through float64. Further decimal places round to the nearest paisa, half
+// away from zero like FromRupees.
+func ParseMoney(value string) (Money, error) {
+	s := strings.TrimSpace(strings.ReplaceAll(value, ",", ""))
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	// Patterns built on [\d,.]+ can capture a sentence-ending full stop.
+	s = strings.TrimSuffix(s, ".")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	roundUp := false
+	if len(frac) > 2 {
+		if strings.Trim(frac, "0123456789") != "" {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+		roundUp = frac[2] >= '5'
+		frac = frac[:2]
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	rupees, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %v", value, err)
+	}
+	paise, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q: %v", value, err)
+	}
+
+	m := Money(rupees*100 + paise)
+	if roundUp {
+		m++
+	}
+	if negative {
+		m = -m
+	}
+	return m, nil
+}
+
+// Rupees returns the amount as a float for display or ratio calculations.
+func (m Money) Rupees() float64 {
+	return float64(m) / 100
+}
+
+// Abs returns the absolute amount.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// DivRound divides the amount by n, rounding half away from zero.
+func (m Money) DivRound(n int) Money {
+	if n == 0 {
+		return 0
+	}
+	q, r := m/Money(n), m%Money(n)
+	if r.Abs()*2 >= Money(n).Abs() {
+		if (m < 0) != (n < 0) {
+			q--
+		} else {
+			q++
+		}
+	}
+	return q
+}
+
+// String formats the amount in rupees with two decimals, e.g. "1234.50".
+func (m Money) String() string {
+	sign := ""
+	if m < 0 {
+		sign = "-"
+	}
+	abs := m.Abs()
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or numeric string in rupees.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if s == "" || s == "null" {
+		*m = 0
+		return nil
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid amount %s: %v", s, err)
+		}
+		*m = FromRupees(f)
+		return nil
+	}
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
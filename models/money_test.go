@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Money{
+		"1,23,456.78": 12345678,
+		"241.00":      24100,
+		"241.5":       24150,
+		"304.00.":     30400,
+		"80000":       8000000,
+		"-12.05":      -1205,
+		"1.005":       101,
+		"1.0049":      100,
+		"-2.675":      -268,
+	}
+	for input, want := range cases {
+		got, err := ParseMoney(input)
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) returned error: %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("ParseMoney(%q) = %d, want %d", input, got, want)
+		}
+	}
+
+	if _, err := ParseMoney("1.00x"); err == nil {
+		t.Fatalf("expected error for a malformed fraction")
+	}
+}
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	var body struct {
+		Amount Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 1100.5}`), &body); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if body.Amount != 110050 {
+		t.Fatalf("expected 110050 paise, got %d", body.Amount)
+	}
+
+	out, err := json.Marshal(body)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if string(out) != `{"amount":1100.50}` {
+		t.Fatalf("unexpected JSON %s", out)
+	}
+}
+
+func TestMoneyDivRound(t *testing.T) {
+	if got := Money(1000).DivRound(3); got != 333 {
+		t.Fatalf("expected 333, got %d", got)
+	}
+	if got := Money(-1001).DivRound(2); got != -501 {
+		t.Fatalf("expected -501, got %d", got)
+	}
+}
+
+func TestMongoPaiseMigrationRoundsLikeFromRupees(t *testing.T) {
+	for _, rupees := range []float64{0.125, 0.375, 2.675, -0.125, -1.005, 1100.5, 42, 0.004999} {
+		got := evalMongoExpr(t, mongoRupeesToPaise, bson.M{"amount": rupees}).(float64)
+		if Money(got) != FromRupees(rupees) {
+			t.Fatalf("migration rounds %v to %v paise, FromRupees gives %d", rupees, got, FromRupees(rupees))
+		}
+	}
+}
+
+func TestMoneyUnmarshalRoundsExtraDecimals(t *testing.T) {
+	var amount Money
+	if err := json.Unmarshal([]byte(`10.005`), &amount); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if amount != 1001 {
+		t.Fatalf("expected 1001 paise, got %d", amount)
+	}
+}
@@ -156,6 +156,24 @@ type mongoTransaction struct {
 	Transaction `bson:",inline"`
 }
 
+// GetTransaction fetches a single transaction by ID
+func (m *MongoClient) GetTransaction(id string) (*Transaction, error) {
+	collection := m.Database.Collection("transactions")
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID: %v", err)
+	}
+
+	var doc mongoTransaction
+	if err := collection.FindOne(m.Ctx, bson.M{"_id": objID}).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to find transaction: %v", err)
+	}
+
+	tx := doc.Transaction
+	tx.ID = doc.ID.Hex()
+	return &tx, nil
+}
 
 // UpdateTransaction updates an existing transaction by ID
 func (m *MongoClient) UpdateTransaction(id string, tx Transaction) error {
@@ -169,13 +187,15 @@ func (m *MongoClient) UpdateTransaction(id string, tx Transaction) error {
 	update := bson.M{"$set": bson.M{
 		"type":            tx.Type,
 		"vendor":          tx.Vendor,
-		"amount":          tx.Amount,
+		"merchant":        tx.Merchant,
+		"amountpaise":     tx.Amount,
 		"category":        tx.Category,
 		"datetime":        tx.DateTime,
 		"cardending":      tx.CardEnding,
 		"debitedaccount":  tx.DebitedAccount,
 		"creditedaccount": tx.CreditedAccount,
-	}}
+		"notes":           tx.Notes,
+	}, "$inc": bson.M{"version": 1}}
 	_, err = collection.UpdateOne(m.Ctx, bson.M{"_id": objID}, update)
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %v", err)
@@ -183,6 +203,56 @@ func (m *MongoClient) UpdateTransaction(id string, tx Transaction) error {
 	return nil
 }
 
+// PatchTransaction updates the given fields if the version still matches
+func (m *MongoClient) PatchTransaction(id string, patch TransactionPatch, expectedVersion int64) (*Transaction, error) {
+	collection := m.Database.Collection("transactions")
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction ID: %v", err)
+	}
+
+	filter := bson.M{"_id": objID, "version": expectedVersion}
+	if expectedVersion == 0 {
+		filter = bson.M{"_id": objID, "$or": bson.A{
+			bson.M{"version": 0},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	update := bson.M{"$inc": bson.M{"version": 1}}
+	set, unset := bson.M{}, bson.M{}
+	for name, value := range patch.Fields() {
+		if value == nil {
+			unset[name] = ""
+		} else {
+			set[name] = value
+		}
+	}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var doc mongoTransaction
+	err = collection.FindOneAndUpdate(m.Ctx, filter, update, opts).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		if _, getErr := m.GetTransaction(id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrVersionConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to patch transaction: %v", err)
+	}
+
+	tx := doc.Transaction
+	tx.ID = doc.ID.Hex()
+	return &tx, nil
+}
+
 func (m *MongoClient) FetchTransactionsByDateRange(from, to time.Time) ([]Transaction, error) {
 	collection := m.Database.Collection("transactions")
 	filter := bson.M{"datetime": bson.M{"$gte": from, "$lte": to}}
@@ -232,6 +302,42 @@ func (m *MongoClient) GetLatestTransactionTimeByType(txType string) (*time.Time,
 	return &txn.DateTime, nil
 }
 
+// mongoRupeesToPaise is FromRupees as an aggregation expression. $round rounds
+// half to even, so it rounds half away from zero the way math.Round does.
+var mongoRupeesToPaise = bson.M{"$let": bson.M{
+	"vars": bson.M{"x": bson.M{"$multiply": bson.A{"$amount", 100}}},
+	"in": bson.M{"$let": bson.M{
+		"vars": bson.M{"t": bson.M{"$trunc": "$$x"}},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$gte": bson.A{bson.M{"$abs": bson.M{"$subtract": bson.A{"$$x", "$$t"}}}, 0.5}},
+			bson.M{"$add": bson.A{"$$t", bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{"$$x", 0}}, -1, 1}}}},
+			"$$t",
+		}},
+	}},
+}}
+
+// MigrateAmountsToPaise converts the legacy float rupee "amount" field into the
+// integer "amountpaise" field. Documents already migrated are skipped.
+func (m *MongoClient) MigrateAmountsToPaise() (int, int, error) {
+	collection := m.Database.Collection("transactions")
+
+	filter := bson.M{"amount": bson.M{"$exists": true}, "amountpaise": bson.M{"$exists": false}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"amountpaise": bson.M{"$toLong": mongoRupeesToPaise}}}},
+		{{Key: "$unset", Value: "amount"}},
+	}
+	result, err := collection.UpdateMany(m.Ctx, filter, pipeline)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to migrate amounts: %v", err)
+	}
+
+	total, err := collection.CountDocuments(m.Ctx, bson.M{})
+	if err != nil {
+		return int(result.ModifiedCount), 0, fmt.Errorf("failed to count transactions: %v", err)
+	}
+	return int(result.ModifiedCount), int(total) - int(result.ModifiedCount), nil
+}
+
 // SaveUnparsedEmail stores unparsed email data in MongoDB
 func (m *MongoClient) SaveUnparsedEmail(body string, headers map[string]string) error {
 	collection := m.Database.Collection("unparsed_emails")
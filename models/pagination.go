@@ -0,0 +1,289 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// Stored fields a page of transactions can be sorted by
+const (
+	PageSortDate   = "datetime"
+	PageSortAmount = "amountpaise"
+	PageSortVendor = "vendor"
+)
+
+// TransactionPageQuery selects live transactions in a date range, sorted by
+// SortField and then by ID. Transactions linked to a canonical record by
+// reconciliation and those in the trash are always left out. Empty filters
+// match everything.
+type TransactionPageQuery struct {
+	From      time.Time
+	To        time.Time
+	SortField string
+	Desc      bool
+	// Type matches exactly.
+	Type string
+	// Tags must all be present on a transaction for it to match.
+	Tags []string
+	// MinAmount and MaxAmount bound the amount, inclusive.
+	MinAmount *Money
+	MaxAmount *Money
+	// After resumes after this transaction; only its ID and sort field are
+	// read.
+	After *Transaction
+	// Limit caps the page; zero returns every remaining transaction.
+	Limit int
+}
+
+// PageStore is implemented by database backends that can sort, filter and
+// page transactions in the database.
+type PageStore interface {
+	ListTransactionPage(query TransactionPageQuery) ([]Transaction, error)
+	// CountTransactions counts every transaction the query matches,
+	// ignoring After and Limit.
+	CountTransactions(query TransactionPageQuery) (int, error)
+}
+
+func (q TransactionPageQuery) validate() error {
+	switch q.SortField {
+	case PageSortDate, PageSortAmount, PageSortVendor:
+		return nil
+	}
+	return fmt.Errorf("cannot sort transactions by %q", q.SortField)
+}
+
+// sortValue is the value of the sort field of tx.
+func (q TransactionPageQuery) sortValue(tx Transaction) interface{} {
+	switch q.SortField {
+	case PageSortAmount:
+		return tx.Amount
+	case PageSortVendor:
+		return tx.Vendor
+	default:
+		return tx.DateTime
+	}
+}
+
+// pageCollation compares strings ignoring case, so vendors sort the way
+// listings sorted in memory.
+var pageCollation = &options.Collation{Locale: "en", Strength: 2}
+
+func (q TransactionPageQuery) mongoFilter() bson.M {
+	filter := bson.M{
+		"datetime":    bson.M{"$gte": q.From, "$lte": q.To},
+		"duplicateof": bson.M{"$in": bson.A{nil, ""}},
+		"deletedat":   nil,
+	}
+	if q.Type != "" {
+		filter["type"] = q.Type
+	}
+	if len(q.Tags) > 0 {
+		filter["tags"] = bson.M{"$all": q.Tags}
+	}
+	amount := bson.M{}
+	if q.MinAmount != nil {
+		amount["$gte"] = *q.MinAmount
+	}
+	if q.MaxAmount != nil {
+		amount["$lte"] = *q.MaxAmount
+	}
+	if len(amount) > 0 {
+		filter["amountpaise"] = amount
+	}
+	return filter
+}
+
+func (m *MongoClient) ListTransactionPage(query TransactionPageQuery) ([]Transaction, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+
+	direction, op := 1, "$gt"
+	if query.Desc {
+		direction, op = -1, "$lt"
+	}
+	filter := query.mongoFilter()
+	if query.After != nil {
+		afterID, err := primitive.ObjectIDFromHex(query.After.ID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor transaction ID: %v", err)
+		}
+		value := query.sortValue(*query.After)
+		filter["$or"] = bson.A{
+			bson.M{query.SortField: bson.M{op: value}},
+			bson.M{query.SortField: value, "_id": bson.M{op: afterID}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: query.SortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetCollation(pageCollation)
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := m.Database.Collection("transactions").Find(m.Ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var docs []mongoTransaction
+	if err := cursor.All(m.Ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode transactions: %v", err)
+	}
+	transactions := make([]Transaction, len(docs))
+	for i, doc := range docs {
+		transactions[i] = doc.Transaction
+		transactions[i].ID = doc.ID.Hex()
+	}
+	return transactions, nil
+}
+
+func (m *MongoClient) CountTransactions(query TransactionPageQuery) (int, error) {
+	count, err := m.Database.Collection("transactions").CountDocuments(m.Ctx, query.mongoFilter(), options.Count().SetCollation(pageCollation))
+	if err != nil {
+		return 0, fmt.Errorf("failed to count transactions: %v", err)
+	}
+	return int(count), nil
+}
+
+// firestoreQuery builds the Firestore query for q and returns what it could
+// not express, checked as rows are read: tags beyond the first, and the
+// duplicate and trash checks until older transactions are backfilled (see
+// BackfillAggregateFields). Type matches case-sensitively and vendors sort
+// case-sensitively.
+func (f *FirestoreClient) firestoreQuery(q TransactionPageQuery) (firestore.Query, func(Transaction) bool, error) {
+	query := f.Client.Collection("transactions").
+		Where("datetime", ">=", q.From).
+		Where("datetime", "<=", q.To)
+
+	backfilled, err := f.aggregateFieldsBackfilled()
+	if err != nil {
+		return query, nil, err
+	}
+	var checks []func(Transaction) bool
+	if backfilled {
+		query = query.Where("duplicateof", "==", "").Where("deletedat", "==", nil)
+	} else {
+		checks = append(checks, func(tx Transaction) bool { return !tx.IsDuplicate() && !tx.IsTrashed() })
+	}
+	if q.Type != "" {
+		query = query.Where("type", "==", q.Type)
+	}
+	if len(q.Tags) > 0 {
+		query = query.Where("tags", "array-contains", q.Tags[0])
+		if rest := q.Tags[1:]; len(rest) > 0 {
+			checks = append(checks, func(tx Transaction) bool {
+				for _, tag := range rest {
+					if !tx.HasTag(tag) {
+						return false
+					}
+				}
+				return true
+			})
+		}
+	}
+	if q.MinAmount != nil {
+		query = query.Where("amountpaise", ">=", *q.MinAmount)
+	}
+	if q.MaxAmount != nil {
+		query = query.Where("amountpaise", "<=", *q.MaxAmount)
+	}
+
+	if len(checks) == 0 {
+		return query, nil, nil
+	}
+	return query, func(tx Transaction) bool {
+		for _, check := range checks {
+			if !check(tx) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func (f *FirestoreClient) ListTransactionPage(query TransactionPageQuery) ([]Transaction, error) {
+	if err := query.validate(); err != nil {
+		return nil, err
+	}
+	q, check, err := f.firestoreQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	direction := firestore.Asc
+	if query.Desc {
+		direction = firestore.Desc
+	}
+	q = q.OrderBy(query.SortField, direction).OrderBy(firestore.DocumentID, direction)
+	if query.After != nil {
+		q = q.StartAfter(query.sortValue(*query.After), query.After.ID)
+	}
+	if check == nil && query.Limit > 0 {
+		q = q.Limit(query.Limit)
+	}
+
+	iter := q.Documents(f.Ctx)
+	defer iter.Stop()
+	var transactions []Transaction
+	for query.Limit == 0 || len(transactions) < query.Limit {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %v", err)
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return nil, fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		tx.ID = doc.Ref.ID
+		if check == nil || check(tx) {
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions, nil
+}
+
+func (f *FirestoreClient) CountTransactions(query TransactionPageQuery) (int, error) {
+	q, check, err := f.firestoreQuery(query)
+	if err != nil {
+		return 0, err
+	}
+	if check == nil {
+		result, err := q.NewAggregationQuery().WithCount("count").Get(f.Ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count transactions: %v", err)
+		}
+		return int(aggregateValue(result["count"])), nil
+	}
+
+	iter := q.Select("duplicateof", "deletedat", "tags").Documents(f.Ctx)
+	defer iter.Stop()
+	count := 0
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to count transactions: %v", err)
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return 0, fmt.Errorf("failed to decode transaction: %v", err)
+		}
+		if check(tx) {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reconciliation match statuses
+const (
+	ReconciliationPending   = "pending"
+	ReconciliationMerged    = "merged"
+	ReconciliationDismissed = "dismissed"
+)
+
+// ReconciliationMatch is a pair of transactions from different sources that
+// look like the same payment but could not be merged automatically.
+type ReconciliationMatch struct {
+	ID          string    `bson:"_id" firestore:"-" json:"id"`
+	CanonicalID string    `bson:"canonical_id" firestore:"canonical_id" json:"canonical_id"`
+	DuplicateID string    `bson:"duplicate_id" firestore:"duplicate_id" json:"duplicate_id"`
+	Amount      Money     `bson:"amount_paise" firestore:"amount_paise" json:"amount"`
+	TimeDelta   string    `bson:"time_delta" firestore:"time_delta" json:"time_delta"`
+	Reason      string    `bson:"reason" firestore:"reason" json:"reason"`
+	Status      string    `bson:"status" firestore:"status" json:"status"`
+	CreatedAt   time.Time `bson:"created_at" firestore:"created_at" json:"created_at"`
+	ResolvedAt  time.Time `bson:"resolved_at,omitempty" firestore:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// ReconciliationStore is implemented by database backends that can persist
+// duplicate links and the review queue for ambiguous matches.
+type ReconciliationStore interface {
+	// MarkDuplicate links id to canonicalID; an empty canonicalID unlinks it.
+	MarkDuplicate(id, canonicalID string) error
+	// ListDuplicatesOf returns the transactions linked to canonicalID.
+	ListDuplicatesOf(canonicalID string) ([]Transaction, error)
+	SaveReconciliationMatch(match ReconciliationMatch) error
+	GetReconciliationMatch(id string) (*ReconciliationMatch, error)
+	ListReconciliationMatches(status string) ([]ReconciliationMatch, error)
+	UpdateReconciliationMatchStatus(id, status string) error
+}
+
+// MarkDuplicate links a transaction to the canonical transaction it duplicates
+func (m *MongoClient) MarkDuplicate(id, canonicalID string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %v", err)
+	}
+	_, err = m.Database.Collection("transactions").UpdateOne(m.Ctx, bson.M{"_id": objID}, bson.M{"$set": bson.M{"duplicateof": canonicalID}})
+	if err != nil {
+		return fmt.Errorf("failed to mark duplicate transaction: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) ListDuplicatesOf(canonicalID string) ([]Transaction, error) {
+	cursor, err := m.Database.Collection("transactions").Find(m.Ctx, bson.M{"duplicateof": canonicalID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch duplicate transactions: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var docs []mongoTransaction
+	if err := cursor.All(m.Ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode duplicate transactions: %v", err)
+	}
+	transactions := make([]Transaction, len(docs))
+	for i, doc := range docs {
+		transactions[i] = doc.Transaction
+		transactions[i].ID = doc.ID.Hex()
+	}
+	return transactions, nil
+}
+
+// SaveReconciliationMatch inserts a match unless one already exists for the
+// same pair, so re-running reconciliation never reopens a resolved match.
+func (m *MongoClient) SaveReconciliationMatch(match ReconciliationMatch) error {
+	collection := m.Database.Collection("reconciliation_matches")
+	opts := options.Update().SetUpsert(true)
+	_, err := collection.UpdateOne(m.Ctx, bson.M{"_id": match.ID}, bson.M{"$setOnInsert": match}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to save reconciliation match: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) GetReconciliationMatch(id string) (*ReconciliationMatch, error) {
+	var match ReconciliationMatch
+	err := m.Database.Collection("reconciliation_matches").FindOne(m.Ctx, bson.M{"_id": id}).Decode(&match)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reconciliation match: %v", err)
+	}
+	return &match, nil
+}
+
+func (m *MongoClient) ListReconciliationMatches(status string) ([]ReconciliationMatch, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := m.Database.Collection("reconciliation_matches").Find(m.Ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reconciliation matches: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var matches []ReconciliationMatch
+	if err := cursor.All(m.Ctx, &matches); err != nil {
+		return nil, fmt.Errorf("failed to decode reconciliation matches: %v", err)
+	}
+	return matches, nil
+}
+
+func (m *MongoClient) UpdateReconciliationMatchStatus(id, status string) error {
+	update := bson.M{"$set": bson.M{"status": status, "resolved_at": time.Now().UTC()}}
+	_, err := m.Database.Collection("reconciliation_matches").UpdateOne(m.Ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update reconciliation match: %v", err)
+	}
+	return nil
+}
+
+// MarkDuplicate links a transaction to the canonical transaction it duplicates
+func (f *FirestoreClient) MarkDuplicate(id, canonicalID string) error {
+	_, err := f.Client.Collection("transactions").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "duplicateof", Value: canonicalID},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark duplicate transaction: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) ListDuplicatesOf(canonicalID string) ([]Transaction, error) {
+	iter := f.Client.Collection("transactions").Where("duplicateof", "==", canonicalID).Documents(f.Ctx)
+	defer iter.Stop()
+
+	var transactions []Transaction
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch duplicate transactions: %v", err)
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return nil, fmt.Errorf("failed to decode duplicate transaction: %v", err)
+		}
+		tx.ID = doc.Ref.ID
+		transactions = append(transactions, tx)
+	}
+	return transactions, nil
+}
+
+// SaveReconciliationMatch creates the match document, leaving any existing
+// match for the same pair untouched.
+func (f *FirestoreClient) SaveReconciliationMatch(match ReconciliationMatch) error {
+	_, err := f.Client.Collection("reconciliation_matches").Doc(match.ID).Create(f.Ctx, match)
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("failed to save reconciliation match: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) GetReconciliationMatch(id string) (*ReconciliationMatch, error) {
+	doc, err := f.Client.Collection("reconciliation_matches").Doc(id).Get(f.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reconciliation match: %v", err)
+	}
+	var match ReconciliationMatch
+	if err := doc.DataTo(&match); err != nil {
+		return nil, fmt.Errorf("failed to decode reconciliation match: %v", err)
+	}
+	match.ID = doc.Ref.ID
+	return &match, nil
+}
+
+func (f *FirestoreClient) ListReconciliationMatches(matchStatus string) ([]ReconciliationMatch, error) {
+	query := f.Client.Collection("reconciliation_matches").Query
+	if matchStatus != "" {
+		query = query.Where("status", "==", matchStatus)
+	}
+	iter := query.Documents(f.Ctx)
+	defer iter.Stop()
+
+	var matches []ReconciliationMatch
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch reconciliation matches: %v", err)
+		}
+		var match ReconciliationMatch
+		if err := doc.DataTo(&match); err != nil {
+			return nil, fmt.Errorf("failed to decode reconciliation match: %v", err)
+		}
+		match.ID = doc.Ref.ID
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+func (f *FirestoreClient) UpdateReconciliationMatchStatus(id, matchStatus string) error {
+	_, err := f.Client.Collection("reconciliation_matches").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "status", Value: matchStatus},
+		{Path: "resolved_at", Value: time.Now().UTC()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update reconciliation match: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// Category rule sources
+const (
+	RuleSourceDefault = "default"
+	RuleSourceUser    = "user"
+)
+
+// CategoryRule assigns Category to transactions matching all of its set
+// conditions. When several rules match, the highest Priority wins.
+type CategoryRule struct {
+	ID       string `bson:"_id" firestore:"-" json:"id"`
+	Name     string `bson:"name,omitempty" firestore:"name,omitempty" json:"name,omitempty"`
+	Category string `bson:"category" firestore:"category" json:"category"`
+	Priority int    `bson:"priority" firestore:"priority" json:"priority"`
+	// VendorPattern is a case-insensitive substring of the vendor, or a
+	// regular expression when VendorRegex is set.
+	VendorPattern string `bson:"vendor_pattern,omitempty" firestore:"vendor_pattern,omitempty" json:"vendor_pattern,omitempty"`
+	VendorRegex   bool   `bson:"vendor_regex,omitempty" firestore:"vendor_regex,omitempty" json:"vendor_regex,omitempty"`
+	// MinAmount and MaxAmount bound the absolute amount, inclusive.
+	MinAmount  *Money `bson:"min_amount_paise,omitempty" firestore:"min_amount_paise,omitempty" json:"min_amount,omitempty"`
+	MaxAmount  *Money `bson:"max_amount_paise,omitempty" firestore:"max_amount_paise,omitempty" json:"max_amount,omitempty"`
+	Type       string `bson:"type,omitempty" firestore:"type,omitempty" json:"type,omitempty"`
+	CardEnding string `bson:"card_ending,omitempty" firestore:"card_ending,omitempty" json:"card_ending,omitempty"`
+	// Account matches the debited or credited account, such as a UPI VPA.
+	Account   string    `bson:"account,omitempty" firestore:"account,omitempty" json:"account,omitempty"`
+	Disabled  bool      `bson:"disabled,omitempty" firestore:"disabled,omitempty" json:"disabled,omitempty"`
+	Source    string    `bson:"source" firestore:"source" json:"source"`
+	CreatedAt time.Time `bson:"created_at" firestore:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" firestore:"updated_at" json:"updated_at"`
+}
+
+// RuleStore is implemented by database backends that keep categorization
+// rules.
+type RuleStore interface {
+	ListCategoryRules() ([]CategoryRule, error)
+	SaveCategoryRule(rule CategoryRule) error
+	DeleteCategoryRule(id string) error
+}
+
+func (m *MongoClient) ListCategoryRules() ([]CategoryRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: -1}, {Key: "_id", Value: 1}})
+	cursor, err := m.Database.Collection("category_rules").Find(m.Ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch category rules: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var rules []CategoryRule
+	if err := cursor.All(m.Ctx, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode category rules: %v", err)
+	}
+	return rules, nil
+}
+
+// SaveCategoryRule creates or replaces a rule
+func (m *MongoClient) SaveCategoryRule(rule CategoryRule) error {
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.Database.Collection("category_rules").ReplaceOne(m.Ctx, bson.M{"_id": rule.ID}, rule, opts); err != nil {
+		return fmt.Errorf("failed to save category rule: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) DeleteCategoryRule(id string) error {
+	result, err := m.Database.Collection("category_rules").DeleteOne(m.Ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete category rule: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("category rule %s not found", id)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) ListCategoryRules() ([]CategoryRule, error) {
+	iter := f.Client.Collection("category_rules").OrderBy("priority", firestore.Desc).Documents(f.Ctx)
+	defer iter.Stop()
+
+	var rules []CategoryRule
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch category rules: %v", err)
+		}
+		var rule CategoryRule
+		if err := doc.DataTo(&rule); err != nil {
+			return nil, fmt.Errorf("failed to decode category rule: %v", err)
+		}
+		rule.ID = doc.Ref.ID
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SaveCategoryRule creates or replaces a rule
+func (f *FirestoreClient) SaveCategoryRule(rule CategoryRule) error {
+	if _, err := f.Client.Collection("category_rules").Doc(rule.ID).Set(f.Ctx, rule); err != nil {
+		return fmt.Errorf("failed to save category rule: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) DeleteCategoryRule(id string) error {
+	ref := f.Client.Collection("category_rules").Doc(id)
+	if _, err := ref.Get(f.Ctx); err != nil {
+		return fmt.Errorf("category rule %s not found", id)
+	}
+	if _, err := ref.Delete(f.Ctx); err != nil {
+		return fmt.Errorf("failed to delete category rule: %v", err)
+	}
+	return nil
+}
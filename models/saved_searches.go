@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// SavedSearch is a named transaction search, such as "Weekend food" for
+// "category:Food weekday:sat,sun".
+type SavedSearch struct {
+	ID        string    `bson:"_id" firestore:"-" json:"id"`
+	Name      string    `bson:"name" firestore:"name" json:"name"`
+	Query     string    `bson:"query" firestore:"query" json:"query"`
+	CreatedAt time.Time `bson:"created_at" firestore:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" firestore:"updated_at" json:"updated_at"`
+}
+
+// SavedSearchStore is implemented by database backends that keep saved
+// searches.
+type SavedSearchStore interface {
+	ListSavedSearches() ([]SavedSearch, error)
+	SaveSavedSearch(search SavedSearch) error
+	DeleteSavedSearch(id string) error
+}
+
+func (m *MongoClient) ListSavedSearches() ([]SavedSearch, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	cursor, err := m.Database.Collection("saved_searches").Find(m.Ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch saved searches: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var searches []SavedSearch
+	if err := cursor.All(m.Ctx, &searches); err != nil {
+		return nil, fmt.Errorf("failed to decode saved searches: %v", err)
+	}
+	return searches, nil
+}
+
+// SaveSavedSearch creates or replaces a saved search
+func (m *MongoClient) SaveSavedSearch(search SavedSearch) error {
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.Database.Collection("saved_searches").ReplaceOne(m.Ctx, bson.M{"_id": search.ID}, search, opts); err != nil {
+		return fmt.Errorf("failed to save saved search: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) DeleteSavedSearch(id string) error {
+	result, err := m.Database.Collection("saved_searches").DeleteOne(m.Ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %v", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("saved search %s not found", id)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) ListSavedSearches() ([]SavedSearch, error) {
+	iter := f.Client.Collection("saved_searches").OrderBy("name", firestore.Asc).Documents(f.Ctx)
+	defer iter.Stop()
+
+	var searches []SavedSearch
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch saved searches: %v", err)
+		}
+		var search SavedSearch
+		if err := doc.DataTo(&search); err != nil {
+			return nil, fmt.Errorf("failed to decode saved search: %v", err)
+		}
+		search.ID = doc.Ref.ID
+		searches = append(searches, search)
+	}
+	return searches, nil
+}
+
+// SaveSavedSearch creates or replaces a saved search
+func (f *FirestoreClient) SaveSavedSearch(search SavedSearch) error {
+	if _, err := f.Client.Collection("saved_searches").Doc(search.ID).Set(f.Ctx, search); err != nil {
+		return fmt.Errorf("failed to save saved search: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) DeleteSavedSearch(id string) error {
+	ref := f.Client.Collection("saved_searches").Doc(id)
+	if _, err := ref.Get(f.Ctx); err != nil {
+		return fmt.Errorf("saved search %s not found", id)
+	}
+	if _, err := ref.Delete(f.Ctx); err != nil {
+		return fmt.Errorf("failed to delete saved search: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Settings keys
+const (
+	// SettingCategoryRulesSeeded records that the default category rules
+	// were seeded, so deleting every rule does not bring them back.
+	SettingCategoryRulesSeeded = "category_rules_seeded"
+	// SettingAmountsMigrated records that legacy float amounts were
+	// converted to paise.
+	SettingAmountsMigrated = "amounts_migrated"
+	// SettingIMPSTimestampsMigrated records that IMPS transactions stored
+	// with UTC wall-clock times were moved into the user's time zone.
+	SettingIMPSTimestampsMigrated = "imps_timestamps_migrated"
+	// SettingAggregateFieldsBackfilled records that every Firestore
+	// transaction stores deletedat and duplicateof, even when empty.
+	SettingAggregateFieldsBackfilled = "aggregate_fields_backfilled"
+)
+
+// Setting is a small piece of app state kept in the settings collection.
+type Setting struct {
+	Key       string    `bson:"_id" firestore:"-" json:"key"`
+	Value     string    `bson:"value" firestore:"value" json:"value"`
+	UpdatedAt time.Time `bson:"updated_at" firestore:"updated_at" json:"updated_at"`
+}
+
+// SettingsStore is implemented by database backends that keep settings.
+type SettingsStore interface {
+	// GetSetting returns nil when the setting was never saved.
+	GetSetting(key string) (*Setting, error)
+	SaveSetting(setting Setting) error
+}
+
+func (m *MongoClient) GetSetting(key string) (*Setting, error) {
+	var setting Setting
+	if err := m.Database.Collection("settings").FindOne(m.Ctx, bson.M{"_id": key}).Decode(&setting); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch setting %s: %v", key, err)
+	}
+	return &setting, nil
+}
+
+func (m *MongoClient) SaveSetting(setting Setting) error {
+	opts := options.Replace().SetUpsert(true)
+	if _, err := m.Database.Collection("settings").ReplaceOne(m.Ctx, bson.M{"_id": setting.Key}, setting, opts); err != nil {
+		return fmt.Errorf("failed to save setting %s: %v", setting.Key, err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) GetSetting(key string) (*Setting, error) {
+	doc, err := f.Client.Collection("settings").Doc(key).Get(f.Ctx)
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch setting %s: %v", key, err)
+	}
+	var setting Setting
+	if err := doc.DataTo(&setting); err != nil {
+		return nil, fmt.Errorf("failed to decode setting %s: %v", key, err)
+	}
+	setting.Key = key
+	return &setting, nil
+}
+
+func (f *FirestoreClient) SaveSetting(setting Setting) error {
+	if _, err := f.Client.Collection("settings").Doc(setting.Key).Set(f.Ctx, setting); err != nil {
+		return fmt.Errorf("failed to save setting %s: %v", setting.Key, err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SplitStore is implemented by database backends that can store the split
+// lines of a transaction. An empty slice removes the split.
+type SplitStore interface {
+	SetTransactionSplits(id string, splits []Split) error
+}
+
+func (m *MongoClient) SetTransactionSplits(id string, splits []Split) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %v", err)
+	}
+
+	update := bson.M{"$set": bson.M{"splits": splits}, "$inc": bson.M{"version": 1}}
+	if len(splits) == 0 {
+		update = bson.M{"$unset": bson.M{"splits": ""}, "$inc": bson.M{"version": 1}}
+	}
+	if _, err := m.Database.Collection("transactions").UpdateOne(m.Ctx, bson.M{"_id": objID}, update); err != nil {
+		return fmt.Errorf("failed to update transaction splits: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) SetTransactionSplits(id string, splits []Split) error {
+	var value interface{} = splits
+	if len(splits) == 0 {
+		value = firestore.Delete
+	}
+	_, err := f.Client.Collection("transactions").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "splits", Value: value},
+		{Path: "version", Value: firestore.Increment(1)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update transaction splits: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Category suggestion statuses
+const (
+	SuggestionPending  = "pending"
+	SuggestionAccepted = "accepted"
+	SuggestionRejected = "rejected"
+)
+
+// CategorySuggestion is a category the AI proposed for a vendor with too
+// little confidence to apply it without review.
+type CategorySuggestion struct {
+	ID         string  `bson:"_id" firestore:"-" json:"id"`
+	Vendor     string  `bson:"vendor" firestore:"vendor" json:"vendor"`
+	Category   string  `bson:"category" firestore:"category" json:"category"`
+	Confidence float64 `bson:"confidence" firestore:"confidence" json:"confidence"`
+	// TransactionCount and Amount describe the uncategorized transactions
+	// from the vendor when the suggestion was made.
+	TransactionCount int       `bson:"transaction_count" firestore:"transaction_count" json:"transaction_count"`
+	Amount           Money     `bson:"amount_paise" firestore:"amount_paise" json:"amount"`
+	Status           string    `bson:"status" firestore:"status" json:"status"`
+	CreatedAt        time.Time `bson:"created_at" firestore:"created_at" json:"created_at"`
+	ResolvedAt       time.Time `bson:"resolved_at,omitempty" firestore:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+}
+
+// SuggestionStore is implemented by database backends that keep the review
+// list of AI category suggestions.
+type SuggestionStore interface {
+	SaveCategorySuggestion(suggestion CategorySuggestion) error
+	GetCategorySuggestion(id string) (*CategorySuggestion, error)
+	ListCategorySuggestions(status string) ([]CategorySuggestion, error)
+	UpdateCategorySuggestionStatus(id, status string) error
+}
+
+// SaveCategorySuggestion inserts a suggestion unless one already exists for
+// the vendor, so a rejected suggestion is never reopened.
+func (m *MongoClient) SaveCategorySuggestion(suggestion CategorySuggestion) error {
+	opts := options.Update().SetUpsert(true)
+	_, err := m.Database.Collection("category_suggestions").UpdateOne(m.Ctx, bson.M{"_id": suggestion.ID}, bson.M{"$setOnInsert": suggestion}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to save category suggestion: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) GetCategorySuggestion(id string) (*CategorySuggestion, error) {
+	var suggestion CategorySuggestion
+	err := m.Database.Collection("category_suggestions").FindOne(m.Ctx, bson.M{"_id": id}).Decode(&suggestion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find category suggestion: %v", err)
+	}
+	return &suggestion, nil
+}
+
+func (m *MongoClient) ListCategorySuggestions(status string) ([]CategorySuggestion, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "transaction_count", Value: -1}})
+	cursor, err := m.Database.Collection("category_suggestions").Find(m.Ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch category suggestions: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var suggestions []CategorySuggestion
+	if err := cursor.All(m.Ctx, &suggestions); err != nil {
+		return nil, fmt.Errorf("failed to decode category suggestions: %v", err)
+	}
+	return suggestions, nil
+}
+
+func (m *MongoClient) UpdateCategorySuggestionStatus(id, status string) error {
+	update := bson.M{"$set": bson.M{"status": status, "resolved_at": time.Now().UTC()}}
+	_, err := m.Database.Collection("category_suggestions").UpdateOne(m.Ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update category suggestion: %v", err)
+	}
+	return nil
+}
+
+// SaveCategorySuggestion creates the suggestion document, leaving any
+// existing suggestion for the vendor untouched.
+func (f *FirestoreClient) SaveCategorySuggestion(suggestion CategorySuggestion) error {
+	_, err := f.Client.Collection("category_suggestions").Doc(suggestion.ID).Create(f.Ctx, suggestion)
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("failed to save category suggestion: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) GetCategorySuggestion(id string) (*CategorySuggestion, error) {
+	doc, err := f.Client.Collection("category_suggestions").Doc(id).Get(f.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find category suggestion: %v", err)
+	}
+	var suggestion CategorySuggestion
+	if err := doc.DataTo(&suggestion); err != nil {
+		return nil, fmt.Errorf("failed to decode category suggestion: %v", err)
+	}
+	suggestion.ID = doc.Ref.ID
+	return &suggestion, nil
+}
+
+func (f *FirestoreClient) ListCategorySuggestions(suggestionStatus string) ([]CategorySuggestion, error) {
+	query := f.Client.Collection("category_suggestions").Query
+	if suggestionStatus != "" {
+		query = query.Where("status", "==", suggestionStatus)
+	}
+	iter := query.Documents(f.Ctx)
+	defer iter.Stop()
+
+	var suggestions []CategorySuggestion
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch category suggestions: %v", err)
+		}
+		var suggestion CategorySuggestion
+		if err := doc.DataTo(&suggestion); err != nil {
+			return nil, fmt.Errorf("failed to decode category suggestion: %v", err)
+		}
+		suggestion.ID = doc.Ref.ID
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, nil
+}
+
+func (f *FirestoreClient) UpdateCategorySuggestionStatus(id, suggestionStatus string) error {
+	_, err := f.Client.Collection("category_suggestions").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "status", Value: suggestionStatus},
+		{Path: "resolved_at", Value: time.Now().UTC()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update category suggestion: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,425 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tag is a free-form label such as "goa-trip-2026" or "reimbursable". Unlike
+// Category a transaction can carry any number of tags.
+type Tag struct {
+	Name        string    `bson:"_id" firestore:"name" json:"name"`
+	Description string    `bson:"description,omitempty" firestore:"description,omitempty" json:"description,omitempty"`
+	CreatedAt   time.Time `bson:"created_at" firestore:"created_at" json:"created_at"`
+}
+
+// TagStore is implemented by database backends that keep the tag list and can
+// add or remove tags on transactions in bulk. RenameTag and DeleteTag return
+// the transactions that carried the tag as they were before the change; the
+// int results are the number of transactions touched.
+type TagStore interface {
+	SaveTag(tag Tag) error
+	ListTags() ([]Tag, error)
+	RenameTag(oldName, newName string) ([]Transaction, error)
+	DeleteTag(name string) ([]Transaction, error)
+	AddTransactionTags(ids []string, tags []string) (int, error)
+	RemoveTransactionTags(ids []string, tags []string) (int, error)
+}
+
+func mongoObjectIDs(ids []string) ([]primitive.ObjectID, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction ID %q: %v", id, err)
+		}
+		objIDs = append(objIDs, objID)
+	}
+	return objIDs, nil
+}
+
+// SaveTag creates a tag or updates its description
+func (m *MongoClient) SaveTag(tag Tag) error {
+	update := bson.M{
+		"$set":         bson.M{"description": tag.Description},
+		"$setOnInsert": bson.M{"created_at": tag.CreatedAt},
+	}
+	_, err := m.Database.Collection("tags").UpdateOne(m.Ctx, bson.M{"_id": tag.Name}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save tag: %v", err)
+	}
+	return nil
+}
+
+func (m *MongoClient) ListTags() ([]Tag, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := m.Database.Collection("tags").Find(m.Ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var tags []Tag
+	if err := cursor.All(m.Ctx, &tags); err != nil {
+		return nil, fmt.Errorf("failed to decode tags: %v", err)
+	}
+	return tags, nil
+}
+
+// RenameTag moves a tag and every transaction carrying it to a new name
+func (m *MongoClient) RenameTag(oldName, newName string) ([]Transaction, error) {
+	var tag Tag
+	if err := m.Database.Collection("tags").FindOne(m.Ctx, bson.M{"_id": oldName}).Decode(&tag); err != nil {
+		return nil, fmt.Errorf("failed to find tag %q: %v", oldName, err)
+	}
+	tag.Name = newName
+	if err := m.SaveTag(tag); err != nil {
+		return nil, err
+	}
+
+	// A pipeline update swaps the name in one write, so no transaction is
+	// ever left carrying both tags or neither.
+	rename := bson.A{bson.M{"$set": bson.M{
+		"tags": bson.M{"$concatArrays": bson.A{
+			bson.M{"$filter": bson.M{
+				"input": "$tags",
+				"cond":  bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$$this", bson.A{oldName, newName}}}}},
+			}},
+			bson.A{newName},
+		}},
+		"version": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+	}}}
+	tagged, err := m.updateTaggedTransactions(oldName, rename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename tag on transactions: %v", err)
+	}
+
+	if _, err := m.Database.Collection("tags").DeleteOne(m.Ctx, bson.M{"_id": oldName}); err != nil {
+		return tagged, fmt.Errorf("failed to delete old tag: %v", err)
+	}
+	return tagged, nil
+}
+
+// DeleteTag removes a tag and strips it from every transaction
+func (m *MongoClient) DeleteTag(name string) ([]Transaction, error) {
+	tagged, err := m.updateTaggedTransactions(name, bson.M{"$pull": bson.M{"tags": name}, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove tag from transactions: %v", err)
+	}
+	if _, err := m.Database.Collection("tags").DeleteOne(m.Ctx, bson.M{"_id": name}); err != nil {
+		return tagged, fmt.Errorf("failed to delete tag: %v", err)
+	}
+	return tagged, nil
+}
+
+// updateTaggedTransactions applies update to every transaction carrying tag
+// and returns those transactions as they were before it.
+func (m *MongoClient) updateTaggedTransactions(tag string, update interface{}) ([]Transaction, error) {
+	transactions := m.Database.Collection("transactions")
+	cursor, err := transactions.Find(m.Ctx, bson.M{"tags": tag})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.Ctx)
+
+	var docs []mongoTransaction
+	if err := cursor.All(m.Ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+	tagged := make([]Transaction, len(docs))
+	objIDs := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		tagged[i] = doc.Transaction
+		tagged[i].ID = doc.ID.Hex()
+		objIDs[i] = doc.ID
+	}
+
+	if _, err := transactions.UpdateMany(m.Ctx, bson.M{"_id": bson.M{"$in": objIDs}, "tags": tag}, update); err != nil {
+		return nil, err
+	}
+	return tagged, nil
+}
+
+func (m *MongoClient) AddTransactionTags(ids []string, tags []string) (int, error) {
+	objIDs, err := mongoObjectIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+	filter := bson.M{"_id": bson.M{"$in": objIDs}}
+	update := bson.M{"$addToSet": bson.M{"tags": bson.M{"$each": tags}}, "$inc": bson.M{"version": 1}}
+	result, err := m.Database.Collection("transactions").UpdateMany(m.Ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to tag transactions: %v", err)
+	}
+	return int(result.ModifiedCount), nil
+}
+
+func (m *MongoClient) RemoveTransactionTags(ids []string, tags []string) (int, error) {
+	objIDs, err := mongoObjectIDs(ids)
+	if err != nil {
+		return 0, err
+	}
+	filter := bson.M{"_id": bson.M{"$in": objIDs}}
+	update := bson.M{"$pull": bson.M{"tags": bson.M{"$in": tags}}, "$inc": bson.M{"version": 1}}
+	result, err := m.Database.Collection("transactions").UpdateMany(m.Ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("failed to untag transactions: %v", err)
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// SaveTag creates a tag or updates its description
+func (f *FirestoreClient) SaveTag(tag Tag) error {
+	ref := f.Client.Collection("tags").Doc(tag.Name)
+	_, err := ref.Update(f.Ctx, []firestore.Update{{Path: "description", Value: tag.Description}})
+	if status.Code(err) == codes.NotFound {
+		_, err = ref.Set(f.Ctx, tag)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save tag: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) ListTags() ([]Tag, error) {
+	iter := f.Client.Collection("tags").OrderBy("name", firestore.Asc).Documents(f.Ctx)
+	defer iter.Stop()
+
+	var tags []Tag
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tags: %v", err)
+		}
+		var tag Tag
+		if err := doc.DataTo(&tag); err != nil {
+			return nil, fmt.Errorf("failed to decode tag: %v", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// RenameTag moves a tag and every transaction carrying it to a new name
+func (f *FirestoreClient) RenameTag(oldName, newName string) ([]Transaction, error) {
+	doc, err := f.Client.Collection("tags").Doc(oldName).Get(f.Ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tag %q: %v", oldName, err)
+	}
+	var tag Tag
+	if err := doc.DataTo(&tag); err != nil {
+		return nil, fmt.Errorf("failed to decode tag: %v", err)
+	}
+	tag.Name = newName
+	if err := f.SaveTag(tag); err != nil {
+		return nil, err
+	}
+
+	// Firestore cannot apply ArrayUnion and ArrayRemove to the same field in
+	// one write, so rewrite the tag list of each transaction instead.
+	renamed, err := f.updateTaggedTransactions(oldName, func(tags []string) []string {
+		out := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != oldName && t != newName {
+				out = append(out, t)
+			}
+		}
+		return append(out, newName)
+	})
+	if err != nil {
+		return renamed, fmt.Errorf("failed to rename tag on transactions: %v", err)
+	}
+
+	if _, err := f.Client.Collection("tags").Doc(oldName).Delete(f.Ctx); err != nil {
+		return renamed, fmt.Errorf("failed to delete old tag: %v", err)
+	}
+	return renamed, nil
+}
+
+// DeleteTag removes a tag and strips it from every transaction
+func (f *FirestoreClient) DeleteTag(name string) ([]Transaction, error) {
+	removed, err := f.updateTaggedTransactions(name, func(tags []string) []string {
+		out := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != name {
+				out = append(out, t)
+			}
+		}
+		return out
+	})
+	if err != nil {
+		return removed, fmt.Errorf("failed to remove tag from transactions: %v", err)
+	}
+
+	if _, err := f.Client.Collection("tags").Doc(name).Delete(f.Ctx); err != nil {
+		return removed, fmt.Errorf("failed to delete tag: %v", err)
+	}
+	return removed, nil
+}
+
+// updateTaggedTransactions rewrites the tags of every transaction carrying
+// tag and returns the transactions it wrote as they were before.
+func (f *FirestoreClient) updateTaggedTransactions(tag string, rewrite func([]string) []string) ([]Transaction, error) {
+	iter := f.Client.Collection("transactions").Where("tags", "array-contains", tag).Documents(f.Ctx)
+	defer iter.Stop()
+
+	batch := f.Client.Batch()
+	var pending, updated []Transaction
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return updated, err
+		}
+
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return updated, err
+		}
+		tx.ID = doc.Ref.ID
+		batch.Update(doc.Ref, []firestore.Update{
+			{Path: "tags", Value: rewrite(tx.Tags)},
+			{Path: "version", Value: firestore.Increment(1)},
+		})
+		pending = append(pending, tx)
+		if len(pending) >= 400 {
+			if _, err := batch.Commit(f.Ctx); err != nil {
+				return updated, err
+			}
+			updated = append(updated, pending...)
+			batch = f.Client.Batch()
+			pending = nil
+		}
+	}
+	if len(pending) > 0 {
+		if _, err := batch.Commit(f.Ctx); err != nil {
+			return updated, err
+		}
+		updated = append(updated, pending...)
+	}
+	return updated, nil
+}
+
+func (f *FirestoreClient) AddTransactionTags(ids []string, tags []string) (int, error) {
+	values := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		values[i] = tag
+	}
+	// ArrayUnion reports no difference between a write that added a tag and
+	// one that found it already there, so only transactions missing one of
+	// the tags are written and counted.
+	missing, err := f.transactionsMatching(ids, func(tx Transaction) bool {
+		for _, tag := range tags {
+			if !tx.HasTag(tag) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to tag transactions: %v", err)
+	}
+	if err := f.updateTransactionsByID(missing, "tags", firestore.ArrayUnion(values...)); err != nil {
+		return 0, fmt.Errorf("failed to tag transactions: %v", err)
+	}
+	return len(missing), nil
+}
+
+func (f *FirestoreClient) RemoveTransactionTags(ids []string, tags []string) (int, error) {
+	values := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		values[i] = tag
+	}
+	carrying, err := f.transactionsMatching(ids, func(tx Transaction) bool {
+		for _, tag := range tags {
+			if tx.HasTag(tag) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to untag transactions: %v", err)
+	}
+	if err := f.updateTransactionsByID(carrying, "tags", firestore.ArrayRemove(values...)); err != nil {
+		return 0, fmt.Errorf("failed to untag transactions: %v", err)
+	}
+	return len(carrying), nil
+}
+
+// transactionsMatching returns the IDs of the existing transactions among
+// ids for which match is true.
+func (f *FirestoreClient) transactionsMatching(ids []string, match func(Transaction) bool) ([]string, error) {
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = f.Client.Collection("transactions").Doc(id)
+	}
+	docs, err := f.Client.GetAll(f.Ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, doc := range docs {
+		if !doc.Exists() {
+			continue
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return nil, err
+		}
+		if match(tx) {
+			matched = append(matched, doc.Ref.ID)
+		}
+	}
+	return matched, nil
+}
+
+// updateTransactionsByID sets one field on many transactions in batches of
+// 400 and bumps their version
+func (f *FirestoreClient) updateTransactionsByID(ids []string, path string, value interface{}) error {
+	return f.applyTransactionUpdates(ids, []firestore.Update{
+		{Path: path, Value: value},
+		{Path: "version", Value: firestore.Increment(1)},
+	})
+}
+
+// applyTransactionUpdates writes the same updates to many transactions in
+// batches of 400
+func (f *FirestoreClient) applyTransactionUpdates(ids []string, updates []firestore.Update) error {
+	batch := f.Client.Batch()
+	batchSize := 0
+	for _, id := range ids {
+		batch.Update(f.Client.Collection("transactions").Doc(id), updates)
+		batchSize++
+		if batchSize >= 400 {
+			if _, err := batch.Commit(f.Ctx); err != nil {
+				return err
+			}
+			batch = f.Client.Batch()
+			batchSize = 0
+		}
+	}
+	if batchSize > 0 {
+		if _, err := batch.Commit(f.Ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
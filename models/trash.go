@@ -0,0 +1,115 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/api/iterator"
+)
+
+// TrashStore is implemented by database backends that support soft delete.
+// Trashed transactions keep their data until they are purged with
+// DeleteTransaction.
+type TrashStore interface {
+	TrashTransaction(id string, deletedAt time.Time) error
+	RestoreTransaction(id string) error
+	ListTrashedTransactions() ([]Transaction, error)
+}
+
+func (m *MongoClient) TrashTransaction(id string, deletedAt time.Time) error {
+	return m.setDeletedAt(id, bson.M{"$set": bson.M{"deletedat": deletedAt}, "$inc": bson.M{"version": 1}})
+}
+
+func (m *MongoClient) RestoreTransaction(id string) error {
+	return m.setDeletedAt(id, bson.M{"$unset": bson.M{"deletedat": ""}, "$inc": bson.M{"version": 1}})
+}
+
+func (m *MongoClient) setDeletedAt(id string, update bson.M) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("invalid transaction ID: %v", err)
+	}
+	result, err := m.Database.Collection("transactions").UpdateOne(m.Ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction trash state: %v", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	return nil
+}
+
+// ListTrashedTransactions returns the trash, most recently deleted first
+func (m *MongoClient) ListTrashedTransactions() ([]Transaction, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "deletedat", Value: -1}})
+	cursor, err := m.Database.Collection("transactions").Find(m.Ctx, bson.M{"deletedat": bson.M{"$exists": true}}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trashed transactions: %v", err)
+	}
+	defer cursor.Close(m.Ctx)
+
+	var docs []mongoTransaction
+	if err := cursor.All(m.Ctx, &docs); err != nil {
+		return nil, fmt.Errorf("failed to decode trashed transactions: %v", err)
+	}
+
+	transactions := make([]Transaction, len(docs))
+	for i, doc := range docs {
+		transactions[i] = doc.Transaction
+		transactions[i].ID = doc.ID.Hex()
+	}
+	return transactions, nil
+}
+
+func (f *FirestoreClient) TrashTransaction(id string, deletedAt time.Time) error {
+	_, err := f.Client.Collection("transactions").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "deletedat", Value: deletedAt},
+		{Path: "version", Value: firestore.Increment(1)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to trash transaction: %v", err)
+	}
+	return nil
+}
+
+func (f *FirestoreClient) RestoreTransaction(id string) error {
+	_, err := f.Client.Collection("transactions").Doc(id).Update(f.Ctx, []firestore.Update{
+		{Path: "deletedat", Value: nil},
+		{Path: "version", Value: firestore.Increment(1)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to restore transaction: %v", err)
+	}
+	return nil
+}
+
+// ListTrashedTransactions returns the trash, most recently deleted first
+func (f *FirestoreClient) ListTrashedTransactions() ([]Transaction, error) {
+	iter := f.Client.Collection("transactions").
+		Where("deletedat", ">", time.Time{}).
+		OrderBy("deletedat", firestore.Desc).
+		Documents(f.Ctx)
+	defer iter.Stop()
+
+	var txs []Transaction
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch trashed transactions: %v", err)
+		}
+		var tx Transaction
+		if err := doc.DataTo(&tx); err != nil {
+			return nil, fmt.Errorf("failed to decode trashed transaction: %v", err)
+		}
+		tx.ID = doc.Ref.ID
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
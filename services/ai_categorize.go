@@ -0,0 +1,358 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/ai"
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// DefaultAIConfidence is the confidence an AI answer needs to become a
+// vendor mapping without review.
+const DefaultAIConfidence = 0.8
+
+// aiClassifyBatchSize is how many vendors go to the model in one request.
+const aiClassifyBatchSize = 50
+
+// aiCategorizerActor is recorded on transactions the job recategorizes.
+const aiCategorizerActor = "ai-categorizer"
+
+// AICategorizeResult counts what a categorization run did. Mapped vendors
+// got an "ai" vendor mapping; Recategorized counts their Other transactions
+// that were moved to the new category.
+type AICategorizeResult struct {
+	DryRun          bool                        `json:"dry_run"`
+	Vendors         int                         `json:"vendors"`
+	Mapped          int                         `json:"mapped"`
+	QueuedForReview int                         `json:"queued_for_review"`
+	Unanswered      int                         `json:"unanswered"`
+	Recategorized   int                         `json:"recategorized"`
+	Suggestions     []models.CategorySuggestion `json:"suggestions"`
+}
+
+// AICategorizeService asks a model to categorize the vendors of Other
+// transactions.
+type AICategorizeService struct {
+	dbClient   models.DatabaseClient
+	store      models.SuggestionStore
+	classifier ai.VendorClassifier
+}
+
+func NewAICategorizeService(dbClient models.DatabaseClient, store models.SuggestionStore, classifier ai.VendorClassifier) *AICategorizeService {
+	return &AICategorizeService{dbClient: dbClient, store: store, classifier: classifier}
+}
+
+// uncategorizedVendor groups the Other transactions from one vendor.
+type uncategorizedVendor struct {
+	key    string
+	name   string
+	ids    []string
+	amount models.Money
+}
+
+// Run collects the distinct vendors of uncategorized debits that have no
+// mapping or earlier suggestion and classifies them in batches. Answers at
+// or above minConfidence become "ai" vendor mappings and recategorize the
+// vendor's Other transactions; the rest go to the review list. A dry run
+// still asks the model but writes nothing.
+func (s *AICategorizeService) Run(minConfidence float64, dryRun bool) (AICategorizeResult, error) {
+	result := AICategorizeResult{DryRun: dryRun, Suggestions: []models.CategorySuggestion{}}
+	if minConfidence <= 0 {
+		minConfidence = DefaultAIConfidence
+	}
+
+	vendors, err := s.unmappedVendors()
+	if err != nil {
+		return result, err
+	}
+	result.Vendors = len(vendors)
+	if len(vendors) == 0 {
+		return result, nil
+	}
+
+	categories, err := knownCategories(s.dbClient)
+	if err != nil {
+		return result, err
+	}
+	canonical := make(map[string]string, len(categories))
+	for _, category := range categories {
+		canonical[strings.ToLower(category)] = category
+	}
+
+	for start := 0; start < len(vendors); start += aiClassifyBatchSize {
+		end := start + aiClassifyBatchSize
+		if end > len(vendors) {
+			end = len(vendors)
+		}
+		batch := vendors[start:end]
+		names := make([]string, len(batch))
+		byName := make(map[string]uncategorizedVendor, len(batch))
+		for i, vendor := range batch {
+			names[i] = vendor.name
+			byName[vendor.key] = vendor
+		}
+
+		answers, err := s.classifier.ClassifyVendors(names, categories)
+		if err != nil {
+			return result, err
+		}
+
+		answered := map[string]bool{}
+		for _, answer := range answers {
+			key := strings.ToLower(strings.TrimSpace(answer.Vendor))
+			vendor, ok := byName[key]
+			if !ok || answered[key] {
+				continue
+			}
+			answered[key] = true
+
+			category, known := canonical[strings.ToLower(strings.TrimSpace(answer.Category))]
+			if !known {
+				category = strings.TrimSpace(answer.Category)
+			}
+			suggestion := models.CategorySuggestion{
+				ID:               categorySuggestionID(vendor.key),
+				Vendor:           vendor.key,
+				Category:         category,
+				Confidence:       answer.Confidence,
+				TransactionCount: len(vendor.ids),
+				Amount:           vendor.amount,
+				Status:           models.SuggestionPending,
+				CreatedAt:        time.Now().UTC(),
+			}
+
+			if known && category != "Other" && answer.Confidence >= minConfidence {
+				suggestion.Status = models.SuggestionAccepted
+				result.Mapped++
+				if !dryRun {
+					changed, err := s.applyMapping(vendor, category, models.MappingSourceAI, aiCategorizerActor, models.AuditSourceSystem)
+					result.Recategorized += changed
+					if err != nil {
+						return result, err
+					}
+				}
+			} else {
+				result.QueuedForReview++
+				if !dryRun {
+					if err := s.store.SaveCategorySuggestion(suggestion); err != nil {
+						return result, err
+					}
+				}
+			}
+			result.Suggestions = append(result.Suggestions, suggestion)
+		}
+		result.Unanswered += len(batch) - len(answered)
+	}
+
+	log.Printf("ai categorization completed dry_run=%t vendors=%d mapped=%d queued=%d unanswered=%d recategorized=%d",
+		dryRun, result.Vendors, result.Mapped, result.QueuedForReview, result.Unanswered, result.Recategorized)
+	return result, nil
+}
+
+// ListReview returns suggestions with the given status, most transactions
+// first.
+func (s *AICategorizeService) ListReview(status string) ([]models.CategorySuggestion, error) {
+	suggestions, err := s.store.ListCategorySuggestions(status)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].TransactionCount > suggestions[j].TransactionCount
+	})
+	return suggestions, nil
+}
+
+// ResolveSuggestion applies a review decision. "accept" saves a manual
+// mapping to category (the suggested one when empty) and recategorizes the
+// vendor's Other transactions; "reject" closes the suggestion.
+func (s *AICategorizeService) ResolveSuggestion(id, action, category, actor string) (int, error) {
+	suggestion, err := s.store.GetCategorySuggestion(id)
+	if err != nil {
+		return 0, err
+	}
+	if suggestion.Status != models.SuggestionPending {
+		return 0, fmt.Errorf("category suggestion %s is already %s", id, suggestion.Status)
+	}
+
+	switch action {
+	case "accept":
+		category = strings.TrimSpace(category)
+		if category == "" {
+			category = suggestion.Category
+		}
+		if category == "" || strings.EqualFold(category, "Other") {
+			return 0, fmt.Errorf("a category other than Other is required to accept")
+		}
+		if categories, ok := s.dbClient.(models.CategoryStore); ok {
+			if category, err = NewCategoryService(s.dbClient, categories).ResolveCategory(category); err != nil {
+				return 0, err
+			}
+		}
+		vendors, err := s.uncategorizedVendors()
+		if err != nil {
+			return 0, err
+		}
+		vendor := uncategorizedVendor{key: suggestion.Vendor}
+		for _, candidate := range vendors {
+			if candidate.key == suggestion.Vendor {
+				vendor = candidate
+				break
+			}
+		}
+		changed, err := s.applyMapping(vendor, category, models.MappingSourceManual, actor, models.AuditSourceUI)
+		if err != nil {
+			return changed, err
+		}
+		return changed, s.store.UpdateCategorySuggestionStatus(id, models.SuggestionAccepted)
+	case "reject":
+		return 0, s.store.UpdateCategorySuggestionStatus(id, models.SuggestionRejected)
+	default:
+		return 0, fmt.Errorf("unsupported action %q, expected accept or reject", action)
+	}
+}
+
+// applyMapping saves the vendor mapping and moves the vendor's Other
+// transactions to category when the backend supports bulk edits.
+func (s *AICategorizeService) applyMapping(vendor uncategorizedVendor, category, source, actor, auditSource string) (int, error) {
+	mapping := &models.CategoryMapping{
+		Vendor:   vendor.key,
+		Category: category,
+		Source:   source,
+		Created:  time.Now().UTC(),
+	}
+	if err := s.dbClient.SaveCategoryMapping(mapping); err != nil {
+		return 0, err
+	}
+
+	store, ok := s.dbClient.(models.BulkStore)
+	if !ok || len(vendor.ids) == 0 {
+		return 0, nil
+	}
+	result, err := NewBulkEditService(s.dbClient, store).Apply(BulkEditRequest{
+		IDs:      vendor.ids,
+		Action:   BulkActionCategory,
+		Category: category,
+	}, actor, auditSource)
+	return result.Changed, err
+}
+
+// unmappedVendors returns the uncategorized vendors that have no mapping or
+// earlier suggestion yet.
+func (s *AICategorizeService) unmappedVendors() ([]uncategorizedVendor, error) {
+	vendors, err := s.uncategorizedVendors()
+	if err != nil {
+		return nil, err
+	}
+	suggestions, err := s.store.ListCategorySuggestions("")
+	if err != nil {
+		return nil, err
+	}
+	suggested := make(map[string]bool, len(suggestions))
+	for _, suggestion := range suggestions {
+		suggested[suggestion.Vendor] = true
+	}
+
+	unmapped := vendors[:0]
+	for _, vendor := range vendors {
+		if suggested[vendor.key] {
+			continue
+		}
+		if mapping, err := s.dbClient.GetCategoryMapping(vendor.key); err == nil && mapping != nil {
+			continue
+		}
+		unmapped = append(unmapped, vendor)
+	}
+	return unmapped, nil
+}
+
+// uncategorizedVendors groups the uncategorized, unsplit debits by vendor,
+// the vendors with the most transactions first.
+func (s *AICategorizeService) uncategorizedVendors() ([]uncategorizedVendor, error) {
+	txs, err := NewReportingService(s.dbClient).ListTransactionsByDateRange(time.Time{}, time.Now(), TransactionFilter{}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := map[string]*uncategorizedVendor{}
+	var order []string
+	for _, tx := range txs {
+		key := strings.ToLower(strings.TrimSpace(tx.Vendor))
+		if key == "" || tx.IsCredit() || len(tx.Splits) > 0 || !isUncategorized(tx) {
+			continue
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &uncategorizedVendor{key: key, name: strings.TrimSpace(tx.Vendor)}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.ids = append(group.ids, tx.ID)
+		group.amount += tx.Amount
+	}
+
+	vendors := make([]uncategorizedVendor, 0, len(order))
+	for _, key := range order {
+		vendors = append(vendors, *groups[key])
+	}
+	sort.SliceStable(vendors, func(i, j int) bool {
+		if len(vendors[i].ids) != len(vendors[j].ids) {
+			return len(vendors[i].ids) > len(vendors[j].ids)
+		}
+		return vendors[i].key < vendors[j].key
+	})
+	return vendors, nil
+}
+
+// knownCategories lists the managed category list when the backend keeps
+// one, and otherwise the categories the rules assign and the stored category
+// tree. Other is always first.
+func knownCategories(dbClient models.DatabaseClient) ([]string, error) {
+	seen := map[string]bool{"Other": true}
+	categories := []string{"Other"}
+	add := func(category string) {
+		if category != "" && !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+	if store, ok := dbClient.(models.CategoryStore); ok {
+		managed, err := NewCategoryService(dbClient, store).ListCategories()
+		if err != nil {
+			return nil, err
+		}
+		for _, category := range managed {
+			if !strings.EqualFold(category.Name, "Other") {
+				add(category.Name)
+			}
+		}
+		sort.Strings(categories[1:])
+		return categories, nil
+	}
+
+	for _, compiled := range categoryRules(dbClient) {
+		add(compiled.rule.Category)
+	}
+	for _, category := range models.VendorCategoryMapping {
+		add(category)
+	}
+	if tree := categoryTree(dbClient); tree != nil {
+		for _, category := range tree.names {
+			add(category)
+		}
+	}
+	sort.Strings(categories[1:])
+	return categories, nil
+}
+
+// categorySuggestionID derives a stable document ID from the vendor, so a
+// vendor only ever gets one suggestion.
+func categorySuggestionID(vendor string) string {
+	sum := sha1.Sum([]byte(vendor))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/ai"
+	"github.com/yourusername/expense-tracker/models"
+)
+
+type fakeClassifier struct {
+	answers map[string]ai.VendorCategory
+	calls   [][]string
+}
+
+func (c *fakeClassifier) ClassifyVendors(vendors []string, categories []string) ([]ai.VendorCategory, error) {
+	c.calls = append(c.calls, vendors)
+	var results []ai.VendorCategory
+	for _, vendor := range vendors {
+		if answer, ok := c.answers[strings.ToLower(vendor)]; ok {
+			answer.Vendor = vendor
+			results = append(results, answer)
+		}
+	}
+	return results, nil
+}
+
+func TestAICategorizeMapsConfidentVendorsAndQueuesTheRest(t *testing.T) {
+	now := time.Now().UTC().Add(-time.Hour)
+	db := newTestDB()
+	db.transactions = []models.Transaction{
+		{ID: "1", Vendor: "CULT FIT", Category: "Other", Amount: models.FromRupees(1500), DateTime: now},
+		{ID: "2", Vendor: "Cult Fit", Category: "Other", Amount: models.FromRupees(1500), DateTime: now},
+		{ID: "3", Vendor: "RAMESH KUMAR", Category: "Other", Amount: models.FromRupees(500), DateTime: now},
+		{ID: "4", Vendor: "NEW MERCHANT", Category: "Other", Amount: models.FromRupees(90), DateTime: now},
+		{ID: "5", Vendor: "SALARY", Category: "Other", Amount: models.FromRupees(-90000), DateTime: now},
+		{ID: "6", Vendor: "KNOWN", Category: "Other", Amount: models.FromRupees(10), DateTime: now},
+	}
+	db.mappings["known"] = models.CategoryMapping{Vendor: "known", Category: "Bills", Source: models.MappingSourceManual}
+	classifier := &fakeClassifier{answers: map[string]ai.VendorCategory{
+		"cult fit":     {Category: "entertainment", Confidence: 0.95},
+		"ramesh kumar": {Category: "Other", Confidence: 0.9},
+	}}
+
+	service := NewAICategorizeService(db, db, classifier)
+	result, err := service.Run(0, false)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if result.Vendors != 3 || result.Mapped != 1 || result.QueuedForReview != 1 || result.Unanswered != 1 || result.Recategorized != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if got := db.mappings["cult fit"]; got.Category != "Entertainment" || got.Source != models.MappingSourceAI {
+		t.Fatalf("expected an ai mapping to Entertainment, got %+v", got)
+	}
+	if db.transactions[0].Category != "Entertainment" || db.transactions[1].Category != "Entertainment" {
+		t.Fatalf("expected cult fit transactions to be recategorized, got %+v", db.transactions[:2])
+	}
+
+	review, err := service.ListReview(models.SuggestionPending)
+	if err != nil {
+		t.Fatalf("ListReview returned error: %v", err)
+	}
+	if len(review) != 1 || review[0].Vendor != "ramesh kumar" {
+		t.Fatalf("expected ramesh kumar in review, got %+v", review)
+	}
+
+	// Vendors with a mapping or a suggestion are not sent again.
+	classifier.calls = nil
+	if _, err := service.Run(0, false); err != nil {
+		t.Fatalf("second Run returned error: %v", err)
+	}
+	if len(classifier.calls) != 1 || len(classifier.calls[0]) != 1 || classifier.calls[0][0] != "NEW MERCHANT" {
+		t.Fatalf("expected only NEW MERCHANT to be classified again, got %v", classifier.calls)
+	}
+
+	changed, err := service.ResolveSuggestion(review[0].ID, "accept", "Bills", "me@example.com")
+	if err != nil {
+		t.Fatalf("ResolveSuggestion returned error: %v", err)
+	}
+	if changed != 1 || db.mappings["ramesh kumar"].Source != models.MappingSourceManual || db.transactions[2].Category != "Bills" {
+		t.Fatalf("expected accepted suggestion to map and recategorize, changed=%d mapping=%+v", changed, db.mappings["ramesh kumar"])
+	}
+	if _, err := service.ResolveSuggestion(review[0].ID, "reject", "", ""); err == nil {
+		t.Fatalf("expected error resolving an accepted suggestion")
+	}
+}
+
+func TestAICategorizeDryRunWritesNothing(t *testing.T) {
+	db := newTestDB()
+	db.transactions = []models.Transaction{
+		{ID: "1", Vendor: "CULT FIT", Category: "Other", Amount: models.FromRupees(1500), DateTime: time.Now().UTC().Add(-time.Hour)},
+	}
+	classifier := &fakeClassifier{answers: map[string]ai.VendorCategory{"cult fit": {Category: "Entertainment", Confidence: 0.5}}}
+
+	result, err := NewAICategorizeService(db, db, classifier).Run(0.4, true)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if result.Mapped != 1 || len(result.Suggestions) != 1 || len(db.mappings) != 0 || len(db.batches) != 0 {
+		t.Fatalf("expected a dry run to only report, got %+v", result)
+	}
+}
+
+func TestKnownCategoriesUsesTheManagedList(t *testing.T) {
+	db := newTestDB()
+	db.categories["c1"] = models.Category{ID: "c1", Name: "Groceries"}
+	db.categories["c2"] = models.Category{ID: "c2", Name: "Eating Out"}
+
+	categories, err := knownCategories(db)
+	if err != nil {
+		t.Fatalf("knownCategories returned error: %v", err)
+	}
+	if strings.Join(categories, ",") != "Other,Eating Out,Groceries" {
+		t.Fatalf("expected only Other and the managed categories, got %v", categories)
+	}
+}
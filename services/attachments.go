@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// MaxAttachmentSize is the largest receipt or invoice accepted for upload.
+const MaxAttachmentSize = 10 << 20
+
+// allowedAttachmentTypes are the content types served back to the browser.
+// Types are sniffed from the file rather than trusted from the upload.
+var allowedAttachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+}
+
+type AttachmentService struct {
+	dbClient models.DatabaseClient
+	store    models.AttachmentStore
+	blobs    models.BlobStore
+}
+
+func NewAttachmentService(dbClient models.DatabaseClient, store models.AttachmentStore, blobs models.BlobStore) *AttachmentService {
+	return &AttachmentService{dbClient: dbClient, store: store, blobs: blobs}
+}
+
+// Upload stores a file and records it on the transaction and in its change
+// log.
+func (s *AttachmentService) Upload(transactionID, fileName string, r io.Reader, actor, source string) (models.Attachment, error) {
+	before, err := s.dbClient.GetTransaction(transactionID)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+
+	buffered := bufio.NewReaderSize(r, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return models.Attachment{}, fmt.Errorf("failed to read upload: %v", err)
+	}
+	contentType := http.DetectContentType(head)
+	if !allowedAttachmentTypes[contentType] {
+		return models.Attachment{}, fmt.Errorf("unsupported attachment type %s, expected a PDF or image", contentType)
+	}
+
+	id, err := newRecordID()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	attachment := models.Attachment{
+		ID:          id,
+		FileName:    sanitizeFileName(fileName),
+		ContentType: contentType,
+		Key:         transactionID + "/" + id,
+		UploadedAt:  time.Now().UTC(),
+	}
+
+	limited := io.LimitReader(buffered, MaxAttachmentSize+1)
+	size, err := s.blobs.Put(attachment.Key, contentType, limited)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	if size > MaxAttachmentSize {
+		s.deleteBlob(attachment.Key)
+		return models.Attachment{}, fmt.Errorf("attachment is larger than %d MB", MaxAttachmentSize>>20)
+	}
+	attachment.Size = size
+
+	if err := s.store.AddTransactionAttachment(transactionID, attachment); err != nil {
+		s.deleteBlob(attachment.Key)
+		return models.Attachment{}, err
+	}
+	after := *before
+	after.Attachments = append(append([]models.Attachment{}, before.Attachments...), attachment)
+	after.Version++
+	RecordTransactionChange(s.dbClient, *before, after, actor, source, models.AuditActionAttachment)
+	return attachment, nil
+}
+
+// Open returns an attachment's metadata and content. The caller closes the
+// reader.
+func (s *AttachmentService) Open(transactionID, attachmentID string) (models.Attachment, io.ReadCloser, error) {
+	attachment, err := s.find(transactionID, attachmentID)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	content, err := s.blobs.Get(attachment.Key)
+	if err != nil {
+		return models.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+// Delete removes an attachment from the transaction, records that in its
+// change log and deletes the stored file.
+func (s *AttachmentService) Delete(transactionID, attachmentID, actor, source string) error {
+	before, err := s.dbClient.GetTransaction(transactionID)
+	if err != nil {
+		return err
+	}
+	attachment, err := s.find(transactionID, attachmentID)
+	if err != nil {
+		return err
+	}
+	if err := s.store.RemoveTransactionAttachment(transactionID, attachmentID); err != nil {
+		return err
+	}
+	after := *before
+	after.Attachments = nil
+	for _, kept := range before.Attachments {
+		if kept.ID != attachmentID {
+			after.Attachments = append(after.Attachments, kept)
+		}
+	}
+	after.Version++
+	RecordTransactionChange(s.dbClient, *before, after, actor, source, models.AuditActionAttachment)
+	return s.blobs.Delete(attachment.Key)
+}
+
+// DeleteAll removes the stored files of a transaction that is being deleted.
+// Failures are logged so they never block deleting the transaction itself.
+func (s *AttachmentService) DeleteAll(tx models.Transaction) {
+	for _, attachment := range tx.Attachments {
+		s.deleteBlob(attachment.Key)
+	}
+}
+
+func (s *AttachmentService) find(transactionID, attachmentID string) (models.Attachment, error) {
+	tx, err := s.dbClient.GetTransaction(transactionID)
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	for _, attachment := range tx.Attachments {
+		if attachment.ID == attachmentID {
+			return attachment, nil
+		}
+	}
+	return models.Attachment{}, fmt.Errorf("attachment %s not found on transaction %s", attachmentID, transactionID)
+}
+
+func (s *AttachmentService) deleteBlob(key string) {
+	if err := s.blobs.Delete(key); err != nil {
+		log.Printf("attachment blob delete failed key=%s err=%v", key, err)
+	}
+}
+
+// newRecordID returns a random hex id for records such as attachments and
+// category rules.
+func newRecordID() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate id: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// sanitizeFileName keeps the base name of an uploaded file without quotes or
+// control characters, so it is safe to echo in a Content-Disposition header.
+func sanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func newAttachmentTestService(t *testing.T) (*AttachmentService, *testDB) {
+	blobs, err := models.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalBlobStore returned error: %v", err)
+	}
+	db := newTestDB(models.Transaction{ID: "tx-1", Vendor: "CROMA"})
+	return NewAttachmentService(db, db, blobs), db
+}
+
+func TestAttachmentUploadDownloadDelete(t *testing.T) {
+	service, db := newAttachmentTestService(t)
+	pdf := []byte("%PDF-1.4\n1 0 obj\n<<>>\nendobj\n")
+
+	attachment, err := service.Upload("tx-1", `C:\receipts\"croma" invoice.pdf`, bytes.NewReader(pdf), "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("Upload returned error: %v", err)
+	}
+	if attachment.ContentType != "application/pdf" || attachment.Size != int64(len(pdf)) {
+		t.Fatalf("unexpected attachment metadata %+v", attachment)
+	}
+	if attachment.FileName != "croma invoice.pdf" {
+		t.Fatalf("expected sanitized file name, got %q", attachment.FileName)
+	}
+	if len(db.find("tx-1").Attachments) != 1 {
+		t.Fatalf("expected metadata on the transaction, got %+v", db.find("tx-1").Attachments)
+	}
+
+	_, content, err := service.Open("tx-1", attachment.ID)
+	if err != nil {
+		t.Fatalf("Open returned error: %v", err)
+	}
+	stored, _ := io.ReadAll(content)
+	content.Close()
+	if !bytes.Equal(stored, pdf) {
+		t.Fatalf("downloaded content does not match upload")
+	}
+
+	if err := service.Delete("tx-1", attachment.ID, "me@example.com", models.AuditSourceUI); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if len(db.find("tx-1").Attachments) != 0 {
+		t.Fatalf("expected metadata to be removed, got %+v", db.find("tx-1").Attachments)
+	}
+	if _, err := service.blobs.Get(attachment.Key); err != models.ErrBlobNotFound {
+		t.Fatalf("expected blob to be deleted, got %v", err)
+	}
+
+	if len(db.changes) != 2 {
+		t.Fatalf("expected upload and delete in the change log, got %d entries", len(db.changes))
+	}
+	for _, change := range db.changes {
+		if change.Action != models.AuditActionAttachment || change.Actor != "me@example.com" || len(change.Fields) != 1 || change.Fields[0] != "attachments" {
+			t.Fatalf("unexpected change entry %+v", change)
+		}
+	}
+}
+
+func TestAttachmentUploadRejectsHTML(t *testing.T) {
+	service, db := newAttachmentTestService(t)
+
+	_, err := service.Upload("tx-1", "receipt.pdf", strings.NewReader("<html><script>alert(1)</script></html>"), "", models.AuditSourceUI)
+	if err == nil || !strings.Contains(err.Error(), "unsupported attachment type") {
+		t.Fatalf("expected unsupported type error, got %v", err)
+	}
+	if len(db.find("tx-1").Attachments) != 0 {
+		t.Fatalf("expected no attachment to be recorded")
+	}
+}
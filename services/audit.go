@@ -0,0 +1,192 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+type AuditService struct {
+	dbClient models.DatabaseClient
+	store    models.AuditStore
+}
+
+func NewAuditService(dbClient models.DatabaseClient, store models.AuditStore) *AuditService {
+	return &AuditService{dbClient: dbClient, store: store}
+}
+
+// RecordTransactionChange appends the change from before to after to the
+// transaction's change log, if the backend keeps one. after is what the edit
+// wrote: the transaction PatchTransaction returned, or before with the change
+// applied and its version bumped for store methods that return nothing.
+// Re-reading it instead could pin someone else's concurrent write on actor.
+// Failures are logged so auditing never blocks the edit itself.
+func RecordTransactionChange(dbClient models.DatabaseClient, before, after models.Transaction, actor, source, action string) {
+	store, ok := dbClient.(models.AuditStore)
+	if !ok {
+		return
+	}
+	if err := NewAuditService(dbClient, store).Record(before, after, actor, source, action); err != nil {
+		log.Printf("audit record failed id=%s action=%s err=%v", before.ID, action, err)
+	}
+}
+
+// Record appends a change entry unless nothing visible changed.
+func (s *AuditService) Record(before, after models.Transaction, actor, source, action string) error {
+	fields := ChangedTransactionFields(before, after)
+	if len(fields) == 0 {
+		return nil
+	}
+	return s.store.SaveTransactionChange(models.TransactionChange{
+		TransactionID: before.ID,
+		Version:       after.Version,
+		Action:        action,
+		Source:        source,
+		Actor:         actor,
+		Fields:        fields,
+		Before:        before,
+		After:         after,
+		ChangedAt:     time.Now().UTC(),
+	})
+}
+
+// History returns the change log of a transaction, oldest first.
+func (s *AuditService) History(id string) ([]models.TransactionChange, error) {
+	if _, err := s.dbClient.GetTransaction(id); err != nil {
+		return nil, err
+	}
+	return s.store.ListTransactionChanges(id)
+}
+
+// Revert restores a transaction to a recorded version: its editable fields,
+// splits and tags, written as one versioned update so a failure leaves the
+// transaction untouched. Attachments are left alone since deleted files
+// cannot be brought back, and a category that has since left the category
+// list is refused rather than brought back. The revert is itself recorded as
+// a new change.
+func (s *AuditService) Revert(id string, version int64, actor, source string) (*models.Transaction, error) {
+	current, err := s.dbClient.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Version == version {
+		return nil, fmt.Errorf("transaction %s is already at version %d", id, version)
+	}
+
+	changes, err := s.store.ListTransactionChanges(id)
+	if err != nil {
+		return nil, err
+	}
+	target, ok := snapshotAtVersion(changes, version)
+	if !ok {
+		return nil, fmt.Errorf("no recorded state of transaction %s at version %d", id, version)
+	}
+
+	patch := models.TransactionPatch{
+		Type:            &target.Type,
+		Vendor:          &target.Vendor,
+		Merchant:        &target.Merchant,
+		Amount:          &target.Amount,
+		Category:        &target.Category,
+		Notes:           &target.Notes,
+		DateTime:        &target.DateTime,
+		CardEnding:      &target.CardEnding,
+		DebitedAccount:  &target.DebitedAccount,
+		CreditedAccount: &target.CreditedAccount,
+	}
+	if store, ok := s.dbClient.(models.CategoryStore); ok && target.Category != current.Category {
+		category, err := NewCategoryService(s.dbClient, store).ResolveCategory(target.Category)
+		if err != nil {
+			return nil, fmt.Errorf("cannot revert transaction %s: %w", id, err)
+		}
+		patch.Category = &category
+	}
+	if !reflect.DeepEqual(current.Splits, target.Splits) {
+		patch.Splits = &target.Splits
+	}
+	if added, removed := diffTags(current.Tags, target.Tags); len(added) > 0 || len(removed) > 0 {
+		if store, ok := s.dbClient.(models.TagStore); ok && len(added) > 0 {
+			// Registering a tag that then goes unused is harmless, so this
+			// happens before the transaction is written.
+			if err := NewTagService(s.dbClient, store).EnsureTags(added); err != nil {
+				return nil, err
+			}
+		}
+		patch.Tags = &target.Tags
+	}
+	reverted, err := s.dbClient.PatchTransaction(id, patch, current.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Record(*current, *reverted, actor, source, models.AuditActionRevert); err != nil {
+		log.Printf("audit record failed id=%s action=%s err=%v", id, models.AuditActionRevert, err)
+	}
+	return reverted, nil
+}
+
+// ChangedTransactionFields lists the JSON names of the fields that differ
+// between two states of a transaction. Version is bookkeeping and ignored.
+func ChangedTransactionFields(before, after models.Transaction) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	add("type", before.Type != after.Type)
+	add("vendor", before.Vendor != after.Vendor)
+	add("merchant", before.Merchant != after.Merchant)
+	add("amount", before.Amount != after.Amount)
+	add("category", before.Category != after.Category)
+	add("notes", before.Notes != after.Notes)
+	add("date_time", !before.DateTime.Equal(after.DateTime))
+	add("card_ending", before.CardEnding != after.CardEnding)
+	add("debited_account", before.DebitedAccount != after.DebitedAccount)
+	add("credited_account", before.CreditedAccount != after.CreditedAccount)
+	add("duplicate_of", before.DuplicateOf != after.DuplicateOf)
+	add("deleted_at", before.IsTrashed() != after.IsTrashed())
+	add("tags", !reflect.DeepEqual(before.Tags, after.Tags) && (len(before.Tags) > 0 || len(after.Tags) > 0))
+	add("splits", !reflect.DeepEqual(before.Splits, after.Splits) && (len(before.Splits) > 0 || len(after.Splits) > 0))
+	add("attachments", len(before.Attachments) != len(after.Attachments))
+	return fields
+}
+
+// snapshotAtVersion finds the state a transaction had at version in its
+// change log.
+func snapshotAtVersion(changes []models.TransactionChange, version int64) (models.Transaction, bool) {
+	for _, change := range changes {
+		if change.After.Version == version {
+			return change.After, true
+		}
+		if change.Before.Version == version {
+			return change.Before, true
+		}
+	}
+	return models.Transaction{}, false
+}
+
+// diffTags returns the tags to add to and remove from current to reach target.
+func diffTags(current, target []string) (added, removed []string) {
+	has := func(tags []string, tag string) bool {
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+	for _, tag := range target {
+		if !has(current, tag) {
+			added = append(added, tag)
+		}
+	}
+	for _, tag := range current {
+		if !has(target, tag) {
+			removed = append(removed, tag)
+		}
+	}
+	return added, removed
+}
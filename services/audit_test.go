@@ -0,0 +1,139 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestAuditRecordsEditAndRevertsToParsedValues(t *testing.T) {
+	db := newTestDB(models.Transaction{
+		ID:       "tx1",
+		Type:     "HDFCCreditCard",
+		Vendor:   "SWIGGY LIMITED",
+		Amount:   models.FromRupees(420),
+		Category: "Food",
+		DateTime: time.Date(2026, 5, 2, 13, 5, 0, 0, time.UTC),
+	})
+	db.categories["c1"] = models.Category{ID: "c1", Name: "Food"}
+	db.categories["c2"] = models.Category{ID: "c2", Name: "Travel"}
+
+	before := *db.find("tx1")
+	category, amount := "Travel", models.FromRupees(42)
+	after, err := db.PatchTransaction("tx1", models.TransactionPatch{Category: &category, Amount: &amount}, 0)
+	if err != nil {
+		t.Fatalf("PatchTransaction returned error: %v", err)
+	}
+	RecordTransactionChange(db, before, *after, "me@example.com", models.AuditSourceUI, models.AuditActionUpdate)
+
+	if len(db.changes) != 1 {
+		t.Fatalf("expected one change entry, got %d", len(db.changes))
+	}
+	change := db.changes[0]
+	if change.Actor != "me@example.com" || change.Source != models.AuditSourceUI || change.Version != 1 {
+		t.Fatalf("unexpected change metadata: %+v", change)
+	}
+	if len(change.Fields) != 2 || change.Fields[0] != "amount" || change.Fields[1] != "category" {
+		t.Fatalf("expected amount and category to be recorded, got %v", change.Fields)
+	}
+	if change.Before.Category != "Food" || change.After.Category != "Travel" {
+		t.Fatalf("expected before/after snapshots, got %q -> %q", change.Before.Category, change.After.Category)
+	}
+
+	audit := NewAuditService(db, db)
+	reverted, err := audit.Revert("tx1", 0, "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("Revert returned error: %v", err)
+	}
+	if reverted.Category != "Food" || reverted.Amount != models.FromRupees(420) || reverted.Version != 2 {
+		t.Fatalf("expected parsed values at a new version, got %+v", reverted)
+	}
+	if len(db.changes) != 2 || db.changes[1].Action != models.AuditActionRevert {
+		t.Fatalf("expected the revert to be recorded, got %+v", db.changes)
+	}
+
+	if _, err := audit.Revert("tx1", 7, "", models.AuditSourceUI); err == nil {
+		t.Fatalf("expected error for an unknown version")
+	}
+}
+
+func TestRevertRestoresTagsAndSplitsInOneWrite(t *testing.T) {
+	db := newTestDB(models.Transaction{
+		ID:       "tx1",
+		Vendor:   "CROMA",
+		Amount:   models.FromRupees(1000),
+		Category: "Shopping",
+		Tags:     []string{"goa-trip"},
+		DateTime: time.Date(2026, 5, 2, 13, 5, 0, 0, time.UTC),
+	})
+
+	before := *db.find("tx1")
+	tags := []string{"reimbursable"}
+	splits := []models.Split{
+		{Category: "Shopping", Amount: models.FromRupees(600)},
+		{Category: "Gifts", Amount: models.FromRupees(400)},
+	}
+	after, err := db.PatchTransaction("tx1", models.TransactionPatch{Tags: &tags, Splits: &splits}, 0)
+	if err != nil {
+		t.Fatalf("PatchTransaction returned error: %v", err)
+	}
+	RecordTransactionChange(db, before, *after, "me@example.com", models.AuditSourceUI, models.AuditActionSplit)
+
+	reverted, err := NewAuditService(db, db).Revert("tx1", 0, "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("Revert returned error: %v", err)
+	}
+	if len(reverted.Tags) != 1 || reverted.Tags[0] != "goa-trip" || len(reverted.Splits) != 0 {
+		t.Fatalf("expected original tags and no splits, got tags %v splits %v", reverted.Tags, reverted.Splits)
+	}
+	if reverted.Version != 2 {
+		t.Fatalf("expected the revert to be a single write at version 2, got %d", reverted.Version)
+	}
+}
+
+func TestRevertRestoresMerchantAndRefusesDeletedCategory(t *testing.T) {
+	db := newTestDB(models.Transaction{
+		ID:       "tx1",
+		Vendor:   "SWIGGY LIMITED",
+		Merchant: "Swiggy",
+		Amount:   models.FromRupees(420),
+		Category: "Food",
+		DateTime: time.Date(2026, 5, 2, 13, 5, 0, 0, time.UTC),
+	})
+	db.categories["c1"] = models.Category{ID: "c1", Name: "Food"}
+	db.categories["c2"] = models.Category{ID: "c2", Name: "Dining"}
+
+	before := *db.find("tx1")
+	vendor, merchant := "ZOMATO", "Zomato"
+	after, err := db.PatchTransaction("tx1", models.TransactionPatch{Vendor: &vendor, Merchant: &merchant}, 0)
+	if err != nil {
+		t.Fatalf("PatchTransaction returned error: %v", err)
+	}
+	RecordTransactionChange(db, before, *after, "", models.AuditSourceUI, models.AuditActionUpdate)
+
+	audit := NewAuditService(db, db)
+	reverted, err := audit.Revert("tx1", 0, "", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("Revert returned error: %v", err)
+	}
+	if reverted.Vendor != "SWIGGY LIMITED" || reverted.Merchant != "Swiggy" {
+		t.Fatalf("expected vendor and merchant to be restored, got %q / %q", reverted.Vendor, reverted.Merchant)
+	}
+
+	before = *reverted
+	category := "Dining"
+	after, err = db.PatchTransaction("tx1", models.TransactionPatch{Category: &category}, before.Version)
+	if err != nil {
+		t.Fatalf("PatchTransaction returned error: %v", err)
+	}
+	RecordTransactionChange(db, before, *after, "", models.AuditSourceUI, models.AuditActionUpdate)
+	delete(db.categories, "c1")
+
+	if _, err := audit.Revert("tx1", before.Version, "", models.AuditSourceUI); err == nil {
+		t.Fatalf("expected reverting to a deleted category to fail")
+	}
+	if current := db.find("tx1"); current.Category != "Dining" || current.Version != after.Version {
+		t.Fatalf("expected the refused revert to leave the transaction alone, got %+v", current)
+	}
+}
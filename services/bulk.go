@@ -0,0 +1,258 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// bulkBatchSize is how many transactions a single bulk write touches.
+const bulkBatchSize = 200
+
+// Bulk edit actions
+const (
+	BulkActionCategory = "category"
+	BulkActionTag      = "tag"
+	BulkActionUntag    = "untag"
+	BulkActionDelete   = "delete"
+)
+
+// BulkEditRequest selects transactions either by IDs or by a period plus
+// filter, and says what to do with them.
+type BulkEditRequest struct {
+	IDs      []string
+	Period   string
+	Filter   TransactionFilter
+	Action   string
+	Category string
+	Tags     []string
+	DryRun   bool
+}
+
+// BulkEditResult counts the selected transactions. Changed is what was (or,
+// for a dry run, would be) written; Skipped were selected but left alone,
+// such as split transactions on a category change.
+type BulkEditResult struct {
+	DryRun   bool `json:"dry_run"`
+	Matched  int  `json:"matched"`
+	Changed  int  `json:"changed"`
+	Skipped  int  `json:"skipped"`
+	NotFound int  `json:"not_found"`
+}
+
+type BulkEditService struct {
+	dbClient models.DatabaseClient
+	store    models.BulkStore
+}
+
+func NewBulkEditService(dbClient models.DatabaseClient, store models.BulkStore) *BulkEditService {
+	return &BulkEditService{dbClient: dbClient, store: store}
+}
+
+// Apply runs a bulk edit in batches of bulkBatchSize. Every changed
+// transaction gets its own entry in the change log.
+func (s *BulkEditService) Apply(req BulkEditRequest, actor, source string) (BulkEditResult, error) {
+	result := BulkEditResult{DryRun: req.DryRun}
+
+	change, action, err := s.prepare(&req)
+	if err != nil {
+		return result, err
+	}
+
+	selected, notFound, err := s.selectTransactions(req)
+	if err != nil {
+		return result, err
+	}
+	result.Matched = len(selected)
+	result.NotFound = notFound
+
+	var pending []models.Transaction
+	for _, tx := range selected {
+		if _, ok := change(tx); ok {
+			pending = append(pending, tx)
+		} else {
+			result.Skipped++
+		}
+	}
+	if req.DryRun {
+		result.Changed = len(pending)
+		return result, nil
+	}
+
+	audit, _ := s.dbClient.(models.AuditStore)
+	for start := 0; start < len(pending); start += bulkBatchSize {
+		end := start + bulkBatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batch := pending[start:end]
+		ids := make([]string, len(batch))
+		for i, tx := range batch {
+			ids[i] = tx.ID
+		}
+
+		written, err := s.write(req, ids)
+		result.Changed += written
+		if err != nil {
+			return result, err
+		}
+
+		if audit == nil {
+			continue
+		}
+		auditService := NewAuditService(s.dbClient, audit)
+		for _, before := range batch {
+			after, _ := change(before)
+			after.Version = before.Version + 1
+			if err := auditService.Record(before, after, actor, source, action); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// prepare validates the request and returns a function that applies the
+// action to a transaction, reporting false when it would not change it.
+func (s *BulkEditService) prepare(req *BulkEditRequest) (func(models.Transaction) (models.Transaction, bool), string, error) {
+	if len(req.IDs) == 0 && strings.TrimSpace(req.Period) == "" {
+		return nil, "", fmt.Errorf("select transactions by ids or by a period filter")
+	}
+	if len(req.IDs) > 0 && (strings.TrimSpace(req.Period) != "" || !filterIsEmpty(req.Filter)) {
+		return nil, "", fmt.Errorf("use either ids or a filter, not both")
+	}
+
+	switch req.Action {
+	case BulkActionCategory:
+		req.Category = strings.TrimSpace(req.Category)
+		if req.Category == "" {
+			return nil, "", fmt.Errorf("category is required")
+		}
+		if store, ok := s.dbClient.(models.CategoryStore); ok {
+			category, err := NewCategoryService(s.dbClient, store).ResolveCategory(req.Category)
+			if err != nil {
+				return nil, "", err
+			}
+			req.Category = category
+		}
+		return func(tx models.Transaction) (models.Transaction, bool) {
+			if len(tx.Splits) > 0 || tx.Category == req.Category {
+				return tx, false
+			}
+			tx.Category = req.Category
+			return tx, true
+		}, models.AuditActionUpdate, nil
+
+	case BulkActionTag, BulkActionUntag:
+		if _, ok := s.dbClient.(models.TagStore); !ok {
+			return nil, "", fmt.Errorf("tags not supported for this database backend")
+		}
+		tags, err := NormalizeTags(req.Tags)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(tags) == 0 {
+			return nil, "", fmt.Errorf("at least one tag is required")
+		}
+		req.Tags = tags
+		adding := req.Action == BulkActionTag
+		return func(tx models.Transaction) (models.Transaction, bool) {
+			var added, removed []string
+			for _, tag := range tags {
+				switch {
+				case adding && !tx.HasTag(tag):
+					added = append(added, tag)
+				case !adding && tx.HasTag(tag):
+					removed = append(removed, tag)
+				}
+			}
+			if len(added) == 0 && len(removed) == 0 {
+				return tx, false
+			}
+			tx.Tags = applyTagDiff(tx.Tags, added, removed)
+			return tx, true
+		}, models.AuditActionTag, nil
+
+	case BulkActionDelete:
+		return func(tx models.Transaction) (models.Transaction, bool) {
+			if tx.IsTrashed() {
+				return tx, false
+			}
+			now := time.Now().UTC()
+			tx.DeletedAt = &now
+			return tx, true
+		}, models.AuditActionDelete, nil
+
+	default:
+		return nil, "", fmt.Errorf("unsupported action %q, expected category, tag, untag or delete", req.Action)
+	}
+}
+
+// selectTransactions loads the transactions named by IDs, or every live
+// transaction in the period that matches the filter.
+func (s *BulkEditService) selectTransactions(req BulkEditRequest) ([]models.Transaction, int, error) {
+	if len(req.IDs) == 0 {
+		txs, err := NewReportingService(s.dbClient).ListTransactions(req.Period, req.Filter, 0)
+		return txs, 0, err
+	}
+
+	seen := make(map[string]bool, len(req.IDs))
+	var selected []models.Transaction
+	notFound := 0
+	for _, id := range req.IDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		tx, err := s.dbClient.GetTransaction(id)
+		if err != nil || tx == nil || tx.IsTrashed() {
+			notFound++
+			continue
+		}
+		selected = append(selected, *tx)
+	}
+	return selected, notFound, nil
+}
+
+func (s *BulkEditService) write(req BulkEditRequest, ids []string) (int, error) {
+	switch req.Action {
+	case BulkActionCategory:
+		return s.store.SetTransactionsCategory(ids, req.Category)
+	case BulkActionTag:
+		return NewTagService(s.dbClient, s.dbClient.(models.TagStore)).TagTransactions(ids, req.Tags)
+	case BulkActionUntag:
+		return NewTagService(s.dbClient, s.dbClient.(models.TagStore)).UntagTransactions(ids, req.Tags)
+	default:
+		for _, id := range ids {
+			if err := promoteDuplicates(s.dbClient, id); err != nil {
+				return 0, err
+			}
+		}
+		return s.store.TrashTransactions(ids, time.Now().UTC())
+	}
+}
+
+func filterIsEmpty(f TransactionFilter) bool {
+	return f.Category == "" && f.Vendor == "" && f.Type == "" && f.Query == "" && len(f.Tags) == 0 &&
+		f.MinAmount == nil && f.MaxAmount == nil && f.Search == nil && f.SavedSearch == ""
+}
+
+// applyTagDiff returns tags with added appended and removed dropped.
+func applyTagDiff(tags, added, removed []string) []string {
+	out := make([]string, 0, len(tags)+len(added))
+	for _, tag := range tags {
+		keep := true
+		for _, r := range removed {
+			if tag == r {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			out = append(out, tag)
+		}
+	}
+	return append(out, added...)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestBulkRecategorizeByFilterInBatches(t *testing.T) {
+	now := time.Now().UTC()
+	db := newTestDB()
+	for i := 0; i < 250; i++ {
+		db.transactions = append(db.transactions, models.Transaction{
+			ID: fmt.Sprintf("swiggy-%d", i), Type: "HDFCCreditCard", Vendor: "SWIGGY INSTAMART",
+			Category: "Other", Amount: models.FromRupees(100), DateTime: now,
+		})
+	}
+	db.transactions = append(db.transactions,
+		models.Transaction{ID: "zomato", Type: "HDFCCreditCard", Vendor: "ZOMATO", Category: "Other", Amount: models.FromRupees(100), DateTime: now},
+		models.Transaction{ID: "already", Type: "HDFCCreditCard", Vendor: "SWIGGY", Category: "Food", Amount: models.FromRupees(100), DateTime: now},
+		models.Transaction{
+			ID: "split", Type: "HDFCCreditCard", Vendor: "SWIGGY DINEOUT", Category: "Other", Amount: models.FromRupees(100), DateTime: now,
+			Splits: []models.Split{{Amount: models.FromRupees(60), Category: "Other"}, {Amount: models.FromRupees(40), Category: "Travel"}},
+		},
+	)
+
+	service := NewBulkEditService(db, db)
+	req := BulkEditRequest{
+		Period:   "THIS_MONTH",
+		Filter:   TransactionFilter{Vendor: "swiggy", Category: "Other", Type: "hdfccreditcard"},
+		Action:   BulkActionCategory,
+		Category: "Food",
+		DryRun:   true,
+	}
+
+	preview, err := service.Apply(req, "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+	if preview.Matched != 251 || preview.Changed != 250 || preview.Skipped != 1 || len(db.batches) != 0 {
+		t.Fatalf("unexpected dry run result %+v batches=%d", preview, len(db.batches))
+	}
+
+	req.DryRun = false
+	result, err := service.Apply(req, "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if result.Changed != 250 {
+		t.Fatalf("expected 250 changed, got %+v", result)
+	}
+	if len(db.batches) != 2 || len(db.batches[0]) != bulkBatchSize || len(db.batches[1]) != 50 {
+		t.Fatalf("expected writes in batches of %d, got %d batches", bulkBatchSize, len(db.batches))
+	}
+	if db.transactions[250].Category != "Other" {
+		t.Fatalf("expected non-matching vendor to be left alone")
+	}
+}
+
+func TestBulkEditRejectsIDsWithFilter(t *testing.T) {
+	db := newTestDB()
+	_, err := NewBulkEditService(db, db).Apply(BulkEditRequest{
+		IDs:    []string{"a"},
+		Period: "THIS_MONTH",
+		Action: BulkActionDelete,
+	}, "", models.AuditSourceUI)
+	if err == nil {
+		t.Fatalf("expected error when combining ids and a filter")
+	}
+}
+
+func TestBulkRecategorizeResolvesManagedCategory(t *testing.T) {
+	now := time.Now().UTC()
+	db := newTestDB(models.Transaction{ID: "tx1", Vendor: "SWIGGY", Category: "Other", Amount: models.FromRupees(100), DateTime: now})
+	db.categories["c1"] = models.Category{ID: "c1", Name: "Food & Dining"}
+	service := NewBulkEditService(db, db)
+
+	if _, err := service.Apply(BulkEditRequest{IDs: []string{"tx1"}, Action: BulkActionCategory, Category: "Takeaway"}, "", models.AuditSourceUI); err == nil {
+		t.Fatalf("expected an unknown category to be rejected")
+	}
+	if db.find("tx1").Category != "Other" {
+		t.Fatalf("expected the rejected edit to write nothing, got %q", db.find("tx1").Category)
+	}
+
+	result, err := service.Apply(BulkEditRequest{IDs: []string{"tx1"}, Action: BulkActionCategory, Category: "food & dining"}, "", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("Apply returned error: %v", err)
+	}
+	if result.Changed != 1 || db.find("tx1").Category != "Food & Dining" {
+		t.Fatalf("expected the managed spelling to be written, got %+v %q", result, db.find("tx1").Category)
+	}
+}
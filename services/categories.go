@@ -0,0 +1,823 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// CategoryTree is the parent/child structure of the stored categories. Names
+// compare case-insensitively; a category that is not stored is a top-level
+// category without children. A nil tree is flat.
+type CategoryTree struct {
+	names    map[string]string
+	parents  map[string]string
+	children map[string][]string
+}
+
+func NewCategoryTree(categories []models.Category) *CategoryTree {
+	tree := &CategoryTree{
+		names:    map[string]string{},
+		parents:  map[string]string{},
+		children: map[string][]string{},
+	}
+	for _, category := range categories {
+		key := categoryKey(category.Name)
+		if key == "" {
+			continue
+		}
+		tree.names[key] = strings.TrimSpace(category.Name)
+		if parent := categoryKey(category.Parent); parent != "" && parent != key {
+			tree.parents[key] = parent
+		}
+	}
+	for _, category := range categories {
+		// A parent that is not stored itself keeps the spelling its children
+		// use.
+		if parent := categoryKey(category.Parent); parent != "" && tree.names[parent] == "" {
+			tree.names[parent] = strings.TrimSpace(category.Parent)
+		}
+	}
+	for key, parent := range tree.parents {
+		tree.children[parent] = append(tree.children[parent], tree.names[key])
+	}
+	for _, children := range tree.children {
+		sort.Slice(children, func(i, j int) bool { return strings.ToLower(children[i]) < strings.ToLower(children[j]) })
+	}
+	return tree
+}
+
+func categoryKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Name returns the stored spelling of a category, or the trimmed name when
+// it is not in the tree.
+func (t *CategoryTree) Name(category string) string {
+	if t != nil {
+		if name, ok := t.names[categoryKey(category)]; ok {
+			return name
+		}
+	}
+	return strings.TrimSpace(category)
+}
+
+// Children returns the direct children of a category by name.
+func (t *CategoryTree) Children(category string) []string {
+	if t == nil {
+		return nil
+	}
+	return t.children[categoryKey(category)]
+}
+
+// Path returns the category and its ancestors, top-level category first.
+func (t *CategoryTree) Path(category string) []string {
+	path := []string{t.Name(category)}
+	if t == nil {
+		return path
+	}
+	seen := map[string]bool{categoryKey(category): true}
+	for key := t.parents[categoryKey(category)]; key != "" && !seen[key]; key = t.parents[key] {
+		seen[key] = true
+		path = append([]string{t.Name(key)}, path...)
+	}
+	return path
+}
+
+// Contains reports whether category is ancestor or one of its descendants.
+func (t *CategoryTree) Contains(ancestor, category string) bool {
+	ancestor = categoryKey(ancestor)
+	for _, name := range t.Path(category) {
+		if categoryKey(name) == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Amount returns the part of a transaction's amount that falls in a
+// category or its descendants, taking split lines into account.
+func (t *CategoryTree) Amount(tx models.Transaction, category string) models.Money {
+	var total models.Money
+	for _, line := range tx.CategoryLines() {
+		if t.Contains(category, line.Category) {
+			total += line.Amount
+		}
+	}
+	return total
+}
+
+type rollupNode struct {
+	item      BreakdownItem
+	children  map[string]*rollupNode
+	lastGroup int
+}
+
+// rollupCategories totals category lines below parent (the top level when
+// empty), nesting up to depth levels of children; depth 0 means every level.
+// Every item includes the spend of its descendants. Spend booked on parent
+// itself is reported as an item named after parent, so the items add up to
+// the parent's total. Counts are of transactions, so a split transaction
+// with two lines under the same category counts once.
+func rollupCategories(groups []models.TransactionAggregate, tree *CategoryTree, parent string, depth int) []BreakdownItem {
+	var parentPath []string
+	if strings.TrimSpace(parent) != "" {
+		parentPath = tree.Path(parent)
+	}
+
+	root := &rollupNode{children: map[string]*rollupNode{}}
+	for i, group := range groups {
+		for _, line := range group.Lines {
+			path := tree.Path(line.Category)
+			if !hasCategoryPrefix(path, parentPath) {
+				continue
+			}
+			relative := path[len(parentPath):]
+			direct := len(relative) == 0
+			if direct {
+				relative = path[len(path)-1:]
+			}
+			if depth > 0 && len(relative) > depth {
+				relative = relative[:depth]
+			}
+
+			node := root
+			for level, name := range relative {
+				child, ok := node.children[categoryKey(name)]
+				if !ok {
+					child = &rollupNode{children: map[string]*rollupNode{}}
+					child.item.Label = name
+					child.item.Path = append(append([]string{}, parentPath...), relative[:level+1]...)
+					if direct {
+						child.item.Path = path
+					} else {
+						child.item.HasChildren = len(tree.Children(name)) > 0
+					}
+					node.children[categoryKey(name)] = child
+				}
+				child.item.Amount += line.Amount
+				if child.lastGroup != i+1 {
+					child.lastGroup = i + 1
+					child.item.Count += group.Count
+				}
+				node = child
+			}
+		}
+	}
+	return root.items()
+}
+
+func (n *rollupNode) items() []BreakdownItem {
+	if len(n.children) == 0 {
+		return nil
+	}
+	items := make([]BreakdownItem, 0, len(n.children))
+	for _, child := range n.children {
+		item := child.item
+		item.Children = child.items()
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Amount != items[j].Amount {
+			return items[i].Amount > items[j].Amount
+		}
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+func hasCategoryPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if categoryKey(path[i]) != categoryKey(prefix[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// CategoryUsage is a category with what refers to it. Managed is false for
+// categories that are in use but missing from the category list, such as a
+// misspelling waiting to be merged.
+type CategoryUsage struct {
+	models.Category
+	Path             []string     `json:"path"`
+	Managed          bool         `json:"managed"`
+	TransactionCount int          `json:"transaction_count"`
+	Amount           models.Money `json:"amount"`
+	RuleCount        int          `json:"rule_count"`
+	MappingCount     int          `json:"mapping_count"`
+	MerchantCount    int          `json:"merchant_count"`
+}
+
+// CategoryChangeResult counts what a rename, merge or delete rewrote.
+type CategoryChangeResult struct {
+	Transactions      int `json:"transactions"`
+	SplitTransactions int `json:"split_transactions"`
+	Rules             int `json:"rules"`
+	Mappings          int `json:"mappings"`
+	Merchants         int `json:"merchants"`
+}
+
+type CategoryService struct {
+	dbClient models.DatabaseClient
+	store    models.CategoryStore
+}
+
+func NewCategoryService(dbClient models.DatabaseClient, store models.CategoryStore) *CategoryService {
+	return &CategoryService{dbClient: dbClient, store: store}
+}
+
+// ListCategories returns the managed categories ordered by their path, so
+// children follow their parent.
+func (s *CategoryService) ListCategories() ([]models.Category, error) {
+	categories, err := s.categories()
+	if err != nil {
+		return nil, err
+	}
+	tree := NewCategoryTree(categories)
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categoryPathKey(tree.Path(categories[i].Name)) < categoryPathKey(tree.Path(categories[j].Name))
+	})
+	return categories, nil
+}
+
+// ListCategoryUsage returns the managed categories, followed in path order
+// by any unmanaged ones still in use, with how many transactions, rules,
+// mappings and merchants use each.
+func (s *CategoryService) ListCategoryUsage() ([]CategoryUsage, error) {
+	categories, err := s.categories()
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.usage()
+	if err != nil {
+		return nil, err
+	}
+
+	tree := NewCategoryTree(categories)
+	list := make([]CategoryUsage, 0, len(categories)+len(usage))
+	for _, category := range categories {
+		var item CategoryUsage
+		if found, ok := usage[categoryKey(category.Name)]; ok {
+			item = *found
+			delete(usage, categoryKey(category.Name))
+		}
+		item.Category = category
+		item.Managed = true
+		item.Path = tree.Path(category.Name)
+		list = append(list, item)
+	}
+	for _, item := range usage {
+		item.Path = []string{item.Name}
+		list = append(list, *item)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Managed != list[j].Managed {
+			return list[i].Managed
+		}
+		return categoryPathKey(list[i].Path) < categoryPathKey(list[j].Path)
+	})
+	return list, nil
+}
+
+// ResolveCategory returns the managed spelling of a category, or an error
+// when it is not in the category list.
+func (s *CategoryService) ResolveCategory(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("category is required")
+	}
+	categories, err := s.categories()
+	if err != nil {
+		return "", err
+	}
+	if category := findCategoryByName(categories, name); category != nil {
+		return category.Name, nil
+	}
+	return "", fmt.Errorf("unknown category %q; add it to the category list first", name)
+}
+
+// SaveCategory validates and creates or replaces a category. Categories
+// without an ID are new; an existing category can move to another parent,
+// but renaming goes through RenameCategory.
+func (s *CategoryService) SaveCategory(category models.Category) (models.Category, error) {
+	existing, err := s.categories()
+	if err != nil {
+		return models.Category{}, err
+	}
+	if err := ValidateCategory(&category, existing); err != nil {
+		return models.Category{}, err
+	}
+
+	now := time.Now().UTC()
+	if category.ID == "" {
+		id, err := newRecordID()
+		if err != nil {
+			return models.Category{}, err
+		}
+		category.ID = id
+		category.CreatedAt = now
+	} else {
+		stored := findCategory(existing, category.ID)
+		if stored == nil {
+			return models.Category{}, fmt.Errorf("category %s not found", category.ID)
+		}
+		if stored.Name != category.Name {
+			return models.Category{}, fmt.Errorf("category %s cannot be renamed by saving it; use rename", stored.Name)
+		}
+		category.CreatedAt = stored.CreatedAt
+	}
+	category.UpdatedAt = now
+
+	if err := s.store.SaveCategory(category); err != nil {
+		return models.Category{}, err
+	}
+	invalidateCategoryTree()
+	return category, nil
+}
+
+// RenameCategory renames a category and rewrites its subcategories,
+// transactions, rules, mappings and merchants to the new name.
+func (s *CategoryService) RenameCategory(id, name, actor, source string) (models.Category, CategoryChangeResult, error) {
+	categories, err := s.categories()
+	if err != nil {
+		return models.Category{}, CategoryChangeResult{}, err
+	}
+	category := findCategory(categories, id)
+	if category == nil {
+		return models.Category{}, CategoryChangeResult{}, fmt.Errorf("category %s not found", id)
+	}
+	if isOtherCategory(category.Name) {
+		return models.Category{}, CategoryChangeResult{}, fmt.Errorf("Other cannot be renamed")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.Category{}, CategoryChangeResult{}, fmt.Errorf("new category name is required")
+	}
+	if name == category.Name {
+		return *category, CategoryChangeResult{}, nil
+	}
+	if other := findCategoryByName(categories, name); other != nil && other.ID != category.ID {
+		return models.Category{}, CategoryChangeResult{}, fmt.Errorf("category %s already exists; merge into it instead", other.Name)
+	}
+
+	oldName := category.Name
+	renamed := *category
+	renamed.Name = name
+	renamed.UpdatedAt = time.Now().UTC()
+	if err := s.store.SaveCategory(renamed); err != nil {
+		return models.Category{}, CategoryChangeResult{}, err
+	}
+	if err := s.moveChildren(categories, oldName, name); err != nil {
+		return renamed, CategoryChangeResult{}, err
+	}
+	result, err := s.reassign(oldName, name, actor, source)
+	log.Printf("category renamed from=%q to=%q result=%+v", oldName, name, result)
+	return renamed, result, err
+}
+
+// MergeCategory folds source into target: everything using source, including
+// an unmanaged spelling such as "Groceries", moves to target, and a managed
+// source is removed with its subcategories moving under target.
+func (s *CategoryService) MergeCategory(source, target, actor, auditSource string) (CategoryChangeResult, error) {
+	categories, err := s.categories()
+	if err != nil {
+		return CategoryChangeResult{}, err
+	}
+	source = strings.TrimSpace(source)
+	into := findCategoryByName(categories, target)
+	if into == nil {
+		return CategoryChangeResult{}, fmt.Errorf("unknown category %q; merge into a category from the list", strings.TrimSpace(target))
+	}
+	if source == "" {
+		return CategoryChangeResult{}, fmt.Errorf("source category is required")
+	}
+	if isOtherCategory(source) {
+		return CategoryChangeResult{}, fmt.Errorf("Other cannot be merged into another category")
+	}
+	if categoryKey(source) == categoryKey(into.Name) {
+		return CategoryChangeResult{}, fmt.Errorf("cannot merge %s into itself", into.Name)
+	}
+
+	if from := findCategoryByName(categories, source); from != nil {
+		if NewCategoryTree(categories).Contains(from.Name, into.Name) {
+			return CategoryChangeResult{}, fmt.Errorf("cannot merge %s into its own subcategory %s", from.Name, into.Name)
+		}
+		if err := s.moveChildren(categories, from.Name, into.Name); err != nil {
+			return CategoryChangeResult{}, err
+		}
+		if err := s.store.DeleteCategory(from.ID); err != nil {
+			return CategoryChangeResult{}, err
+		}
+		source = from.Name
+	}
+	result, err := s.reassign(source, into.Name, actor, auditSource)
+	log.Printf("category merged from=%q into=%q result=%+v", source, into.Name, result)
+	return result, err
+}
+
+// DeleteCategory removes a category and reassigns everything using it to
+// reassignTo, which defaults to the category's parent and then to Other. Its
+// subcategories move up to its parent.
+func (s *CategoryService) DeleteCategory(id, reassignTo, actor, source string) (CategoryChangeResult, error) {
+	if strings.TrimSpace(id) == "" {
+		return CategoryChangeResult{}, fmt.Errorf("category id is required")
+	}
+	categories, err := s.categories()
+	if err != nil {
+		return CategoryChangeResult{}, err
+	}
+	category := findCategory(categories, id)
+	if category == nil {
+		return CategoryChangeResult{}, fmt.Errorf("category %s not found", id)
+	}
+	if isOtherCategory(category.Name) {
+		return CategoryChangeResult{}, fmt.Errorf("Other cannot be deleted")
+	}
+
+	target := strings.TrimSpace(reassignTo)
+	if target == "" {
+		target = category.Parent
+	}
+	if target == "" {
+		target = "Other"
+	}
+	into := findCategoryByName(categories, target)
+	if into == nil && !isOtherCategory(target) {
+		return CategoryChangeResult{}, fmt.Errorf("unknown category %q to reassign to", target)
+	}
+	if into != nil {
+		target = into.Name
+	}
+	if categoryKey(target) == categoryKey(category.Name) {
+		return CategoryChangeResult{}, fmt.Errorf("cannot reassign %s to itself", category.Name)
+	}
+	if NewCategoryTree(categories).Contains(category.Name, target) {
+		return CategoryChangeResult{}, fmt.Errorf("cannot reassign %s to its own subcategory %s", category.Name, target)
+	}
+
+	if err := s.moveChildren(categories, category.Name, category.Parent); err != nil {
+		return CategoryChangeResult{}, err
+	}
+	if err := s.store.DeleteCategory(id); err != nil {
+		return CategoryChangeResult{}, err
+	}
+	result, err := s.reassign(category.Name, target, actor, source)
+	log.Printf("category deleted name=%q reassigned_to=%q result=%+v", category.Name, target, result)
+	return result, err
+}
+
+// moveChildren gives the subcategories of parent a new parent.
+func (s *CategoryService) moveChildren(categories []models.Category, parent, newParent string) error {
+	now := time.Now().UTC()
+	for _, category := range categories {
+		if categoryKey(category.Parent) != categoryKey(parent) || category.Parent == "" {
+			continue
+		}
+		category.Parent = newParent
+		category.UpdatedAt = now
+		if err := s.store.SaveCategory(category); err != nil {
+			return err
+		}
+	}
+	invalidateCategoryTree()
+	return nil
+}
+
+// reassign rewrites every use of the category from to the category to:
+// transaction categories and split lines (audited, in batches), rule
+// categories, vendor mappings and merchant default categories. Trashed rows
+// and reconciled duplicates are rewritten too, so restoring or unlinking one
+// does not bring the old category back.
+func (s *CategoryService) reassign(from, to, actor, source string) (CategoryChangeResult, error) {
+	var result CategoryChangeResult
+	key := categoryKey(from)
+	defer invalidateCategoryTree()
+
+	txs, err := s.dbClient.FetchTransactionsByDateRange(time.Time{}, time.Now())
+	if err != nil {
+		return result, err
+	}
+	var recategorized []models.Transaction
+	for _, tx := range txs {
+		if len(tx.Splits) == 0 {
+			if categoryKey(tx.Category) == key && tx.Category != to {
+				recategorized = append(recategorized, tx)
+			}
+			continue
+		}
+
+		splits := make([]models.Split, len(tx.Splits))
+		copy(splits, tx.Splits)
+		changed := false
+		for i := range splits {
+			if categoryKey(splits[i].Category) == key && splits[i].Category != to {
+				splits[i].Category = to
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		store, ok := s.dbClient.(models.SplitStore)
+		if !ok {
+			return result, fmt.Errorf("split transactions cannot be updated for this database backend")
+		}
+		if err := store.SetTransactionSplits(tx.ID, splits); err != nil {
+			return result, err
+		}
+		after := tx
+		after.Splits = splits
+		after.Version++
+		RecordTransactionChange(s.dbClient, tx, after, actor, source, models.AuditActionSplit)
+		result.SplitTransactions++
+	}
+	if len(recategorized) > 0 {
+		// Written directly rather than through bulk edit, which only selects
+		// live transactions.
+		store, ok := s.dbClient.(models.BulkStore)
+		if !ok {
+			return result, fmt.Errorf("transactions cannot be recategorized for this database backend")
+		}
+		audit, _ := s.dbClient.(models.AuditStore)
+		for start := 0; start < len(recategorized); start += bulkBatchSize {
+			batch := recategorized[start:min(start+bulkBatchSize, len(recategorized))]
+			ids := make([]string, len(batch))
+			for i, tx := range batch {
+				ids[i] = tx.ID
+			}
+			written, err := store.SetTransactionsCategory(ids, to)
+			result.Transactions += written
+			if err != nil {
+				return result, err
+			}
+			if audit == nil {
+				continue
+			}
+			for _, before := range batch {
+				after := before
+				after.Category = to
+				after.Version = before.Version + 1
+				if err := NewAuditService(s.dbClient, audit).Record(before, after, actor, source, models.AuditActionUpdate); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+
+	if rules, ok := s.dbClient.(models.RuleStore); ok {
+		stored, err := loadCategoryRules(rules)
+		if err != nil {
+			return result, err
+		}
+		for _, rule := range stored {
+			if categoryKey(rule.Category) != key {
+				continue
+			}
+			rule.Category = to
+			rule.UpdatedAt = time.Now().UTC()
+			if err := rules.SaveCategoryRule(rule); err != nil {
+				return result, err
+			}
+			result.Rules++
+		}
+		invalidateCategoryRules()
+	}
+
+	mappings, err := s.store.ListCategoryMappings()
+	if err != nil {
+		return result, err
+	}
+	for _, mapping := range mappings {
+		if categoryKey(mapping.Category) != key {
+			continue
+		}
+		mapping.Category = to
+		if err := s.dbClient.SaveCategoryMapping(&mapping); err != nil {
+			return result, err
+		}
+		result.Mappings++
+	}
+
+	if merchants, ok := s.dbClient.(models.MerchantStore); ok {
+		stored, err := merchants.ListMerchants()
+		if err != nil {
+			return result, err
+		}
+		for _, merchant := range stored {
+			if categoryKey(merchant.DefaultCategory) != key {
+				continue
+			}
+			merchant.DefaultCategory = to
+			merchant.UpdatedAt = time.Now().UTC()
+			if err := merchants.SaveMerchant(merchant); err != nil {
+				return result, err
+			}
+			result.Merchants++
+		}
+		invalidateMerchants()
+	}
+	return result, nil
+}
+
+// categories returns the managed categories. An empty list is first seeded
+// with every category already in use, so existing data stays valid.
+func (s *CategoryService) categories() ([]models.Category, error) {
+	categories, err := s.store.ListCategories()
+	if err != nil || len(categories) > 0 {
+		return categories, err
+	}
+
+	usage, err := s.usage()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for _, item := range usage {
+		id, err := newRecordID()
+		if err != nil {
+			return nil, err
+		}
+		category := models.Category{ID: id, Name: item.Name, CreatedAt: now, UpdatedAt: now}
+		if err := s.store.SaveCategory(category); err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	invalidateCategoryTree()
+	log.Printf("categories seeded count=%d", len(categories))
+	return categories, nil
+}
+
+// usage counts the uses of every category, keyed by its lowercase name and
+// spelled as its most recent transaction spells it. Other is always present.
+func (s *CategoryService) usage() (map[string]*CategoryUsage, error) {
+	usage := map[string]*CategoryUsage{}
+	entry := func(name string) *CategoryUsage {
+		key := categoryKey(name)
+		if key == "" {
+			return nil
+		}
+		item, ok := usage[key]
+		if !ok {
+			item = &CategoryUsage{Category: models.Category{Name: strings.TrimSpace(name)}}
+			usage[key] = item
+		}
+		return item
+	}
+
+	txs, err := NewReportingService(s.dbClient).ListTransactionsByDateRange(time.Time{}, time.Now(), TransactionFilter{}, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range txs {
+		counted := map[string]bool{}
+		for _, line := range tx.CategoryLines() {
+			item := entry(line.Category)
+			if item == nil {
+				continue
+			}
+			item.Amount += line.Amount
+			if !counted[categoryKey(line.Category)] {
+				counted[categoryKey(line.Category)] = true
+				item.TransactionCount++
+			}
+		}
+	}
+
+	for _, compiled := range categoryRules(s.dbClient) {
+		if item := entry(compiled.rule.Category); item != nil {
+			item.RuleCount++
+		}
+	}
+
+	mappings, err := s.store.ListCategoryMappings()
+	if err != nil {
+		return nil, err
+	}
+	for _, mapping := range mappings {
+		if item := entry(mapping.Category); item != nil {
+			item.MappingCount++
+		}
+	}
+
+	if merchants, ok := s.dbClient.(models.MerchantStore); ok {
+		stored, err := merchants.ListMerchants()
+		if err != nil {
+			return nil, err
+		}
+		for _, merchant := range stored {
+			if item := entry(merchant.DefaultCategory); item != nil {
+				item.MerchantCount++
+			}
+		}
+	}
+
+	entry("Other")
+	return usage, nil
+}
+
+// ValidateCategory trims a category and checks its name is unique and that
+// its parent is another listed category that does not make the tree loop
+// back on itself.
+func ValidateCategory(category *models.Category, existing []models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	category.Parent = strings.TrimSpace(category.Parent)
+	if category.Name == "" {
+		return fmt.Errorf("category name is required")
+	}
+
+	others := make([]models.Category, 0, len(existing))
+	for _, other := range existing {
+		if other.ID == category.ID {
+			continue
+		}
+		if categoryKey(other.Name) == categoryKey(category.Name) {
+			return fmt.Errorf("category %s already exists", other.Name)
+		}
+		others = append(others, other)
+	}
+
+	if category.Parent == "" {
+		return nil
+	}
+	parent := findCategoryByName(others, category.Parent)
+	if parent == nil {
+		return fmt.Errorf("parent category %q is not in the category list", category.Parent)
+	}
+	category.Parent = parent.Name
+	if NewCategoryTree(others).Contains(category.Name, category.Parent) {
+		return fmt.Errorf("category %s cannot be placed under itself", category.Name)
+	}
+	return nil
+}
+
+func findCategory(categories []models.Category, id string) *models.Category {
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+func findCategoryByName(categories []models.Category, name string) *models.Category {
+	key := categoryKey(name)
+	for i := range categories {
+		if categoryKey(categories[i].Name) == key {
+			return &categories[i]
+		}
+	}
+	return nil
+}
+
+func isOtherCategory(name string) bool {
+	return categoryKey(name) == "other"
+}
+
+func categoryPathKey(path []string) string {
+	return strings.ToLower(strings.Join(path, "/"))
+}
+
+var (
+	categoryTreeMu       sync.Mutex
+	categoryTreeCache    *CategoryTree
+	categoryTreeLoadedAt time.Time
+)
+
+// categoryTree returns the stored category tree, which is flat for backends
+// without a category store. It is cached like the category rules.
+func categoryTree(dbClient models.DatabaseClient) *CategoryTree {
+	store, ok := dbClient.(models.CategoryStore)
+	if !ok {
+		return nil
+	}
+
+	categoryTreeMu.Lock()
+	defer categoryTreeMu.Unlock()
+	if categoryTreeCache != nil && time.Since(categoryTreeLoadedAt) < ruleCacheTTL {
+		return categoryTreeCache
+	}
+	categories, err := store.ListCategories()
+	if err != nil {
+		log.Printf("categories load failed err=%v", err)
+		return nil
+	}
+	categoryTreeCache = NewCategoryTree(categories)
+	categoryTreeLoadedAt = time.Now()
+	return categoryTreeCache
+}
+
+func invalidateCategoryTree() {
+	categoryTreeMu.Lock()
+	categoryTreeCache = nil
+	categoryTreeMu.Unlock()
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func newCategoriesTestDB(categories ...models.Category) *testDB {
+	db := newTestDB()
+	for _, category := range categories {
+		db.categories[category.ID] = category
+	}
+	return db
+}
+
+func foodAndBillsTree() []models.Category {
+	return []models.Category{
+		{ID: "food", Name: "Food"},
+		{ID: "bills", Name: "Bills"},
+		{ID: "dining", Name: "Dining Out", Parent: "Food"},
+		{ID: "delivery", Name: "Delivery", Parent: "Food"},
+		{ID: "canteen", Name: "Office Canteen", Parent: "Dining Out"},
+		{ID: "electricity", Name: "Electricity", Parent: "Bills"},
+	}
+}
+
+func categoryRollupTransactions() []models.Transaction {
+	now := time.Now().UTC()
+	return []models.Transaction{
+		{ID: "1", Category: "Dining Out", Amount: models.FromRupees(500), DateTime: now},
+		{ID: "2", Category: "delivery", Amount: models.FromRupees(300), DateTime: now},
+		{ID: "3", Category: "Food", Amount: models.FromRupees(100), DateTime: now},
+		{ID: "4", Category: "Office Canteen", Amount: models.FromRupees(50), DateTime: now},
+		{ID: "5", Category: "Electricity", Amount: models.FromRupees(1000), DateTime: now},
+		{ID: "6", Category: "Shopping", Amount: models.FromRupees(200), DateTime: now},
+	}
+}
+
+func breakdownTotals(items []BreakdownItem) map[string]models.Money {
+	totals := map[string]models.Money{}
+	for _, item := range items {
+		totals[item.Label] = item.Amount
+	}
+	return totals
+}
+
+func TestGetCategoryBreakdownRollsUpSubcategories(t *testing.T) {
+	db := newCategoriesTestDB(foodAndBillsTree()...)
+	defer invalidateCategoryTree()
+	db.transactions = categoryRollupTransactions()
+	reporting := NewReportingService(db)
+
+	items, err := reporting.GetCategoryBreakdown("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetCategoryBreakdown returned error: %v", err)
+	}
+	totals := breakdownTotals(items)
+	if len(items) != 3 || totals["Bills"] != models.FromRupees(1000) || totals["Food"] != models.FromRupees(950) || totals["Shopping"] != models.FromRupees(200) {
+		t.Fatalf("unexpected top-level rollup %+v", items)
+	}
+	if items[1].Label != "Food" || items[1].Count != 4 || !items[1].HasChildren || len(items[1].Children) != 0 {
+		t.Fatalf("expected Food with four transactions and no nested children at depth 1, got %+v", items[1])
+	}
+
+	food, err := reporting.GetCategoryRollup("THIS_MONTH", "food", 1)
+	if err != nil {
+		t.Fatalf("GetCategoryRollup returned error: %v", err)
+	}
+	totals = breakdownTotals(food)
+	if len(food) != 3 || totals["Dining Out"] != models.FromRupees(550) || totals["Delivery"] != models.FromRupees(300) || totals["Food"] != models.FromRupees(100) {
+		t.Fatalf("unexpected Food drill-down %+v", food)
+	}
+	if food[0].Label != "Dining Out" || !food[0].HasChildren || len(food[0].Path) != 2 || food[0].Path[0] != "Food" {
+		t.Fatalf("expected Dining Out to be drillable under Food, got %+v", food[0])
+	}
+
+	all, err := reporting.GetCategoryRollup("THIS_MONTH", "", 0)
+	if err != nil {
+		t.Fatalf("GetCategoryRollup returned error: %v", err)
+	}
+	dining := all[1].Children[0]
+	if dining.Label != "Dining Out" || len(dining.Children) != 1 || dining.Children[0].Label != "Office Canteen" || dining.Children[0].Amount != models.FromRupees(50) {
+		t.Fatalf("expected every level nested at depth 0, got %+v", all[1])
+	}
+}
+
+func TestCategoryFilterIncludesSubcategories(t *testing.T) {
+	db := newCategoriesTestDB(foodAndBillsTree()...)
+	defer invalidateCategoryTree()
+	db.transactions = categoryRollupTransactions()
+	reporting := NewReportingService(db)
+
+	txs, err := reporting.ListTransactions("THIS_MONTH", TransactionFilter{Category: "Dining Out"}, 0)
+	if err != nil {
+		t.Fatalf("ListTransactions returned error: %v", err)
+	}
+	if len(txs) != 2 {
+		t.Fatalf("expected Dining Out and Office Canteen transactions, got %d", len(txs))
+	}
+	if amount := reporting.CategoryTree().Amount(db.transactions[3], "food"); amount != models.FromRupees(50) {
+		t.Fatalf("expected a canteen transaction to count towards Food, got %s", amount)
+	}
+}
+
+func TestCategoryServiceValidatesTree(t *testing.T) {
+	db := newCategoriesTestDB(foodAndBillsTree()...)
+	defer invalidateCategoryTree()
+	service := NewCategoryService(db, db)
+
+	if _, err := service.SaveCategory(models.Category{Name: "dining out"}); err == nil {
+		t.Fatalf("expected a duplicate name error")
+	}
+	if _, err := service.SaveCategory(models.Category{ID: "food", Name: "Food", Parent: "Office Canteen"}); err == nil {
+		t.Fatalf("expected an error moving Food under its own subcategory")
+	}
+	if _, err := service.SaveCategory(models.Category{ID: "food", Name: "Groceries"}); err == nil {
+		t.Fatalf("expected an error renaming through save")
+	}
+	if _, err := service.SaveCategory(models.Category{Name: "Broadband", Parent: "Utilities"}); err == nil {
+		t.Fatalf("expected an error for a parent missing from the list")
+	}
+
+	saved, err := service.SaveCategory(models.Category{Name: " Internet ", Parent: "bills"})
+	if err != nil {
+		t.Fatalf("SaveCategory returned error: %v", err)
+	}
+	if saved.ID == "" || saved.Name != "Internet" || saved.Parent != "Bills" {
+		t.Fatalf("unexpected saved category %+v", saved)
+	}
+	if got := NewReportingService(db).CategoryTree().Path("internet"); len(got) != 2 || got[0] != "Bills" {
+		t.Fatalf("expected the saved category to be in the cached tree, got %v", got)
+	}
+}
+
+func TestCategoryServiceSeedsListFromCategoriesInUse(t *testing.T) {
+	db := newCategoriesTestDB()
+	defer invalidateCategoryTree()
+	now := time.Now().UTC()
+	db.transactions = []models.Transaction{
+		{ID: "1", Category: "Grocery", Amount: models.FromRupees(100), DateTime: now},
+		{ID: "2", Category: "grocery", Amount: models.FromRupees(100), DateTime: now.Add(-time.Hour)},
+		{ID: "3", Category: "Groceries", Amount: models.FromRupees(100), DateTime: now},
+	}
+	db.rules["r1"] = models.CategoryRule{ID: "r1", Category: "Fuel", VendorPattern: "hpcl", Priority: 100}
+
+	categories, err := NewCategoryService(db, db).ListCategories()
+	if err != nil {
+		t.Fatalf("ListCategories returned error: %v", err)
+	}
+	var names []string
+	for _, category := range categories {
+		names = append(names, category.Name)
+	}
+	if fmt.Sprint(names) != "[Fuel Groceries Grocery Other]" || len(db.categories) != 4 {
+		t.Fatalf("expected the categories in use to be seeded, got %v", names)
+	}
+}
+
+func TestCategoryServiceMergesRenamesAndDeletes(t *testing.T) {
+	db := newCategoriesTestDB(
+		models.Category{ID: "food", Name: "Food"},
+		models.Category{ID: "dining", Name: "Dining Out", Parent: "Food"},
+		models.Category{ID: "grocery", Name: "Grocery"},
+		models.Category{ID: "other", Name: "Other"},
+	)
+	defer invalidateCategoryTree()
+	defer invalidateCategoryRules()
+	now := time.Now().UTC().Add(-time.Hour)
+	db.transactions = []models.Transaction{
+		{ID: "1", Vendor: "BIGBASKET", Category: "Groceries", Amount: models.FromRupees(500), DateTime: now},
+		{ID: "2", Vendor: "BIGBASKET", Category: "groceries", Amount: models.FromRupees(300), DateTime: now},
+		{ID: "3", Vendor: "DMART", Category: "Grocery", Amount: models.FromRupees(200), DateTime: now},
+		{ID: "4", Vendor: "TRUFFLES", Category: "Dining Out", Amount: models.FromRupees(900), DateTime: now},
+		{ID: "5", Vendor: "RELIANCE", Category: "Shopping", Amount: models.FromRupees(1000), DateTime: now,
+			Splits: []models.Split{{Amount: models.FromRupees(600), Category: "Groceries"}, {Amount: models.FromRupees(400), Category: "Food"}}},
+		{ID: "6", Vendor: "BIGBASKET", Category: "Groceries", Amount: models.FromRupees(100), DateTime: now, DeletedAt: &now},
+	}
+	db.mappings["bigbasket"] = models.CategoryMapping{Vendor: "bigbasket", Category: "Groceries", Source: models.MappingSourceManual}
+	db.rules["r1"] = models.CategoryRule{ID: "r1", Category: "Groceries", VendorPattern: "bigbasket", Priority: 100}
+	service := NewCategoryService(db, db)
+
+	usage, err := service.ListCategoryUsage()
+	if err != nil {
+		t.Fatalf("ListCategoryUsage returned error: %v", err)
+	}
+	last := usage[len(usage)-1]
+	if last.Managed || last.Name != "Groceries" || last.TransactionCount != 3 || last.RuleCount != 1 || last.MappingCount != 1 {
+		t.Fatalf("expected Groceries to be listed as unmanaged with its uses, got %+v", last)
+	}
+
+	if _, err := service.ResolveCategory("groceries"); err == nil {
+		t.Fatalf("expected an unmanaged category to be rejected")
+	}
+	if got, err := service.ResolveCategory(" GROCERY "); err != nil || got != "Grocery" {
+		t.Fatalf("expected the managed spelling, got %q err=%v", got, err)
+	}
+
+	result, err := service.MergeCategory("Groceries", "grocery", "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("MergeCategory returned error: %v", err)
+	}
+	if result != (CategoryChangeResult{Transactions: 3, SplitTransactions: 1, Rules: 1, Mappings: 1}) {
+		t.Fatalf("unexpected merge result %+v", result)
+	}
+	if db.transactions[0].Category != "Grocery" || db.transactions[1].Category != "Grocery" || db.transactions[4].Splits[0].Category != "Grocery" {
+		t.Fatalf("expected transactions and split lines to move to Grocery")
+	}
+	if db.transactions[5].Category != "Grocery" {
+		t.Fatalf("expected the trashed transaction to move to Grocery too, so restoring it does not bring Groceries back")
+	}
+	if db.rules["r1"].Category != "Grocery" || db.mappings["bigbasket"].Category != "Grocery" {
+		t.Fatalf("expected the rule and mapping to move to Grocery")
+	}
+
+	if _, _, err := service.RenameCategory("food", "grocery", "", models.AuditSourceUI); err == nil {
+		t.Fatalf("expected renaming onto an existing category to fail")
+	}
+	renamed, result, err := service.RenameCategory("food", "Eating", "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("RenameCategory returned error: %v", err)
+	}
+	if renamed.Name != "Eating" || db.categories["dining"].Parent != "Eating" || result.SplitTransactions != 1 || db.transactions[4].Splits[1].Category != "Eating" {
+		t.Fatalf("expected Food to be renamed with its subcategory and split line, got %+v %+v", renamed, result)
+	}
+
+	if _, err := service.DeleteCategory("other", "", "", models.AuditSourceUI); err == nil {
+		t.Fatalf("expected Other to be protected")
+	}
+	result, err = service.DeleteCategory("dining", "", "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("DeleteCategory returned error: %v", err)
+	}
+	if result.Transactions != 1 || db.transactions[3].Category != "Eating" {
+		t.Fatalf("expected Dining Out transactions to move to the parent, got %+v", result)
+	}
+	if _, ok := db.categories["dining"]; ok {
+		t.Fatalf("expected Dining Out to be deleted")
+	}
+}
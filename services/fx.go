@@ -0,0 +1,195 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+type FXService struct {
+	dbClient models.DatabaseClient
+	store    models.FXRateStore
+}
+
+type FXImportSummary struct {
+	Imported    int      `json:"imported"`
+	Skipped     int      `json:"skipped"`
+	Currencies  []string `json:"currencies"`
+	Reconverted int      `json:"reconverted"`
+}
+
+func NewFXService(dbClient models.DatabaseClient, store models.FXRateStore) *FXService {
+	return &FXService{dbClient: dbClient, store: store}
+}
+
+// ImportCSV loads rates from rows of date,currency,rate where rate is the
+// number of rupees per unit, e.g. "2026-04-01,USD,83.42". A header row is
+// optional. Foreign transactions still waiting for a rate on or after the
+// earliest imported date are converted afterwards.
+func (s *FXService) ImportCSV(r io.Reader) (FXImportSummary, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	summary := FXImportSummary{}
+	var rates []models.FXRate
+	currencies := make(map[string]bool)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return summary, fmt.Errorf("invalid CSV at line %d: %w", line, err)
+		}
+
+		rate, err := parseFXRecord(record)
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			log.Printf("fx import skipped line=%d err=%v", line, err)
+			summary.Skipped++
+			continue
+		}
+
+		rates = append(rates, rate)
+		currencies[rate.Currency] = true
+	}
+
+	if err := s.store.SaveFXRates(rates); err != nil {
+		return summary, err
+	}
+	summary.Imported = len(rates)
+	for currency := range currencies {
+		summary.Currencies = append(summary.Currencies, currency)
+	}
+	sort.Strings(summary.Currencies)
+
+	if len(rates) == 0 {
+		return summary, nil
+	}
+
+	earliest := rates[0].Date
+	for _, rate := range rates {
+		if rate.Date.Before(earliest) {
+			earliest = rate.Date
+		}
+	}
+	reconverted, err := s.ConvertPending(earliest, time.Now().UTC())
+	summary.Reconverted = reconverted
+	if err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// ConvertPending converts foreign-currency transactions in the range that were
+// saved before a rate for their date was available.
+func (s *FXService) ConvertPending(from, to time.Time) (int, error) {
+	txs, err := s.dbClient.FetchTransactionsByDateRange(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	converted := 0
+	for _, tx := range txs {
+		if !tx.NeedsConversion() {
+			continue
+		}
+		amount, rate, err := convertToBase(s.store, tx.OriginalAmount, tx.Currency, tx.DateTime)
+		if err != nil {
+			log.Printf("fx conversion still pending id=%s currency=%s err=%v", tx.ID, tx.Currency, err)
+			continue
+		}
+		if err := s.store.SetConvertedAmount(tx.ID, amount, rate); err != nil {
+			return converted, err
+		}
+		converted++
+	}
+	return converted, nil
+}
+
+func (s *FXService) ListRates(currency string) ([]models.FXRate, error) {
+	return s.store.ListFXRates(strings.ToUpper(strings.TrimSpace(currency)))
+}
+
+func parseFXRecord(record []string) (models.FXRate, error) {
+	if len(record) < 3 {
+		return models.FXRate{}, fmt.Errorf("expected date,currency,rate, got %d fields", len(record))
+	}
+
+	// A rate applies from the start of its day in the user's time zone, the
+	// same day the transactions it converts are bucketed into.
+	date, err := utils.ParseDate(strings.TrimSpace(record[0]))
+	if err != nil {
+		return models.FXRate{}, fmt.Errorf("invalid date %q", record[0])
+	}
+
+	currency := models.NormalizeCurrency(record[1])
+	if len(currency) != 3 || currency == models.BaseCurrency {
+		return models.FXRate{}, fmt.Errorf("invalid currency %q", record[1])
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+	if err != nil || rate <= 0 {
+		return models.FXRate{}, fmt.Errorf("invalid rate %q", record[2])
+	}
+
+	return models.FXRate{Currency: currency, Date: date, Rate: rate}, nil
+}
+
+func convertToBase(store models.FXRateStore, amount models.Money, currency string, on time.Time) (models.Money, float64, error) {
+	if currency == "" || currency == models.BaseCurrency {
+		return amount, 1, nil
+	}
+
+	rate, err := store.GetFXRate(currency, on)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rate == nil {
+		return 0, 0, fmt.Errorf("no %s rate on or before %s", currency, on.Format("2006-01-02"))
+	}
+
+	return models.Money(math.Round(float64(amount) * rate.Rate)), rate.Rate, nil
+}
+
+// SetTransactionAmount records a parsed amount. Rupee amounts are stored as
+// is; foreign amounts keep the original and are converted with the local rate
+// table when the backend has one. Without a rate the transaction is saved with
+// a zero rupee amount and picked up by ConvertPending later; until then the
+// totals leave it out and report it as pending.
+func SetTransactionAmount(tx *models.Transaction, currency string, amount models.Money, dbClient models.DatabaseClient) {
+	currency = models.NormalizeCurrency(currency)
+	if currency == models.BaseCurrency {
+		tx.Amount = amount
+		return
+	}
+
+	tx.Currency = currency
+	tx.OriginalAmount = amount
+
+	store, ok := dbClient.(models.FXRateStore)
+	if !ok {
+		return
+	}
+	converted, rate, err := convertToBase(store, amount, currency, tx.DateTime)
+	if err != nil {
+		log.Printf("fx conversion pending currency=%s amount=%s err=%v", currency, amount, err)
+		return
+	}
+	tx.Amount = converted
+	tx.FXRate = rate
+}
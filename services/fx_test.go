@@ -0,0 +1,100 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+func TestParseCreditCardTransaction_ForeignCurrencyIsConverted(t *testing.T) {
+	db := newTestDB()
+	db.rates = []models.FXRate{
+		{Currency: "USD", Date: time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC), Rate: 83.5},
+		{Currency: "USD", Date: time.Date(2026, 4, 15, 0, 0, 0, 0, time.UTC), Rate: 84},
+	}
+	text := "USD 12.99 has been debited from your HDFC Bank Credit Card ending 4207 towards NETFLIX.COM on 10 Apr, 2026 at 10:20:18."
+
+	tx := ParseCreditCardTransaction(text, time.Date(2026, 4, 10, 10, 20, 18, 0, time.UTC), db)
+	if tx == nil {
+		t.Fatalf("expected transaction to be parsed")
+	}
+
+	if tx.Currency != "USD" || tx.OriginalAmount != models.FromRupees(12.99) {
+		t.Fatalf("unexpected original amount %s %s", tx.Currency, tx.OriginalAmount)
+	}
+	// 1299 cents * 83.5 = 108466.5 paise, rounded to 108467
+	if tx.Amount != 108467 {
+		t.Fatalf("expected converted amount 1084.67, got %s", tx.Amount)
+	}
+	if tx.FXRate != 83.5 {
+		t.Fatalf("expected rate 83.5, got %v", tx.FXRate)
+	}
+}
+
+func TestParseICICICreditCardTransaction_ForeignCurrencyWithoutRate(t *testing.T) {
+	text := "Your ICICI Bank Credit Card XX3013 has been used for a transaction of EUR 25.00 on Apr 12, 2026 at 09:10:11. Info: BOOKING.COM."
+
+	tx := ParseICICICreditCardTransaction(text, time.Now(), newTestDB())
+	if tx == nil {
+		t.Fatalf("expected transaction to be parsed")
+	}
+	if tx.Currency != "EUR" || tx.OriginalAmount != models.FromRupees(25) {
+		t.Fatalf("unexpected original amount %s %s", tx.Currency, tx.OriginalAmount)
+	}
+	if tx.Amount != 0 || !tx.NeedsConversion() {
+		t.Fatalf("expected conversion to be pending, got amount %s", tx.Amount)
+	}
+}
+
+func TestImportCSVConvertsPendingTransactions(t *testing.T) {
+	db := newTestDB(models.Transaction{
+		ID:             "tx-1",
+		Currency:       "EUR",
+		OriginalAmount: models.FromRupees(25),
+		DateTime:       time.Date(2026, 4, 12, 9, 10, 11, 0, time.UTC),
+	})
+
+	csv := "date,currency,rate\n2026-04-01,EUR,90.10\n2026-04-01,usd,83.50\nnot-a-date,USD,1\n"
+	summary, err := NewFXService(db, db).ImportCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ImportCSV returned error: %v", err)
+	}
+
+	if summary.Imported != 2 || summary.Skipped != 1 {
+		t.Fatalf("expected 2 imported and 1 skipped, got %+v", summary)
+	}
+	if summary.Reconverted != 1 {
+		t.Fatalf("expected 1 reconverted transaction, got %d", summary.Reconverted)
+	}
+	if tx := db.find("tx-1"); tx.Amount != models.FromRupees(2252.50) || tx.FXRate != 90.10 {
+		t.Fatalf("expected converted amount 2252.50 at 90.10, got %s at %v", tx.Amount, tx.FXRate)
+	}
+}
+
+func TestImportCSVRateAppliesFromTheUsersMidnight(t *testing.T) {
+	// Half past midnight in the user's zone is still the previous day in UTC.
+	day, err := utils.ParseDate("2026-04-01")
+	if err != nil {
+		t.Fatalf("ParseDate returned error: %v", err)
+	}
+	db := newTestDB(models.Transaction{
+		ID:             "tx-1",
+		Currency:       "USD",
+		OriginalAmount: models.FromRupees(10),
+		DateTime:       day.Add(30 * time.Minute),
+	})
+
+	summary, err := NewFXService(db, db).ImportCSV(strings.NewReader("date,currency,rate\n2026-04-01,USD,84\n"))
+	if err != nil {
+		t.Fatalf("ImportCSV returned error: %v", err)
+	}
+	if summary.Reconverted != 1 || db.find("tx-1").Amount != models.FromRupees(840) {
+		t.Fatalf("expected the day's rate to convert the transaction, got %+v amount %s", summary, db.find("tx-1").Amount)
+	}
+	if !db.rates[0].Date.Equal(day) {
+		t.Fatalf("expected the rate to start at the user's midnight, got %s", db.rates[0].Date)
+	}
+}
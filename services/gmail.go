@@ -95,13 +95,13 @@ func ProcessEmails(srv *gmail.Service, user string, dbClient models.DatabaseClie
 			var tx *models.Transaction
 
 			if tx = ParseCreditCardTransaction(cleanBody, receivedAt, dbClient); tx != nil {
-				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%.2f", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
+				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%s", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
 			} else if tx = ParseICICICreditCardTransaction(cleanBody, receivedAt, dbClient); tx != nil {
-				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%.2f", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
+				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%s", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
 			} else if tx = ParseRBLCreditCardTransaction(cleanBody, receivedAt, dbClient); tx != nil {
-				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%.2f", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
+				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%s", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
 			} else if tx = ParseBankTransaction(cleanBody, receivedAt, dbClient); tx != nil {
-				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%.2f", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
+				log.Printf("gmail sync parsed message_id=%s from=%q subject=%q type=%s vendor=%q amount=%s", msg.Id, from, subject, tx.Type, tx.Vendor, tx.Amount)
 			} else {
 				log.Printf("gmail sync unparsed message_id=%s from=%q subject=%q", msg.Id, from, subject)
 				stats.ParseFailures++
@@ -119,12 +119,14 @@ func ProcessEmails(srv *gmail.Service, user string, dbClient models.DatabaseClie
 				continue
 			}
 			stats.TransactionsParsed++
+			tx.SourceKind = models.SourceKindGmail
+			tx.SourceID = msg.Id
 
 			if err := dbClient.SaveTransaction(*tx); err != nil {
-				log.Printf("gmail sync transaction save failed message_id=%s type=%s vendor=%q amount=%.2f err=%v", msg.Id, tx.Type, tx.Vendor, tx.Amount, err)
+				log.Printf("gmail sync transaction save failed message_id=%s type=%s vendor=%q amount=%s err=%v", msg.Id, tx.Type, tx.Vendor, tx.Amount, err)
 				stats.SaveFailures++
 			} else {
-				log.Printf("gmail sync transaction saved message_id=%s type=%s vendor=%q amount=%.2f", msg.Id, tx.Type, tx.Vendor, tx.Amount)
+				log.Printf("gmail sync transaction saved message_id=%s type=%s vendor=%q amount=%s", msg.Id, tx.Type, tx.Vendor, tx.Amount)
 				stats.TransactionsSaved++
 			}
 		}
@@ -5,7 +5,6 @@ import (
 	"html"
 	"io"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
@@ -52,6 +51,12 @@ func ImportGooglePayHTML(r io.Reader, dbClient models.DatabaseClient) (GooglePay
 }
 
 func ImportGooglePayHTMLWithProgress(r io.Reader, dbClient models.DatabaseClient, progress GooglePayImportProgress) (GooglePayImportSummary, error) {
+	return ImportGooglePayHTMLForJob("", r, dbClient, progress)
+}
+
+// ImportGooglePayHTMLForJob imports an activity export and records jobID as
+// the source ID of every imported transaction.
+func ImportGooglePayHTMLForJob(jobID string, r io.Reader, dbClient models.DatabaseClient, progress GooglePayImportProgress) (GooglePayImportSummary, error) {
 	raw, err := io.ReadAll(r)
 	if err != nil {
 		return GooglePayImportSummary{}, fmt.Errorf("failed to read uploaded file: %w", err)
@@ -110,6 +115,8 @@ func ImportGooglePayHTMLWithProgress(r io.Reader, dbClient models.DatabaseClient
 			break
 		}
 
+		tx.SourceKind = models.SourceKindGooglePay
+		tx.SourceID = jobID
 		pending = append(pending, *tx)
 		summary.PendingCount = len(pending)
 
@@ -210,7 +217,7 @@ func parseGooglePayBodyLines(lines []string, dbClient models.DatabaseClient) (*m
 		return nil, fmt.Errorf("amount not found")
 	}
 
-	amount, err := strconv.ParseFloat(strings.ReplaceAll(amountMatch[1], ",", ""), 64)
+	amount, err := models.ParseMoney(amountMatch[1])
 	if err != nil {
 		return nil, fmt.Errorf("invalid amount: %w", err)
 	}
@@ -230,20 +237,24 @@ func parseGooglePayBodyLines(lines []string, dbClient models.DatabaseClient) (*m
 		Amount:         amount,
 		DateTime:       dateTime,
 		DebitedAccount: account,
+		RawText:        description + "\n" + timestampLine,
 	}
 
 	switch {
 	case strings.HasPrefix(description, "Paid "):
+		tx.ParserRule = "google_pay_paid"
 		tx.Vendor = betweenGooglePayTokens(description, " to ", " using Bank Account ")
 		if tx.Vendor == "" {
 			tx.Vendor = "Google Pay"
 		}
 	case strings.HasPrefix(description, "Sent "):
+		tx.ParserRule = "google_pay_sent"
 		tx.Vendor = betweenGooglePayTokens(description, " to ", " using Bank Account ")
 		if tx.Vendor == "" {
 			tx.Vendor = "Google Pay"
 		}
 	case strings.HasPrefix(description, "Received "):
+		tx.ParserRule = "google_pay_received"
 		tx.Amount = -tx.Amount
 		tx.DebitedAccount = ""
 		tx.CreditedAccount = "Google Pay"
@@ -255,7 +266,7 @@ func parseGooglePayBodyLines(lines []string, dbClient models.DatabaseClient) (*m
 		return nil, fmt.Errorf("unsupported Google Pay transaction description: %s", description)
 	}
 
-	tx.Category = CategorizeTransaction(tx.Vendor, dbClient)
+	EnrichTransaction(tx, dbClient)
 	return tx, nil
 }
 
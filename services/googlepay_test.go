@@ -28,6 +28,10 @@ func (d *googlePayTestDB) SaveTransactions(txns []models.Transaction) error {
 	return nil
 }
 
+func (d *googlePayTestDB) GetTransaction(id string) (*models.Transaction, error) {
+	return nil, nil
+}
+
 func (d *googlePayTestDB) FetchTransactionsByDateRange(from, to time.Time) ([]models.Transaction, error) {
 	return nil, nil
 }
@@ -35,6 +39,9 @@ func (d *googlePayTestDB) FetchTransactionsByDateRange(from, to time.Time) ([]mo
 func (d *googlePayTestDB) UpdateTransaction(id string, txn models.Transaction) error {
 	return nil
 }
+func (d *googlePayTestDB) PatchTransaction(id string, patch models.TransactionPatch, expectedVersion int64) (*models.Transaction, error) {
+	return nil, nil
+}
 
 func (d *googlePayTestDB) GetLatestTransactionTimeByType(txType string) (*time.Time, error) {
 	return d.latest, nil
@@ -179,7 +186,7 @@ func TestImportGooglePayHTMLMarksReceivedTransactionsAsCredits(t *testing.T) {
 		t.Fatalf("expected debited account to be empty, got %q", db.saved[0].DebitedAccount)
 	}
 
-	if db.saved[0].Amount != -80000 {
+	if db.saved[0].Amount != models.FromRupees(-80000) {
 		t.Fatalf("expected amount -80000, got %v", db.saved[0].Amount)
 	}
 
@@ -212,10 +219,10 @@ func TestImportGooglePayHTMLAcceptsISTTimestampFormat(t *testing.T) {
 		t.Fatalf("expected 1 saved transaction, got %d", len(db.saved))
 	}
 
-	// Wall-clock time from Google Pay (8:31:30 AM IST) stored as-is, no conversion.
-	want := time.Date(2026, 4, 19, 8, 31, 30, 0, time.UTC)
+	// 8:31:30 AM IST is stored as that instant, i.e. 03:01:30 UTC.
+	want := time.Date(2026, 4, 19, 3, 1, 30, 0, time.UTC)
 	if !db.saved[0].DateTime.Equal(want) {
-		t.Fatalf("expected wall-clock timestamp %s, got %s", want.Format(time.RFC3339), db.saved[0].DateTime.Format(time.RFC3339))
+		t.Fatalf("expected timestamp %s, got %s", want.Format(time.RFC3339), db.saved[0].DateTime.UTC().Format(time.RFC3339))
 	}
 }
 
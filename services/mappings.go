@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// CategoryMappingService learns vendor-to-category mappings from the
+// category corrections users make.
+type CategoryMappingService struct {
+	dbClient models.DatabaseClient
+}
+
+func NewCategoryMappingService(dbClient models.DatabaseClient) *CategoryMappingService {
+	return &CategoryMappingService{dbClient: dbClient}
+}
+
+// LearnCorrection saves a manual mapping from vendor to category, so later
+// transactions from the same vendor are categorized the same way. Manual
+// mappings take precedence over categorization rules.
+func (s *CategoryMappingService) LearnCorrection(vendor, category string) (*models.CategoryMapping, error) {
+	vendor = strings.ToLower(strings.TrimSpace(vendor))
+	category = strings.TrimSpace(category)
+	if vendor == "" {
+		return nil, fmt.Errorf("vendor is required")
+	}
+	if category == "" {
+		return nil, fmt.Errorf("category is required")
+	}
+
+	mapping := &models.CategoryMapping{
+		Vendor:   vendor,
+		Category: category,
+		Source:   models.MappingSourceManual,
+		Created:  time.Now().UTC(),
+	}
+	if err := s.dbClient.SaveCategoryMapping(mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// ApplyToPast recategorizes the existing transactions from vendor (compared
+// case-insensitively) to category through a bulk edit, so each change lands
+// in the change log. Split transactions are skipped.
+func (s *CategoryMappingService) ApplyToPast(vendor, category, actor, source string, dryRun bool) (BulkEditResult, error) {
+	store, ok := s.dbClient.(models.BulkStore)
+	if !ok {
+		return BulkEditResult{DryRun: dryRun}, fmt.Errorf("bulk edit not supported for this database backend")
+	}
+	vendor = strings.TrimSpace(vendor)
+	if vendor == "" {
+		return BulkEditResult{DryRun: dryRun}, fmt.Errorf("vendor is required")
+	}
+
+	txs, err := NewReportingService(s.dbClient).ListTransactionsByDateRange(time.Time{}, time.Now(), TransactionFilter{}, 0)
+	if err != nil {
+		return BulkEditResult{DryRun: dryRun}, err
+	}
+	var ids []string
+	for _, tx := range txs {
+		if strings.EqualFold(strings.TrimSpace(tx.Vendor), vendor) && tx.Category != category {
+			ids = append(ids, tx.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return BulkEditResult{DryRun: dryRun}, nil
+	}
+
+	return NewBulkEditService(s.dbClient, store).Apply(BulkEditRequest{
+		IDs:      ids,
+		Action:   BulkActionCategory,
+		Category: category,
+		DryRun:   dryRun,
+	}, actor, source)
+}
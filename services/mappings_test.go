@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestLearnCorrectionOverridesRules(t *testing.T) {
+	db := newTestDB()
+	tx := models.Transaction{Vendor: "SWIGGY"}
+	if got := CategorizeTransaction(tx, db); got != "Food" {
+		t.Fatalf("expected the default rule to give Food, got %q", got)
+	}
+
+	mapping, err := NewCategoryMappingService(db).LearnCorrection(" SWIGGY ", "Grocery")
+	if err != nil {
+		t.Fatalf("LearnCorrection returned error: %v", err)
+	}
+	if mapping.Vendor != "swiggy" || mapping.Source != models.MappingSourceManual {
+		t.Fatalf("unexpected mapping %+v", mapping)
+	}
+	if got := CategorizeTransaction(tx, db); got != "Grocery" {
+		t.Fatalf("expected the manual mapping to win, got %q", got)
+	}
+
+	db.mappings["swiggy"] = models.CategoryMapping{Vendor: "swiggy", Category: "Travel", Source: models.MappingSourceAI}
+	if got := CategorizeTransaction(tx, db); got != "Food" {
+		t.Fatalf("expected rules to beat a non-manual mapping, got %q", got)
+	}
+}
+
+func TestApplyToPastRecategorizesSameVendorOnly(t *testing.T) {
+	now := time.Now().UTC().Add(-time.Hour)
+	db := newTestDB(
+		models.Transaction{ID: "a", Vendor: "Swiggy", Category: "Food", Amount: models.FromRupees(100), DateTime: now.AddDate(0, -3, 0)},
+		models.Transaction{ID: "b", Vendor: "SWIGGY ", Category: "Food", Amount: models.FromRupees(200), DateTime: now},
+		models.Transaction{ID: "c", Vendor: "SWIGGY INSTAMART", Category: "Food", Amount: models.FromRupees(300), DateTime: now},
+		models.Transaction{ID: "d", Vendor: "SWIGGY", Category: "Grocery", Amount: models.FromRupees(400), DateTime: now},
+	)
+
+	service := NewCategoryMappingService(db)
+	preview, err := service.ApplyToPast("swiggy", "Grocery", "me@example.com", models.AuditSourceUI, true)
+	if err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+	if preview.Changed != 2 || len(db.batches) != 0 {
+		t.Fatalf("unexpected dry run %+v", preview)
+	}
+
+	result, err := service.ApplyToPast("swiggy", "Grocery", "me@example.com", models.AuditSourceUI, false)
+	if err != nil {
+		t.Fatalf("ApplyToPast returned error: %v", err)
+	}
+	if result.Changed != 2 {
+		t.Fatalf("expected 2 changed, got %+v", result)
+	}
+	if db.transactions[0].Category != "Grocery" || db.transactions[1].Category != "Grocery" || db.transactions[2].Category != "Food" {
+		t.Fatalf("unexpected categories %+v", db.transactions)
+	}
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// paymentGatewayPrefix matches the gateway names card alerts put in front of
+// the merchant, as in "RAZORPAY*MYNTRA" or "PAYU ZOMATO".
+var paymentGatewayPrefix = regexp.MustCompile(`(?i)^\s*(razorpay|payu|ccavenue)\b[\s*\-_/.:]*`)
+
+// StripPaymentGateway removes a leading payment gateway name from a vendor.
+// A vendor that is only the gateway name is returned unchanged.
+func StripPaymentGateway(vendor string) string {
+	stripped := strings.TrimSpace(paymentGatewayPrefix.ReplaceAllString(vendor, ""))
+	if stripped == "" {
+		return strings.TrimSpace(vendor)
+	}
+	return stripped
+}
+
+type MerchantService struct {
+	dbClient models.DatabaseClient
+	store    models.MerchantStore
+}
+
+func NewMerchantService(dbClient models.DatabaseClient, store models.MerchantStore) *MerchantService {
+	return &MerchantService{dbClient: dbClient, store: store}
+}
+
+func (s *MerchantService) ListMerchants() ([]models.Merchant, error) {
+	merchants, err := s.store.ListMerchants()
+	if err != nil {
+		return nil, err
+	}
+	sortMerchants(merchants)
+	return merchants, nil
+}
+
+// SaveMerchant validates and creates or replaces a merchant. Merchants
+// without an ID are new.
+func (s *MerchantService) SaveMerchant(merchant models.Merchant) (models.Merchant, error) {
+	existing, err := s.store.ListMerchants()
+	if err != nil {
+		return models.Merchant{}, err
+	}
+	if err := ValidateMerchant(&merchant, existing); err != nil {
+		return models.Merchant{}, err
+	}
+
+	now := time.Now().UTC()
+	if merchant.ID == "" {
+		id, err := newRecordID()
+		if err != nil {
+			return models.Merchant{}, err
+		}
+		merchant.ID = id
+		merchant.CreatedAt = now
+	} else {
+		found := false
+		for _, stored := range existing {
+			if stored.ID == merchant.ID {
+				merchant.CreatedAt = stored.CreatedAt
+				found = true
+				break
+			}
+		}
+		if !found {
+			return models.Merchant{}, fmt.Errorf("merchant %s not found", merchant.ID)
+		}
+	}
+	merchant.UpdatedAt = now
+
+	if err := s.store.SaveMerchant(merchant); err != nil {
+		return models.Merchant{}, err
+	}
+	invalidateMerchants()
+	return merchant, nil
+}
+
+func (s *MerchantService) DeleteMerchant(id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("merchant id is required")
+	}
+	if err := s.store.DeleteMerchant(id); err != nil {
+		return err
+	}
+	invalidateMerchants()
+	return nil
+}
+
+// Relink recomputes the canonical merchant of every live transaction and
+// writes the ones that changed, in batches. Run it after editing the
+// registry so stored merchants follow the new aliases and patterns. The
+// merchant is derived from the vendor, so the write is not a new version and
+// is not recorded in the change log; trashed and linked duplicate
+// transactions are left as they are.
+func (s *MerchantService) Relink() (int, error) {
+	txs, err := s.dbClient.FetchTransactionsByDateRange(time.Time{}, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	byMerchant := map[string][]string{}
+	for _, tx := range txs {
+		if tx.IsTrashed() || tx.IsDuplicate() {
+			continue
+		}
+		merchant := CanonicalMerchant(tx.Vendor, s.dbClient)
+		if merchant != tx.Merchant {
+			byMerchant[merchant] = append(byMerchant[merchant], tx.ID)
+		}
+	}
+
+	names := make([]string, 0, len(byMerchant))
+	for name := range byMerchant {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	relinked := 0
+	for _, name := range names {
+		ids := byMerchant[name]
+		for start := 0; start < len(ids); start += bulkBatchSize {
+			end := start + bulkBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			written, err := s.store.SetTransactionsMerchant(ids[start:end], name)
+			relinked += written
+			if err != nil {
+				return relinked, err
+			}
+		}
+	}
+	log.Printf("merchants relinked transactions=%d merchants=%d", relinked, len(names))
+	return relinked, nil
+}
+
+// ValidateMerchant trims a merchant, drops duplicate aliases and checks its
+// patterns compile and that no alias already belongs to another merchant.
+func ValidateMerchant(merchant *models.Merchant, existing []models.Merchant) error {
+	merchant.Name = strings.TrimSpace(merchant.Name)
+	merchant.DefaultCategory = strings.TrimSpace(merchant.DefaultCategory)
+	if merchant.Name == "" {
+		return fmt.Errorf("merchant name is required")
+	}
+
+	seen := map[string]bool{normalizeVendorWords(merchant.Name): true}
+	var aliases []string
+	for _, alias := range merchant.Aliases {
+		alias = strings.TrimSpace(alias)
+		key := normalizeVendorWords(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+	merchant.Aliases = aliases
+
+	var patterns []string
+	for _, pattern := range merchant.Patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile("(?i)" + pattern); err != nil {
+			return fmt.Errorf("invalid merchant pattern %q: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	merchant.Patterns = patterns
+
+	for _, other := range existing {
+		if other.ID == merchant.ID {
+			continue
+		}
+		for _, name := range append([]string{other.Name}, other.Aliases...) {
+			if seen[normalizeVendorWords(name)] {
+				return fmt.Errorf("%q already belongs to merchant %s", name, other.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// CanonicalMerchant returns the registry name for a raw vendor, or the
+// vendor without its payment gateway prefix when no merchant matches.
+func CanonicalMerchant(vendor string, dbClient models.DatabaseClient) string {
+	if merchant, ok := merchantRegistry(dbClient).resolve(vendor); ok {
+		return merchant.Name
+	}
+	return StripPaymentGateway(vendor)
+}
+
+type compiledMerchant struct {
+	merchant models.Merchant
+	patterns []*regexp.Regexp
+}
+
+// merchantSet resolves vendors: exact name and alias matches by their
+// normalized words first, then patterns in merchant name order.
+type merchantSet struct {
+	byAlias  map[string]models.Merchant
+	patterns []compiledMerchant
+}
+
+func compileMerchants(merchants []models.Merchant) merchantSet {
+	sortMerchants(merchants)
+	set := merchantSet{byAlias: map[string]models.Merchant{}}
+	for _, merchant := range merchants {
+		for _, name := range append([]string{merchant.Name}, merchant.Aliases...) {
+			if key := normalizeVendorWords(name); key != "" {
+				if _, taken := set.byAlias[key]; !taken {
+					set.byAlias[key] = merchant
+				}
+			}
+		}
+		compiled := compiledMerchant{merchant: merchant}
+		for _, pattern := range merchant.Patterns {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				log.Printf("merchant pattern skipped merchant=%s pattern=%q err=%v", merchant.Name, pattern, err)
+				continue
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+		if len(compiled.patterns) > 0 {
+			set.patterns = append(set.patterns, compiled)
+		}
+	}
+	return set
+}
+
+func (set merchantSet) resolve(vendor string) (models.Merchant, bool) {
+	stripped := StripPaymentGateway(vendor)
+	if merchant, ok := set.byAlias[normalizeVendorWords(stripped)]; ok {
+		return merchant, true
+	}
+	if merchant, ok := set.byAlias[normalizeVendorWords(vendor)]; ok {
+		return merchant, true
+	}
+	for _, compiled := range set.patterns {
+		for _, re := range compiled.patterns {
+			if re.MatchString(stripped) || re.MatchString(vendor) {
+				return compiled.merchant, true
+			}
+		}
+	}
+	return models.Merchant{}, false
+}
+
+func sortMerchants(merchants []models.Merchant) {
+	sort.SliceStable(merchants, func(i, j int) bool {
+		if !strings.EqualFold(merchants[i].Name, merchants[j].Name) {
+			return strings.ToLower(merchants[i].Name) < strings.ToLower(merchants[j].Name)
+		}
+		return merchants[i].ID < merchants[j].ID
+	})
+}
+
+var (
+	merchantCacheMu       sync.Mutex
+	merchantCache         *merchantSet
+	merchantCacheLoadedAt time.Time
+)
+
+// merchantRegistry returns the compiled registry, which is empty for
+// backends without a merchant store. It is cached like the category rules.
+func merchantRegistry(dbClient models.DatabaseClient) merchantSet {
+	store, ok := dbClient.(models.MerchantStore)
+	if !ok {
+		return merchantSet{}
+	}
+
+	merchantCacheMu.Lock()
+	defer merchantCacheMu.Unlock()
+	if merchantCache != nil && time.Since(merchantCacheLoadedAt) < ruleCacheTTL {
+		return *merchantCache
+	}
+	merchants, err := store.ListMerchants()
+	if err != nil {
+		log.Printf("merchants load failed err=%v", err)
+		return merchantSet{}
+	}
+	set := compileMerchants(merchants)
+	merchantCache = &set
+	merchantCacheLoadedAt = time.Now()
+	return set
+}
+
+func invalidateMerchants() {
+	merchantCacheMu.Lock()
+	merchantCache = nil
+	merchantCacheMu.Unlock()
+}
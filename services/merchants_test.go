@@ -0,0 +1,153 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func newMerchantsTestDB(merchants ...models.Merchant) *testDB {
+	db := newTestDB()
+	for _, merchant := range merchants {
+		db.merchants[merchant.ID] = merchant
+	}
+	return db
+}
+
+var myntra = models.Merchant{
+	ID:              "myntra",
+	Name:            "Myntra",
+	Aliases:         []string{"WWW MYNTRA COM"},
+	Patterns:        []string{`^myntra\b`},
+	DefaultCategory: "Shopping",
+}
+
+func TestStripPaymentGateway(t *testing.T) {
+	cases := map[string]string{
+		"RAZORPAY*MYNTRA":  "MYNTRA",
+		"PayU - Zomato":    "Zomato",
+		"CCAVENUE/IRCTC":   "IRCTC",
+		"RAZORPAY":         "RAZORPAY",
+		"RAZORPAYX PAYOUT": "RAZORPAYX PAYOUT",
+		" Swiggy ":         "Swiggy",
+	}
+	for vendor, want := range cases {
+		if got := StripPaymentGateway(vendor); got != want {
+			t.Errorf("StripPaymentGateway(%q) = %q, want %q", vendor, got, want)
+		}
+	}
+}
+
+func TestCanonicalMerchantResolvesAliasesAndPatterns(t *testing.T) {
+	db := newMerchantsTestDB(myntra)
+	defer invalidateMerchants()
+
+	for _, vendor := range []string{"WWW MYNTRA COM", "www.myntra.com", "MYNTRA DESIGNS", "RAZORPAY*MYNTRA"} {
+		if got := CanonicalMerchant(vendor, db); got != "Myntra" {
+			t.Errorf("CanonicalMerchant(%q) = %q, want Myntra", vendor, got)
+		}
+	}
+	if got := CanonicalMerchant("PAYU*BIGBASKET", db); got != "BIGBASKET" {
+		t.Errorf("expected an unknown vendor without its gateway, got %q", got)
+	}
+}
+
+func TestMerchantDefaultCategoryAppliesWhenNoRuleMatches(t *testing.T) {
+	decathlon := models.Merchant{ID: "decathlon", Name: "Decathlon", Patterns: []string{`decathlon`}, DefaultCategory: "Shopping"}
+	db := newMerchantsTestDB(decathlon)
+	defer invalidateMerchants()
+
+	tx := &models.Transaction{Vendor: "RAZORPAY*DECATHLON SPORTS", Amount: models.FromRupees(1999)}
+	EnrichTransaction(tx, db)
+	if tx.Merchant != "Decathlon" || tx.Category != "Shopping" {
+		t.Fatalf("expected Decathlon/Shopping, got %q/%q", tx.Merchant, tx.Category)
+	}
+
+	explanation := ExplainCategory(models.Transaction{Vendor: "DECATHLON SPORTS INDIA"}, db)
+	if explanation.Source != "merchant" || explanation.Merchant != "Decathlon" {
+		t.Fatalf("expected the merchant default to decide, got %+v", explanation)
+	}
+}
+
+func TestMonthlyComparisonGroupsVendorsByMerchant(t *testing.T) {
+	now := time.Now().UTC()
+	db := newMerchantsTestDB(myntra)
+	defer invalidateMerchants()
+	db.transactions = []models.Transaction{
+		{ID: "1", Vendor: "WWW MYNTRA COM", Category: "Shopping", Amount: models.FromRupees(600), DateTime: now},
+		{ID: "2", Vendor: "MYNTRA DESIGNS", Category: "Shopping", Amount: models.FromRupees(600), DateTime: now},
+		{ID: "3", Vendor: "AMAZON", Category: "Shopping", Amount: models.FromRupees(1000), DateTime: now},
+	}
+
+	comparison, err := NewReportingService(db).GetMonthlyComparison()
+	if err != nil {
+		t.Fatalf("GetMonthlyComparison returned error: %v", err)
+	}
+	if comparison.TopMerchantThisMonth != "Myntra" || comparison.TopMerchantSpend != models.FromRupees(1200) {
+		t.Fatalf("expected Myntra to be the top merchant, got %q %s", comparison.TopMerchantThisMonth, comparison.TopMerchantSpend)
+	}
+}
+
+func TestValidateMerchantRejectsAliasOwnedByAnotherMerchant(t *testing.T) {
+	merchant := models.Merchant{Name: " Myntra Fashion ", Aliases: []string{"www.myntra.com", "MYNTRA FASHION", ""}}
+	err := ValidateMerchant(&merchant, []models.Merchant{myntra})
+	if err == nil {
+		t.Fatalf("expected an alias conflict error")
+	}
+
+	merchant = models.Merchant{Name: " Myntra Fashion ", Aliases: []string{"MYNTRA FASHION", "", "MF"}, Patterns: []string{"fashion"}}
+	if err := ValidateMerchant(&merchant, []models.Merchant{myntra}); err != nil {
+		t.Fatalf("ValidateMerchant returned error: %v", err)
+	}
+	if merchant.Name != "Myntra Fashion" || len(merchant.Aliases) != 1 || merchant.Aliases[0] != "MF" {
+		t.Fatalf("expected trimmed name and deduplicated aliases, got %+v", merchant)
+	}
+
+	bad := models.Merchant{Name: "Broken", Patterns: []string{"("}}
+	if err := ValidateMerchant(&bad, nil); err == nil {
+		t.Fatalf("expected an invalid pattern error")
+	}
+}
+
+func TestRelinkUpdatesChangedMerchantsInBatches(t *testing.T) {
+	now := time.Now().UTC().Add(-time.Hour)
+	db := newMerchantsTestDB()
+	defer invalidateMerchants()
+	for i := 0; i < 250; i++ {
+		db.transactions = append(db.transactions, models.Transaction{
+			ID: fmt.Sprintf("myntra-%d", i), Vendor: "MYNTRA DESIGNS", Merchant: "MYNTRA DESIGNS",
+			Amount: models.FromRupees(100), DateTime: now,
+		})
+	}
+	db.transactions = append(db.transactions, models.Transaction{ID: "other", Vendor: "AMAZON", Merchant: "AMAZON", DateTime: now})
+	trashedAt := now
+	db.transactions = append(db.transactions,
+		models.Transaction{ID: "trashed", Vendor: "MYNTRA DESIGNS", Merchant: "MYNTRA DESIGNS", DateTime: now, DeletedAt: &trashedAt},
+		models.Transaction{ID: "duplicate", Vendor: "MYNTRA DESIGNS", Merchant: "MYNTRA DESIGNS", DateTime: now, DuplicateOf: "myntra-0"},
+	)
+
+	service := NewMerchantService(db, db)
+	merchant := myntra
+	merchant.ID = ""
+	if _, err := service.SaveMerchant(merchant); err != nil {
+		t.Fatalf("SaveMerchant returned error: %v", err)
+	}
+	relinked, err := service.Relink()
+	if err != nil {
+		t.Fatalf("Relink returned error: %v", err)
+	}
+	if relinked != 250 || len(db.batches) != 2 || len(db.batches[0]) != bulkBatchSize {
+		t.Fatalf("expected 250 transactions relinked in two batches, got %d in %d", relinked, len(db.batches))
+	}
+	if db.transactions[0].Merchant != "Myntra" || db.transactions[250].Merchant != "AMAZON" {
+		t.Fatalf("unexpected merchants after relink: %q %q", db.transactions[0].Merchant, db.transactions[250].Merchant)
+	}
+	if db.transactions[0].Version != 0 || len(db.changes) != 0 {
+		t.Fatalf("expected the derived merchant to leave the version and change log alone, got version %d and %d changes", db.transactions[0].Version, len(db.changes))
+	}
+	if db.transactions[251].Merchant != "MYNTRA DESIGNS" || db.transactions[252].Merchant != "MYNTRA DESIGNS" {
+		t.Fatalf("expected trashed and duplicate transactions to be left alone, got %q %q", db.transactions[251].Merchant, db.transactions[252].Merchant)
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+// MigrateIMPSTimestamps corrects ICICI IMPS transactions stored while their
+// alert time was read as UTC instead of the user's time zone. Rows that kept
+// their alert text are parsed again, which is safe to repeat. Older rows
+// without it have their wall-clock time moved into the user's zone once; the
+// settings record when that happened so a second run does not shift them
+// again. It returns how many transactions were corrected and skipped.
+func MigrateIMPSTimestamps(dbClient models.DatabaseClient) (int, int, error) {
+	settings, _ := dbClient.(models.SettingsStore)
+	shiftUnparsed := true
+	if settings != nil {
+		done, err := settings.GetSetting(models.SettingIMPSTimestampsMigrated)
+		if err != nil {
+			return 0, 0, err
+		}
+		shiftUnparsed = done == nil
+	}
+
+	txs, err := dbClient.FetchTransactionsByDateRange(time.Time{}, time.Now().UTC())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	migrated, skipped := 0, 0
+	for _, tx := range txs {
+		if tx.Type != "ICICIIMPS" {
+			continue
+		}
+		corrected, ok := correctedIMPSTime(tx, shiftUnparsed)
+		if !ok || corrected.Equal(tx.DateTime) {
+			skipped++
+			continue
+		}
+		updated, err := dbClient.PatchTransaction(tx.ID, models.TransactionPatch{DateTime: &corrected}, tx.Version)
+		if err != nil {
+			return migrated, skipped, fmt.Errorf("transaction %s: %v", tx.ID, err)
+		}
+		RecordTransactionChange(dbClient, tx, *updated, "", models.AuditSourceSystem, models.AuditActionUpdate)
+		migrated++
+	}
+
+	if settings != nil && shiftUnparsed {
+		if err := settings.SaveSetting(models.Setting{Key: models.SettingIMPSTimestampsMigrated, Value: "true", UpdatedAt: time.Now().UTC()}); err != nil {
+			return migrated, skipped, err
+		}
+	}
+	log.Printf("imps timestamp migration complete migrated=%d skipped=%d", migrated, skipped)
+	return migrated, skipped, nil
+}
+
+// correctedIMPSTime returns the time an IMPS transaction should have. Without
+// the alert text the stored UTC wall clock is read in the user's zone, but
+// only when shift is set.
+func correctedIMPSTime(tx models.Transaction, shift bool) (time.Time, bool) {
+	if match := impsPaymentPattern.FindStringSubmatch(tx.RawText); len(match) == 7 {
+		dt, err := parseIMPSDateTime(match[3], match[4], match[5])
+		return dt, err == nil
+	}
+	if !shift {
+		return time.Time{}, false
+	}
+	wall := tx.DateTime.UTC()
+	return time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), utils.UserLocation()), true
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestMigrateIMPSTimestampsMovesWallClockIntoUserZoneOnce(t *testing.T) {
+	t.Setenv("USER_TIMEZONE", "Asia/Kolkata")
+	ist := time.FixedZone("IST", 5*3600+30*60)
+	db := newTestDB(
+		models.Transaction{
+			ID:       "parsed",
+			Type:     "ICICIIMPS",
+			DateTime: time.Date(2026, 3, 4, 21, 15, 0, 0, time.UTC),
+			RawText:  "You have made an online IMPS payment of Rs 1,500.00 towards RAHUL on Mar 04, 2026 at 09:15 p.m. from your ICICI Bank Savings Account XX123",
+		},
+		models.Transaction{
+			ID:       "legacy",
+			Type:     "ICICIIMPS",
+			DateTime: time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC),
+		},
+		models.Transaction{
+			ID:       "card",
+			Type:     "HDFCCreditCard",
+			DateTime: time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC),
+		},
+	)
+
+	migrated, skipped, err := MigrateIMPSTimestamps(db)
+	if err != nil {
+		t.Fatalf("MigrateIMPSTimestamps returned error: %v", err)
+	}
+	if migrated != 2 || skipped != 0 {
+		t.Fatalf("expected 2 migrated and 0 skipped, got %d and %d", migrated, skipped)
+	}
+	if want := time.Date(2026, 3, 4, 21, 15, 0, 0, ist); !db.transactions[0].DateTime.Equal(want) {
+		t.Fatalf("expected parsed row at %v, got %v", want, db.transactions[0].DateTime)
+	}
+	if want := time.Date(2026, 2, 10, 8, 0, 0, 0, ist); !db.transactions[1].DateTime.Equal(want) {
+		t.Fatalf("expected legacy row at %v, got %v", want, db.transactions[1].DateTime)
+	}
+	if !db.transactions[2].DateTime.Equal(time.Date(2026, 2, 10, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected other transaction types to be left alone, got %v", db.transactions[2].DateTime)
+	}
+
+	migrated, skipped, err = MigrateIMPSTimestamps(db)
+	if err != nil {
+		t.Fatalf("second MigrateIMPSTimestamps returned error: %v", err)
+	}
+	if migrated != 0 || skipped != 2 {
+		t.Fatalf("expected a second run to change nothing, got %d migrated and %d skipped", migrated, skipped)
+	}
+	if want := time.Date(2026, 2, 10, 8, 0, 0, 0, ist); !db.transactions[1].DateTime.Equal(want) {
+		t.Fatalf("expected legacy row to be shifted once, got %v", db.transactions[1].DateTime)
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+// Sort fields for transaction listings.
+const (
+	SortByDate   = "date"
+	SortByAmount = "amount"
+	SortByVendor = "vendor"
+)
+
+// TransactionSort orders a listing. Ties are broken by transaction ID so
+// every row has a stable position to resume from.
+type TransactionSort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseTransactionSort reads the sort and order query values. Dates and
+// amounts default to descending (newest, largest first), vendors to
+// ascending.
+func ParseTransactionSort(field, order string) (TransactionSort, error) {
+	sorting := TransactionSort{Field: strings.ToLower(strings.TrimSpace(field))}
+	switch sorting.Field {
+	case "":
+		sorting.Field = SortByDate
+		sorting.Desc = true
+	case SortByDate, SortByAmount:
+		sorting.Desc = true
+	case SortByVendor:
+	default:
+		return sorting, fmt.Errorf("invalid sort %q, expected date, amount or vendor", field)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "":
+	case "asc":
+		sorting.Desc = false
+	case "desc":
+		sorting.Desc = true
+	default:
+		return sorting, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+	return sorting, nil
+}
+
+func (s TransactionSort) String() string {
+	if s.Desc {
+		return s.Field + ":desc"
+	}
+	return s.Field + ":asc"
+}
+
+// less reports whether a is listed before b.
+func (s TransactionSort) less(a, b models.Transaction) bool {
+	var order int
+	switch s.Field {
+	case SortByAmount:
+		order = cmp.Compare(a.Amount, b.Amount)
+	case SortByVendor:
+		order = strings.Compare(strings.ToLower(a.Vendor), strings.ToLower(b.Vendor))
+	default:
+		order = a.DateTime.Compare(b.DateTime)
+	}
+	if order == 0 {
+		order = strings.Compare(a.ID, b.ID)
+	}
+	if s.Desc {
+		return order > 0
+	}
+	return order < 0
+}
+
+// PageRequest asks for one page of a listing. A zero Sort lists newest
+// first; a zero Limit returns every remaining row. Cursor is the NextCursor
+// of the previous page.
+type PageRequest struct {
+	Sort   TransactionSort
+	Limit  int
+	Cursor string
+}
+
+// TransactionPage is one page of a listing. Total counts every transaction
+// matching the filter, not just this page; NextCursor is empty on the last
+// page.
+type TransactionPage struct {
+	Transactions []models.Transaction `json:"transactions"`
+	Total        int                  `json:"total"`
+	NextCursor   string               `json:"next_cursor,omitempty"`
+}
+
+// pageCursor records the sort and the last row of a page. Listing resumes
+// after that row rather than at an offset, so rows added or removed in the
+// meantime do not shift later pages.
+type pageCursor struct {
+	Sort     string       `json:"s"`
+	ID       string       `json:"id"`
+	DateTime time.Time    `json:"t"`
+	Amount   models.Money `json:"a"`
+	Vendor   string       `json:"v"`
+}
+
+func encodePageCursor(sorting TransactionSort, tx models.Transaction) string {
+	data, _ := json.Marshal(pageCursor{Sort: sorting.String(), ID: tx.ID, DateTime: tx.DateTime, Amount: tx.Amount, Vendor: tx.Vendor})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageCursor(value string, sorting TransactionSort) (models.Transaction, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("invalid cursor")
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return models.Transaction{}, fmt.Errorf("invalid cursor")
+	}
+	if cursor.Sort != sorting.String() {
+		return models.Transaction{}, fmt.Errorf("cursor was issued for sort %s, not %s", cursor.Sort, sorting)
+	}
+	return models.Transaction{ID: cursor.ID, DateTime: cursor.DateTime, Amount: cursor.Amount, Vendor: cursor.Vendor}, nil
+}
+
+// ListTransactionsPage lists one page of the transactions in a period that
+// match filter.
+func (s *ReportingService) ListTransactionsPage(period string, filter TransactionFilter, page PageRequest) (TransactionPage, error) {
+	start, end, err := utils.ResolvePeriod(normalizePeriod(period))
+	if err != nil {
+		return TransactionPage{}, err
+	}
+	return s.ListTransactionsByDateRangePage(start, end, filter, page)
+}
+
+// ListTransactionsByDateRangePage lists one page of the transactions between
+// from and to that match filter. Backends that can page in the database sort,
+// filter and resume there; only filter terms the database cannot evaluate
+// (categories with their subcategories, vendor and text matching, searches)
+// make it read the whole range and page in memory.
+func (s *ReportingService) ListTransactionsByDateRangePage(from, to time.Time, filter TransactionFilter, page PageRequest) (TransactionPage, error) {
+	sorting := page.Sort
+	if sorting.Field == "" {
+		sorting = TransactionSort{Field: SortByDate, Desc: true}
+	}
+
+	store, ok := s.dbClient.(models.PageStore)
+	if !ok {
+		txs, err := s.fetchTransactions(from, to)
+		if err != nil {
+			return TransactionPage{}, err
+		}
+		return s.paginate(txs, filter, sorting, page)
+	}
+
+	query := pageQuery(from, to, filter, sorting)
+	if !filterIsPushable(filter) {
+		txs, err := store.ListTransactionPage(query)
+		if err != nil {
+			return TransactionPage{}, err
+		}
+		return s.paginate(txs, filter, sorting, page)
+	}
+
+	total, err := store.CountTransactions(query)
+	if err != nil {
+		return TransactionPage{}, err
+	}
+	result := TransactionPage{Total: total}
+	if page.Cursor != "" {
+		last, err := decodePageCursor(page.Cursor, sorting)
+		if err != nil {
+			return result, err
+		}
+		query.After = &last
+	}
+	if page.Limit > 0 {
+		query.Limit = page.Limit + 1
+	}
+	txs, err := store.ListTransactionPage(query)
+	if err != nil {
+		return result, err
+	}
+	if page.Limit > 0 && len(txs) > page.Limit {
+		txs = txs[:page.Limit]
+		result.NextCursor = encodePageCursor(sorting, txs[len(txs)-1])
+	}
+	result.Transactions = txs
+	return result, nil
+}
+
+// pageQuery is the part of a listing the database can evaluate.
+func pageQuery(from, to time.Time, filter TransactionFilter, sorting TransactionSort) models.TransactionPageQuery {
+	query := models.TransactionPageQuery{
+		From:      from,
+		To:        to,
+		SortField: models.PageSortDate,
+		Desc:      sorting.Desc,
+		Type:      strings.TrimSpace(filter.Type),
+		Tags:      filter.Tags,
+		MinAmount: filter.MinAmount,
+		MaxAmount: filter.MaxAmount,
+	}
+	switch sorting.Field {
+	case SortByAmount:
+		query.SortField = models.PageSortAmount
+	case SortByVendor:
+		query.SortField = models.PageSortVendor
+	}
+	return query
+}
+
+// filterIsPushable reports whether pageQuery covers every term of f.
+func filterIsPushable(f TransactionFilter) bool {
+	return strings.TrimSpace(f.Category) == "" && strings.TrimSpace(f.Vendor) == "" &&
+		strings.TrimSpace(f.Query) == "" && f.Search == nil && f.SavedSearch == ""
+}
+
+func (s *ReportingService) paginate(txs []models.Transaction, filter TransactionFilter, sorting TransactionSort, page PageRequest) (TransactionPage, error) {
+	filter.tree = s.CategoryTree()
+	if filter.SavedSearch != "" {
+		saved, err := s.savedSearchQuery(filter.SavedSearch)
+		if err != nil {
+			return TransactionPage{}, err
+		}
+		filter.saved = saved
+	}
+	txs = applyTransactionFilter(txs, filter)
+	sort.Slice(txs, func(i, j int) bool { return sorting.less(txs[i], txs[j]) })
+	result := TransactionPage{Total: len(txs)}
+
+	if page.Cursor != "" {
+		last, err := decodePageCursor(page.Cursor, sorting)
+		if err != nil {
+			return result, err
+		}
+		txs = txs[sort.Search(len(txs), func(i int) bool { return sorting.less(last, txs[i]) }):]
+	}
+
+	if page.Limit > 0 && len(txs) > page.Limit {
+		txs = txs[:page.Limit]
+		result.NextCursor = encodePageCursor(sorting, txs[len(txs)-1])
+	}
+	result.Transactions = txs
+	return result, nil
+}
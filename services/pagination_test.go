@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func paginationTestDB() *reportingTestDB {
+	now := time.Now().Add(-time.Hour)
+	db := &reportingTestDB{}
+	for i := 0; i < 7; i++ {
+		db.transactions = append(db.transactions, models.Transaction{
+			ID:       fmt.Sprintf("tx-%d", i),
+			Vendor:   fmt.Sprintf("Vendor %c", 'G'-i),
+			Amount:   models.FromRupees(float64(100 * (i + 1))),
+			DateTime: now.Add(-time.Duration(i) * time.Minute),
+		})
+	}
+	return db
+}
+
+func TestListTransactionsPageWalksEveryRowOnce(t *testing.T) {
+	db := paginationTestDB()
+	reporting := NewReportingService(db)
+	sorting, err := ParseTransactionSort("amount", "asc")
+	if err != nil {
+		t.Fatalf("ParseTransactionSort returned error: %v", err)
+	}
+
+	var seen []string
+	page := PageRequest{Sort: sorting, Limit: 3}
+	for {
+		result, err := reporting.ListTransactionsPage("LAST_30_DAYS", TransactionFilter{}, page)
+		if err != nil {
+			t.Fatalf("ListTransactionsPage returned error: %v", err)
+		}
+		if page.Cursor == "" && result.Total != 7 {
+			t.Fatalf("expected a total of 7, got %d", result.Total)
+		}
+		for _, tx := range result.Transactions {
+			seen = append(seen, tx.ID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+		// A row added between pages must not shift the next page.
+		db.transactions = append(db.transactions, models.Transaction{ID: fmt.Sprintf("new-%d", len(seen)), Amount: models.FromRupees(1), DateTime: time.Now().Add(-time.Minute)})
+	}
+
+	want := []string{"tx-0", "tx-1", "tx-2", "tx-3", "tx-4", "tx-5", "tx-6"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+}
+
+func TestListTransactionsPageFiltersAndSorts(t *testing.T) {
+	reporting := NewReportingService(paginationTestDB())
+	low, high := models.FromRupees(200), models.FromRupees(500)
+	sorting, _ := ParseTransactionSort("vendor", "")
+
+	result, err := reporting.ListTransactionsPage("LAST_30_DAYS", TransactionFilter{MinAmount: &low, MaxAmount: &high}, PageRequest{Sort: sorting, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListTransactionsPage returned error: %v", err)
+	}
+	if result.Total != 4 || len(result.Transactions) != 2 || result.Transactions[0].Vendor != "Vendor C" || result.NextCursor == "" {
+		t.Fatalf("unexpected page %+v", result)
+	}
+
+	other, _ := ParseTransactionSort("date", "")
+	if _, err := reporting.ListTransactionsPage("LAST_30_DAYS", TransactionFilter{}, PageRequest{Sort: other, Cursor: result.NextCursor}); err == nil {
+		t.Fatalf("expected a cursor issued for another sort to be rejected")
+	}
+	if _, err := ParseTransactionSort("category", ""); err == nil {
+		t.Fatalf("expected an unknown sort field to be rejected")
+	}
+}
+
+func TestListTransactionsPagePushesLimitAndCursorToStore(t *testing.T) {
+	db := newTestDB(paginationTestDB().transactions...)
+	reporting := NewReportingService(db)
+	sorting, err := ParseTransactionSort("amount", "asc")
+	if err != nil {
+		t.Fatalf("ParseTransactionSort returned error: %v", err)
+	}
+	minAmount := models.FromRupees(200)
+
+	var seen []string
+	page := PageRequest{Sort: sorting, Limit: 4}
+	for {
+		result, err := reporting.ListTransactionsPage("LAST_30_DAYS", TransactionFilter{MinAmount: &minAmount}, page)
+		if err != nil {
+			t.Fatalf("ListTransactionsPage returned error: %v", err)
+		}
+		if result.Total != 6 {
+			t.Fatalf("expected a total of 6, got %d", result.Total)
+		}
+		for _, tx := range result.Transactions {
+			seen = append(seen, tx.ID)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+
+	want := []string{"tx-1", "tx-2", "tx-3", "tx-4", "tx-5", "tx-6"}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("expected %v, got %v", want, seen)
+	}
+	if len(db.pages) != 2 {
+		t.Fatalf("expected one store query per page, got %d", len(db.pages))
+	}
+	first, second := db.pages[0], db.pages[1]
+	if first.Limit != 5 || first.After != nil || first.SortField != models.PageSortAmount || first.MinAmount == nil {
+		t.Fatalf("expected the first page to be limited and filtered in the store, got %+v", first)
+	}
+	if second.After == nil || second.After.ID != "tx-4" {
+		t.Fatalf("expected the second page to resume after tx-4, got %+v", second.After)
+	}
+}
+
+func TestListTransactionsPageFiltersInMemoryWhenStoreCannot(t *testing.T) {
+	db := newTestDB(paginationTestDB().transactions...)
+	reporting := NewReportingService(db)
+
+	result, err := reporting.ListTransactionsPage("LAST_30_DAYS", TransactionFilter{Vendor: "vendor g"}, PageRequest{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListTransactionsPage returned error: %v", err)
+	}
+	if result.Total != 1 || len(result.Transactions) != 1 || result.Transactions[0].ID != "tx-0" {
+		t.Fatalf("expected only tx-0, got %+v", result)
+	}
+	if len(db.pages) != 1 || db.pages[0].Limit != 0 {
+		t.Fatalf("expected one unlimited store query, got %+v", db.pages)
+	}
+}
@@ -9,67 +9,77 @@ import (
 	"time"
 
 	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
 )
 
 func ParseCreditCardTransaction(text string, receivedAt time.Time, dbClient models.DatabaseClient) *models.Transaction {
 	// Try HDFC format: "Rs. 3241.00 has been debited from your HDFC Bank Credit Card ending 4207 towards WWW MYNTRA COM on 20 Apr, 2026 at 10:20:18."
-	re := regexp.MustCompile(`Rs\.?\s*([\d,\.]+)\s+has\s+been\s+debited\s+from\s+your\s+HDFC\s+Bank\s+Credit\s+Card\s+ending\s+(\d+)\s+towards\s+(.+?)\s+on\s+(\d{1,2}\s+[A-Za-z]{3},\s+\d{4})\s+at\s+(\d{2}:\d{2}:\d{2})`)
+	re := regexp.MustCompile(`(Rs\.?|[A-Z]{3})\s*([\d,\.]+)\s+has\s+been\s+debited\s+from\s+your\s+HDFC\s+Bank\s+Credit\s+Card\s+ending\s+(\d+)\s+towards\s+(.+?)\s+on\s+(\d{1,2}\s+[A-Za-z]{3},\s+\d{4})\s+at\s+(\d{2}:\d{2}:\d{2})`)
 	match := re.FindStringSubmatch(text)
-	if len(match) == 6 {
-		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	if len(match) == 7 {
+		amount, err := models.ParseMoney(match[2])
 		if err != nil {
 			log.Printf("Error parsing amount: %v", err)
 			return nil
 		}
-		vendor := strings.TrimSpace(match[3])
-		return &models.Transaction{
+		vendor := strings.TrimSpace(match[4])
+		tx := &models.Transaction{
 			Type:       "HDFCCreditCard",
-			CardEnding: match[2],
-			Amount:     amount,
+			CardEnding: match[3],
 			Vendor:     vendor,
 			DateTime:   receivedAt,
-			Category:   CategorizeTransaction(vendor, dbClient),
+			ParserRule: "hdfc_credit_card_has_been_debited",
+			RawText:    match[0],
 		}
+		SetTransactionAmount(tx, match[1], amount, dbClient)
+		EnrichTransaction(tx, dbClient)
+		return tx
 	}
 
 	// Try new HDFC format: "Rs.304.00 is debited from your HDFC Bank Credit Card ending 4207 towards RAZORPAY LICIOUS on 09 Jan, 2026 at 16:28:26."
-	re = regexp.MustCompile(`Rs\.?([\d,\.]+)\s+is\s+debited\s+from\s+your\s+HDFC\s+Bank\s+Credit\s+Card\s+ending\s+(\d+)\s+towards\s+(.+?)\s+on\s+(\d{1,2}\s+[A-Za-z]{3},\s+\d{4})\s+at\s+(\d{2}:\d{2}:\d{2})`)
+	re = regexp.MustCompile(`(Rs\.?|[A-Z]{3})\s*([\d,\.]+)\s+is\s+debited\s+from\s+your\s+HDFC\s+Bank\s+Credit\s+Card\s+ending\s+(\d+)\s+towards\s+(.+?)\s+on\s+(\d{1,2}\s+[A-Za-z]{3},\s+\d{4})\s+at\s+(\d{2}:\d{2}:\d{2})`)
 	match = re.FindStringSubmatch(text)
-	if len(match) == 6 {
-		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	if len(match) == 7 {
+		amount, err := models.ParseMoney(match[2])
 		if err != nil {
 			log.Printf("Error parsing amount: %v", err)
 			return nil
 		}
-		vendor := strings.TrimSpace(match[3])
-		return &models.Transaction{
+		vendor := strings.TrimSpace(match[4])
+		tx := &models.Transaction{
 			Type:       "HDFCCreditCard",
-			CardEnding: match[2],
-			Amount:     amount,
+			CardEnding: match[3],
 			Vendor:     vendor,
 			DateTime:   receivedAt,
-			Category:   CategorizeTransaction(vendor, dbClient),
+			ParserRule: "hdfc_credit_card_is_debited",
+			RawText:    match[0],
 		}
+		SetTransactionAmount(tx, match[1], amount, dbClient)
+		EnrichTransaction(tx, dbClient)
+		return tx
 	}
 
 	// Try original format: "Credit Card ending 1234 for Rs 100.00 at VENDOR on 01-01-2024 12:00:00"
-	re = regexp.MustCompile(`Credit Card ending (\d+) for Rs ([\d,.]+) at (.*?) on (\d{2}-\d{2}-\d{4} \d{2}:\d{2}:\d{2})`)
+	re = regexp.MustCompile(`Credit Card ending (\d+) for (Rs|[A-Z]{3}) ([\d,.]+) at (.*?) on (\d{2}-\d{2}-\d{4} \d{2}:\d{2}:\d{2})`)
 	match = re.FindStringSubmatch(text)
-	if len(match) == 5 {
-		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[2], ",", ""), 64)
+	if len(match) == 6 {
+		amount, err := models.ParseMoney(match[3])
 		if err != nil {
 			log.Printf("Error parsing amount: %v", err)
 			return nil
 		}
-		vendor := strings.TrimSpace(match[3])
-		return &models.Transaction{
+		vendor := strings.TrimSpace(match[4])
+		tx := &models.Transaction{
 			Type:       "HDFCCreditCard",
 			CardEnding: match[1],
-			Amount:     amount,
 			Vendor:     vendor,
 			DateTime:   receivedAt,
-			Category:   CategorizeTransaction(vendor, dbClient),
+			ParserRule: "hdfc_credit_card_legacy",
+			RawText:    match[0],
 		}
+		SetTransactionAmount(tx, match[2], amount, dbClient)
+		EnrichTransaction(tx, dbClient)
+		return tx
 	}
 	return nil
 }
@@ -78,7 +88,7 @@ func ParseBankTransaction(text string, receivedAt time.Time, dbClient models.Dat
 	re := regexp.MustCompile(`Your A/c (\w+) is debited for INR ([\d,\.]+) on (\d{2}-\d{2}-\d{2}) and A/c (\w+) is credited`)
 	match := re.FindStringSubmatch(text)
 	if len(match) == 5 {
-		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[2], ",", ""), 64)
+		amount, err := models.ParseMoney(match[2])
 		if err != nil {
 			log.Printf("Error parsing amount: %v", err)
 			return nil
@@ -89,38 +99,46 @@ func ParseBankTransaction(text string, receivedAt time.Time, dbClient models.Dat
 			CreditedAccount: match[4],
 			Amount:          amount,
 			DateTime:        receivedAt,
+			ParserRule:      "hdfc_bank_transfer",
+			RawText:         match[0],
 		}
 	}
 	return nil
 }
 
 func ParseICICICreditCardTransaction(text string, receivedAt time.Time, dbClient models.DatabaseClient) *models.Transaction {
-	patterns := []*regexp.Regexp{
-		regexp.MustCompile(`ICICI Bank Credit Card (\w+) has been used for a transaction of INR ([\d,\.]+) on ([A-Za-z]+ \d{1,2}, \d{4}) at (\d{2}:\d{2}:\d{2})\. Info: (.+?)\.\s+The`),
-		regexp.MustCompile(`ICICI Bank Credit Card (\w+) has been used for a transaction of INR ([\d,\.]+) on ([A-Za-z]+ \d{1,2}, \d{4}) at (\d{2}:\d{2}:\d{2})\. Info: (.+?)\.`),
+	patterns := []struct {
+		rule string
+		re   *regexp.Regexp
+	}{
+		{"icici_credit_card", regexp.MustCompile(`ICICI Bank Credit Card (\w+) has been used for a transaction of ([A-Z]{3}) ([\d,\.]+) on ([A-Za-z]+ \d{1,2}, \d{4}) at (\d{2}:\d{2}:\d{2})\. Info: (.+?)\.\s+The`)},
+		{"icici_credit_card_short", regexp.MustCompile(`ICICI Bank Credit Card (\w+) has been used for a transaction of ([A-Z]{3}) ([\d,\.]+) on ([A-Za-z]+ \d{1,2}, \d{4}) at (\d{2}:\d{2}:\d{2})\. Info: (.+?)\.`)},
 	}
 
-	for _, re := range patterns {
-		match := re.FindStringSubmatch(text)
-		if len(match) != 6 {
+	for _, pattern := range patterns {
+		match := pattern.re.FindStringSubmatch(text)
+		if len(match) != 7 {
 			continue
 		}
 
-		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[2], ",", ""), 64)
+		amount, err := models.ParseMoney(match[3])
 		if err != nil {
 			log.Printf("Error parsing amount: %v", err)
 			return nil
 		}
 
-		vendor := strings.TrimSpace(match[5])
-		return &models.Transaction{
+		vendor := strings.TrimSpace(match[6])
+		tx := &models.Transaction{
 			Type:       "ICICICreditCard",
 			CardEnding: match[1],
-			Amount:     amount,
 			Vendor:     vendor,
 			DateTime:   receivedAt,
-			Category:   CategorizeTransaction(vendor, dbClient),
+			ParserRule: pattern.rule,
+			RawText:    match[0],
 		}
+		SetTransactionAmount(tx, match[2], amount, dbClient)
+		EnrichTransaction(tx, dbClient)
+		return tx
 	}
 	return nil
 }
@@ -130,112 +148,110 @@ func ParseCardPaymentTransaction(text string, dbClient models.DatabaseClient) *m
 	re := regexp.MustCompile(`payment of [₹INR ]*([\d,\.]+) using iMobile towards (\w+) from your Account (\w+)`)
 	match := re.FindStringSubmatch(text)
 	if len(match) == 4 {
-		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+		amount, err := models.ParseMoney(match[1])
 		if err != nil {
 			log.Printf("Error parsing amount: %v", err)
 			return nil
 		}
 		vendor := match[2]
-		return &models.Transaction{
+		tx := &models.Transaction{
 			Type:           "ICICIBankTransfer",
 			Amount:         amount,
 			CardEnding:     vendor,
 			DebitedAccount: match[3],
 			Vendor:         vendor,
-			Category:       CategorizeTransaction(vendor, dbClient),
+			ParserRule:     "icici_imobile_payment",
+			RawText:        match[0],
 		}
+		EnrichTransaction(tx, dbClient)
+		return tx
 	}
 	return nil
 }
 
+var impsPaymentPattern = regexp.MustCompile(`You have made an online IMPS payment of Rs ([\d,\.]+) towards (.+) on ([A-Za-z]+ \d{2}, \d{4}) at (\d{2}:\d{2}) (a\.m\.|p\.m\.) from your .* Account (\w+)`)
+
 // IMPS Payment Transaction
 func ParseIMPSPaymentTransaction(text string, dbClient models.DatabaseClient) *models.Transaction {
-	re := regexp.MustCompile(`You have made an online IMPS payment of Rs ([\d,\.]+) towards (.+) on ([A-Za-z]+ \d{2}, \d{4}) at (\d{2}:\d{2}) (a\.m\.|p\.m\.) from your .* Account (\w+)`)
-	match := re.FindStringSubmatch(text)
+	match := impsPaymentPattern.FindStringSubmatch(text)
 	if len(match) == 7 {
-		amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+		amount, err := models.ParseMoney(match[1])
 		if err != nil {
 			log.Printf("Error parsing amount: %v", err)
 			return nil
 		}
-		timeStr := match[4]
-		if match[5] == "p.m." && !strings.HasPrefix(timeStr, "12") {
-			hour, min := timeStr[:2], timeStr[3:]
-			hourInt, _ := strconv.Atoi(hour)
-			hourInt += 12
-			timeStr = fmt.Sprintf("%02d:%s", hourInt, min)
-		}
-		datetimeStr := match[3] + " " + timeStr
-		dt, err := time.Parse("Jan 2, 2006 15:04", datetimeStr)
+		dt, err := parseIMPSDateTime(match[3], match[4], match[5])
 		if err != nil {
 			log.Printf("Error parsing date: %v", err)
 			return nil
 		}
 		vendor := match[2] // payee
-		return &models.Transaction{
+		tx := &models.Transaction{
 			Type:           "ICICIIMPS",
 			Amount:         amount,
 			Vendor:         vendor,
 			DateTime:       dt,
 			DebitedAccount: match[6],
-			Category:       CategorizeTransaction(vendor, dbClient),
+			ParserRule:     "icici_imps",
+			RawText:        match[0],
 		}
+		EnrichTransaction(tx, dbClient)
+		return tx
 	}
 	return nil
 }
 
+// parseIMPSDateTime reads the date, 12-hour clock and "a.m."/"p.m." of an
+// IMPS alert as wall-clock time in the user's time zone.
+func parseIMPSDateTime(date, clock, meridiem string) (time.Time, error) {
+	if meridiem == "p.m." && !strings.HasPrefix(clock, "12") {
+		hour, min := clock[:2], clock[3:]
+		hourInt, _ := strconv.Atoi(hour)
+		hourInt += 12
+		clock = fmt.Sprintf("%02d:%s", hourInt, min)
+	}
+	return time.ParseInLocation("Jan 2, 2006 15:04", date+" "+clock, utils.UserLocation())
+}
+
 func ParseRBLCreditCardTransaction(text string, receivedAt time.Time, dbClient models.DatabaseClient) *models.Transaction {
-	re := regexp.MustCompile(`INR([\d,\.]+)\s+spent\s+at\s+(.+?)\s+on\s+RBL\s+Bank\s+credit\s+card\s+\((\d+)\)\s+on\s+(\d{2}-\d{2}-\d{4})`)
+	re := regexp.MustCompile(`([A-Z]{3})\s?([\d,\.]+)\s+spent\s+at\s+(.+?)\s+on\s+RBL\s+Bank\s+credit\s+card\s+\((\d+)\)\s+on\s+(\d{2}-\d{2}-\d{4})`)
 	match := re.FindStringSubmatch(text)
-	if len(match) != 5 {
+	if len(match) != 6 {
 		return nil
 	}
 
-	amount, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	amount, err := models.ParseMoney(match[2])
 	if err != nil {
 		log.Printf("Error parsing RBL amount: %v", err)
 		return nil
 	}
 
-	vendor := strings.TrimSpace(match[2])
-	return &models.Transaction{
+	vendor := strings.TrimSpace(match[3])
+	tx := &models.Transaction{
 		Type:       "RBLCreditCard",
-		CardEnding: match[3],
-		Amount:     amount,
+		CardEnding: match[4],
 		Vendor:     vendor,
 		DateTime:   receivedAt,
-		Category:   CategorizeTransaction(vendor, dbClient),
+		ParserRule: "rbl_credit_card",
+		RawText:    match[0],
 	}
+	SetTransactionAmount(tx, match[1], amount, dbClient)
+	EnrichTransaction(tx, dbClient)
+	return tx
 }
 
-// CategorizeTransaction determines the category based on vendor name
-// Uses manual mapping first, then stored manual mappings, and falls back to Other.
-func CategorizeTransaction(vendor string, dbClient models.DatabaseClient) string {
-	if vendor == "" {
-		return "Other"
-	}
-
-	// Convert to lowercase for case-insensitive matching
-	vendorLower := strings.ToLower(vendor)
-
-	// 1. Check manual mapping first
-	if category, exists := models.VendorCategoryMapping[vendorLower]; exists {
-		return category
-	}
-
-	// Check for partial matches in manual mapping
-	for mappedVendor, category := range models.VendorCategoryMapping {
-		if strings.Contains(vendorLower, mappedVendor) || strings.Contains(mappedVendor, vendorLower) {
-			return category
-		}
-	}
-
-	// 2. Check MongoDB cache
-	if dbClient != nil {
-		if cachedMapping, err := dbClient.GetCategoryMapping(vendorLower); err == nil && cachedMapping != nil {
-			return cachedMapping.Category
-		}
-	}
+// EnrichTransaction sets the canonical merchant and the category of a parsed
+// transaction.
+func EnrichTransaction(tx *models.Transaction, dbClient models.DatabaseClient) {
+	tx.Merchant = CanonicalMerchant(tx.Vendor, dbClient)
+	tx.Category = CategorizeTransaction(*tx, dbClient)
+}
 
-	return "Other"
+// CategorizeTransaction determines the category of a parsed transaction.
+// Manual vendor mappings come first, then categorization rules (the stored
+// rules, or the built-in defaults for backends without a rule store), then
+// the merchant's default category and other stored vendor mappings, falling
+// back to Other. ExplainCategory reports the reasoning.
+func CategorizeTransaction(tx models.Transaction, dbClient models.DatabaseClient) string {
+	return ExplainCategory(tx, dbClient).Category
 }
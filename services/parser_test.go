@@ -10,9 +10,15 @@ import (
 type parserTestDB struct{}
 
 func (d *parserTestDB) SaveTransaction(txn models.Transaction) error { return nil }
+func (d *parserTestDB) GetTransaction(id string) (*models.Transaction, error) {
+	return nil, nil
+}
 func (d *parserTestDB) UpdateTransaction(id string, txn models.Transaction) error {
 	return nil
 }
+func (d *parserTestDB) PatchTransaction(id string, patch models.TransactionPatch, expectedVersion int64) (*models.Transaction, error) {
+	return nil, nil
+}
 func (d *parserTestDB) FetchTransactionsByDateRange(from, to time.Time) ([]models.Transaction, error) {
 	return nil, nil
 }
@@ -44,7 +50,7 @@ func TestParseICICICreditCardTransaction_AmazonPayAlert(t *testing.T) {
 	if tx.CardEnding != "XX3013" {
 		t.Fatalf("unexpected card ending %q", tx.CardEnding)
 	}
-	if tx.Amount != 241.00 {
+	if tx.Amount != models.FromRupees(241.00) {
 		t.Fatalf("unexpected amount %v", tx.Amount)
 	}
 	if tx.Vendor != "AMAZON PAY IN E COMMERCE" {
@@ -53,6 +59,9 @@ func TestParseICICICreditCardTransaction_AmazonPayAlert(t *testing.T) {
 	if tx.Category != "Amazon" {
 		t.Fatalf("unexpected category %q", tx.Category)
 	}
+	if tx.ParserRule != "icici_credit_card_short" || tx.RawText != text[len("Your "):] {
+		t.Fatalf("unexpected provenance rule=%q raw=%q", tx.ParserRule, tx.RawText)
+	}
 }
 
 func TestParseICICICreditCardTransaction_WithTrailingSentence(t *testing.T) {
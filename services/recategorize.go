@@ -0,0 +1,267 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// recategorizeActor is recorded as the actor of the changes the
+// recategorize-history job writes.
+const recategorizeActor = "recategorize-job"
+
+// ErrRecategorizePreviewStale is returned when the changes a run would apply
+// no longer match the dry run they were previewed with.
+var ErrRecategorizePreviewStale = errors.New("the changes differ from the preview; run a new dry run")
+
+// RecategorizeChange is one line of the diff: a transaction whose stored
+// category differs from what categorization picks today.
+type RecategorizeChange struct {
+	TransactionID string       `json:"transaction_id"`
+	DateTime      time.Time    `json:"date_time"`
+	Vendor        string       `json:"vendor"`
+	Amount        models.Money `json:"amount"`
+	OldCategory   string       `json:"old_category"`
+	NewCategory   string       `json:"new_category"`
+	// Reason says which mapping, rule or merchant picked NewCategory.
+	Reason string `json:"reason"`
+}
+
+// RecategorizeSummary counts the changes from one category to another.
+type RecategorizeSummary struct {
+	OldCategory string `json:"old_category"`
+	NewCategory string `json:"new_category"`
+	Count       int    `json:"count"`
+}
+
+// RecategorizeResult reports a recategorize run. Changed is what was (or,
+// for a dry run, would be) written. Transactions whose category was set by
+// hand or that are split are left alone and counted separately.
+// PreviewToken identifies the change list; applying it requires the token
+// of the dry run that showed it.
+type RecategorizeResult struct {
+	DryRun        bool                  `json:"dry_run"`
+	PreviewToken  string                `json:"preview_token"`
+	Scanned       int                   `json:"scanned"`
+	Changed       int                   `json:"changed"`
+	SkippedManual int                   `json:"skipped_manual"`
+	SkippedSplit  int                   `json:"skipped_split"`
+	Summary       []RecategorizeSummary `json:"summary"`
+	Changes       []RecategorizeChange  `json:"changes"`
+}
+
+type RecategorizeService struct {
+	dbClient models.DatabaseClient
+	store    models.BulkStore
+}
+
+func NewRecategorizeService(dbClient models.DatabaseClient, store models.BulkStore) *RecategorizeService {
+	return &RecategorizeService{dbClient: dbClient, store: store}
+}
+
+// Run re-runs categorization over the transactions between from and to and
+// diffs the result against their stored categories. Unless includeManual is
+// set, categories the user chose by hand are kept. Without dryRun the diff is
+// written in batches, audited like any bulk edit, but only when previewToken
+// is the token of a dry run that showed the same diff; otherwise nothing is
+// written and ErrRecategorizePreviewStale is returned.
+func (s *RecategorizeService) Run(from, to time.Time, includeManual, dryRun bool, previewToken string) (RecategorizeResult, error) {
+	result := RecategorizeResult{DryRun: dryRun}
+	if !dryRun && previewToken == "" {
+		return result, fmt.Errorf("preview token is required; run a dry run first")
+	}
+
+	txs, err := NewReportingService(s.dbClient).ListTransactionsByDateRange(from, to, TransactionFilter{}, 0)
+	if err != nil {
+		return result, err
+	}
+	sort.SliceStable(txs, func(i, j int) bool { return txs[i].DateTime.Before(txs[j].DateTime) })
+	result.Scanned = len(txs)
+
+	mappingFor, err := s.mappingLookup()
+	if err != nil {
+		return result, err
+	}
+	var candidates []models.Transaction
+	var explanations []CategoryExplanation
+	for _, tx := range txs {
+		explanation := explainCategory(tx, s.dbClient, mappingFor)
+		if strings.EqualFold(strings.TrimSpace(tx.Category), explanation.Category) {
+			continue
+		}
+		if len(tx.Splits) > 0 {
+			result.SkippedSplit++
+			continue
+		}
+		candidates = append(candidates, tx)
+		explanations = append(explanations, explanation)
+	}
+
+	var setByHand map[string]bool
+	if !includeManual {
+		if setByHand, err = s.categoriesSetByHand(candidates); err != nil {
+			return result, err
+		}
+	}
+
+	for i, tx := range candidates {
+		explanation := explanations[i]
+		if setByHand[tx.ID] {
+			result.SkippedManual++
+			continue
+		}
+		result.Changes = append(result.Changes, RecategorizeChange{
+			TransactionID: tx.ID,
+			DateTime:      tx.DateTime,
+			Vendor:        tx.Vendor,
+			Amount:        tx.Amount,
+			OldCategory:   tx.Category,
+			NewCategory:   explanation.Category,
+			Reason:        explanation.Reason,
+		})
+	}
+	result.Summary = summarizeRecategorize(result.Changes)
+	result.PreviewToken = recategorizePreviewToken(result.Changes)
+
+	if dryRun {
+		result.Changed = len(result.Changes)
+		return result, nil
+	}
+	if previewToken != result.PreviewToken {
+		return result, ErrRecategorizePreviewStale
+	}
+
+	byCategory := map[string][]string{}
+	for _, change := range result.Changes {
+		byCategory[change.NewCategory] = append(byCategory[change.NewCategory], change.TransactionID)
+	}
+	categories := make([]string, 0, len(byCategory))
+	for category := range byCategory {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	bulk := NewBulkEditService(s.dbClient, s.store)
+	for _, category := range categories {
+		applied, err := bulk.Apply(BulkEditRequest{
+			IDs:      byCategory[category],
+			Action:   BulkActionCategory,
+			Category: category,
+		}, recategorizeActor, models.AuditSourceRule)
+		result.Changed += applied.Changed
+		if err != nil {
+			return result, err
+		}
+	}
+	log.Printf("recategorize applied scanned=%d changed=%d skipped_manual=%d skipped_split=%d",
+		result.Scanned, result.Changed, result.SkippedManual, result.SkippedSplit)
+	return result, nil
+}
+
+// mappingLookup returns the vendor mapping reader for a run. Backends with
+// a category store have every mapping loaded once; the others are asked per
+// vendor.
+func (s *RecategorizeService) mappingLookup() (func(vendor string) *models.CategoryMapping, error) {
+	store, ok := s.dbClient.(models.CategoryStore)
+	if !ok {
+		return func(vendor string) *models.CategoryMapping {
+			mapping, err := s.dbClient.GetCategoryMapping(vendor)
+			if err != nil {
+				return nil
+			}
+			return mapping
+		}, nil
+	}
+	mappings, err := store.ListCategoryMappings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vendor mappings: %v", err)
+	}
+	byVendor := make(map[string]*models.CategoryMapping, len(mappings))
+	for i := range mappings {
+		byVendor[mappings[i].Vendor] = &mappings[i]
+	}
+	return func(vendor string) *models.CategoryMapping {
+		return byVendor[vendor]
+	}, nil
+}
+
+// recategorizePreviewToken identifies a change list by the transactions it
+// touches and their old and new categories.
+func recategorizePreviewToken(changes []RecategorizeChange) string {
+	lines := make([]string, len(changes))
+	for i, change := range changes {
+		lines[i] = change.TransactionID + "\x00" + change.OldCategory + "\x00" + change.NewCategory
+	}
+	sort.Strings(lines)
+	sum := sha1.Sum([]byte(strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// categoriesSetByHand reports which transactions' current categories the
+// user chose: entered manually with a category, or whose latest category
+// change in the change log came from the UI or the chat. The change logs are
+// read in one batch.
+func (s *RecategorizeService) categoriesSetByHand(txs []models.Transaction) (map[string]bool, error) {
+	manual := map[string]bool{}
+	var ids []string
+	for _, tx := range txs {
+		if tx.SourceKind == models.SourceKindManual && !isUncategorized(tx) {
+			manual[tx.ID] = true
+		} else {
+			ids = append(ids, tx.ID)
+		}
+	}
+	audit, ok := s.dbClient.(models.AuditStore)
+	if !ok || len(ids) == 0 {
+		return manual, nil
+	}
+	logs, err := audit.ListTransactionChangesFor(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change logs: %v", err)
+	}
+	for id, changes := range logs {
+		manual[id] = lastCategoryChangeByHand(changes)
+	}
+	return manual, nil
+}
+
+// lastCategoryChangeByHand reports whether the latest category change in a
+// change log came from the UI or the chat.
+func lastCategoryChangeByHand(changes []models.TransactionChange) bool {
+	for i := len(changes) - 1; i >= 0; i-- {
+		for _, field := range changes[i].Fields {
+			if field == "category" {
+				return changes[i].Source == models.AuditSourceUI || changes[i].Source == models.AuditSourceChat
+			}
+		}
+	}
+	return false
+}
+
+func summarizeRecategorize(changes []RecategorizeChange) []RecategorizeSummary {
+	counts := map[[2]string]int{}
+	for _, change := range changes {
+		counts[[2]string{change.OldCategory, change.NewCategory}]++
+	}
+	summary := make([]RecategorizeSummary, 0, len(counts))
+	for key, count := range counts {
+		summary = append(summary, RecategorizeSummary{OldCategory: key[0], NewCategory: key[1], Count: count})
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Count != summary[j].Count {
+			return summary[i].Count > summary[j].Count
+		}
+		if summary[i].OldCategory != summary[j].OldCategory {
+			return summary[i].OldCategory < summary[j].OldCategory
+		}
+		return summary[i].NewCategory < summary[j].NewCategory
+	})
+	return summary
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// newRecategorizeTestDB holds 201 uncategorized SWIGGY orders and four UBER
+// rides: one categorized by a rule then corrected by hand, one entered by
+// hand, one split and one already right.
+func newRecategorizeTestDB() *testDB {
+	now := time.Now().UTC().Add(-time.Hour)
+	db := newTestDB()
+	for i := 0; i < 201; i++ {
+		db.transactions = append(db.transactions, models.Transaction{
+			ID: fmt.Sprintf("swiggy-%d", i), Vendor: "SWIGGY", Category: "Other", Amount: models.FromRupees(300), DateTime: now,
+		})
+	}
+	db.transactions = append(db.transactions,
+		models.Transaction{ID: "uber", Vendor: "UBER", Category: "Food", Amount: models.FromRupees(250), DateTime: now},
+		models.Transaction{ID: "gift", Vendor: "UBER", Category: "Gifts", SourceKind: models.SourceKindManual, Amount: models.FromRupees(900), DateTime: now},
+		models.Transaction{ID: "split", Vendor: "UBER", Category: "Shopping", Amount: models.FromRupees(400), DateTime: now,
+			Splits: []models.Split{{Amount: models.FromRupees(200), Category: "Shopping"}, {Amount: models.FromRupees(200), Category: "Food"}}},
+		models.Transaction{ID: "ok", Vendor: "UBER", Category: "Travel", Amount: models.FromRupees(150), DateTime: now},
+	)
+	db.changes = []models.TransactionChange{
+		{TransactionID: "uber", Source: models.AuditSourceRule, Fields: []string{"category"}},
+		{TransactionID: "uber", Source: models.AuditSourceUI, Fields: []string{"category"}},
+		{TransactionID: "uber", Source: models.AuditSourceRule, Fields: []string{"tags"}},
+	}
+	return db
+}
+
+func TestRecategorizeDryRunReturnsDiffWithoutWriting(t *testing.T) {
+	db := newRecategorizeTestDB()
+
+	result, err := NewRecategorizeService(db, db).Run(time.Time{}, time.Now(), false, true, "")
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if result.Scanned != 205 || result.Changed != 201 || result.SkippedManual != 2 || result.SkippedSplit != 1 {
+		t.Fatalf("unexpected counts %+v", result)
+	}
+	if len(result.Changes) != 201 || result.Changes[0].OldCategory != "Other" || result.Changes[0].NewCategory != "Food" || result.Changes[0].Reason == "" {
+		t.Fatalf("unexpected diff %+v", result.Changes[0])
+	}
+	if len(result.Summary) != 1 || result.Summary[0] != (RecategorizeSummary{OldCategory: "Other", NewCategory: "Food", Count: 201}) {
+		t.Fatalf("unexpected summary %+v", result.Summary)
+	}
+	if len(db.batches) != 0 || db.transactions[0].Category != "Other" {
+		t.Fatalf("expected a dry run to write nothing")
+	}
+	if db.changeLogs != 1 {
+		t.Fatalf("expected the change logs to be read in one batch, got %d reads", db.changeLogs)
+	}
+	if db.mappingReads != 0 {
+		t.Fatalf("expected the vendor mappings to be listed once, got %d single reads", db.mappingReads)
+	}
+	if result.PreviewToken == "" {
+		t.Fatalf("expected a preview token")
+	}
+}
+
+func TestRecategorizeAppliesInBatchesAndCanOverrideManual(t *testing.T) {
+	db := newRecategorizeTestDB()
+	service := NewRecategorizeService(db, db)
+
+	preview, err := service.Run(time.Time{}, time.Now(), false, true, "")
+	if err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+	result, err := service.Run(time.Time{}, time.Now(), false, false, preview.PreviewToken)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if result.Changed != 201 || len(db.batches) != 2 || len(db.batches[0]) != bulkBatchSize {
+		t.Fatalf("expected 201 changes in two batches, got %d in %d", result.Changed, len(db.batches))
+	}
+	if db.transactions[200].Category != "Food" || db.transactions[201].Category != "Food" {
+		t.Fatalf("expected only the non-manual transactions to change")
+	}
+	last := db.changes[len(db.changes)-1]
+	if last.Source != models.AuditSourceRule || last.Actor != recategorizeActor {
+		t.Fatalf("expected job changes in the change log, got %+v", last)
+	}
+
+	preview, err = service.Run(time.Time{}, time.Now(), true, true, "")
+	if err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+	result, err = service.Run(time.Time{}, time.Now(), true, false, preview.PreviewToken)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if result.Changed != 2 || db.transactions[201].Category != "Travel" || db.transactions[202].Category != "Travel" {
+		t.Fatalf("expected include_manual to overwrite hand-set categories, got %+v", result)
+	}
+	if db.transactions[203].Category != "Shopping" {
+		t.Fatalf("expected split transactions to be left alone")
+	}
+}
+
+func TestRecategorizeRefusesChangesThatDifferFromThePreview(t *testing.T) {
+	db := newRecategorizeTestDB()
+	service := NewRecategorizeService(db, db)
+
+	if _, err := service.Run(time.Time{}, time.Now(), false, false, ""); err == nil {
+		t.Fatalf("expected applying without a preview token to fail")
+	}
+	preview, err := service.Run(time.Time{}, time.Now(), false, true, "")
+	if err != nil {
+		t.Fatalf("dry run returned error: %v", err)
+	}
+
+	// A new order arrives between the preview and the apply.
+	db.transactions = append(db.transactions, models.Transaction{
+		ID: "swiggy-late", Vendor: "SWIGGY", Category: "Other", Amount: models.FromRupees(300), DateTime: time.Now().UTC().Add(-time.Minute),
+	})
+	result, err := service.Run(time.Time{}, time.Now(), false, false, preview.PreviewToken)
+	if !errors.Is(err, ErrRecategorizePreviewStale) {
+		t.Fatalf("expected a stale preview error, got %v", err)
+	}
+	if result.Changed != 0 || len(db.batches) != 0 || db.transactions[0].Category != "Other" {
+		t.Fatalf("expected nothing to be written, got %+v", result)
+	}
+}
@@ -0,0 +1,467 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+// reconcileWindow is how far apart two alerts for the same payment may be.
+// Bank alert emails usually land within a few minutes of the UPI/Google Pay row.
+const reconcileWindow = 30 * time.Minute
+
+type ReconciliationService struct {
+	dbClient models.DatabaseClient
+	store    models.ReconciliationStore
+}
+
+type ReconciliationSummary struct {
+	Scanned         int `json:"scanned"`
+	Merged          int `json:"merged"`
+	QueuedForReview int `json:"queued_for_review"`
+}
+
+type ReconciliationReviewItem struct {
+	Match     models.ReconciliationMatch `json:"match"`
+	Canonical *models.Transaction        `json:"canonical"`
+	Duplicate *models.Transaction        `json:"duplicate"`
+}
+
+type duplicateMatch int
+
+const (
+	noMatch duplicateMatch = iota
+	possibleMatch
+	confirmedMatch
+)
+
+func NewReconciliationService(dbClient models.DatabaseClient, store models.ReconciliationStore) *ReconciliationService {
+	return &ReconciliationService{dbClient: dbClient, store: store}
+}
+
+// ReconcilePeriod runs Reconcile over a named period such as THIS_MONTH.
+func (s *ReconciliationService) ReconcilePeriod(period string) (ReconciliationSummary, error) {
+	start, end, err := utils.ResolvePeriod(normalizePeriod(period))
+	if err != nil {
+		return ReconciliationSummary{}, err
+	}
+	return s.Reconcile(start, end)
+}
+
+// Reconcile looks for the same payment recorded by different sources. A pair
+// whose accounts or VPAs agree and has no competing candidate is merged
+// straight away; everything else is queued for review.
+func (s *ReconciliationService) Reconcile(from, to time.Time) (ReconciliationSummary, error) {
+	all, err := s.dbClient.FetchTransactionsByDateRange(from, to)
+	if err != nil {
+		return ReconciliationSummary{}, err
+	}
+
+	txs := make([]models.Transaction, 0, len(all))
+	for _, tx := range all {
+		if tx.ID != "" && !tx.IsDuplicate() && !tx.IsTrashed() {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].DateTime.Before(txs[j].DateTime)
+	})
+
+	summary := ReconciliationSummary{Scanned: len(txs)}
+	linked := make(map[string]bool)
+	queued := make(map[string]bool)
+
+	for i := range txs {
+		if linked[txs[i].ID] {
+			continue
+		}
+
+		candidates, confirmed := duplicateCandidates(txs, i, linked)
+		if len(candidates) == 0 {
+			continue
+		}
+
+		// Merge only a one-to-one confirmed match: the other row must not
+		// match anything else either, or the ambiguity goes to review.
+		ambiguity := len(candidates)
+		if len(candidates) == 1 && confirmed == 1 {
+			reverse, _ := duplicateCandidates(txs, candidates[0], linked)
+			ambiguity = len(reverse)
+		}
+		if ambiguity == 1 && confirmed == 1 {
+			canonical, duplicate := pickCanonical(txs[i], txs[candidates[0]])
+			if err := s.merge(canonical, duplicate); err != nil {
+				return summary, err
+			}
+			linked[canonical.ID] = true
+			linked[duplicate.ID] = true
+			summary.Merged++
+			continue
+		}
+
+		for _, j := range candidates {
+			canonical, duplicate := pickCanonical(txs[i], txs[j])
+			matchID := canonical.ID + "_" + duplicate.ID
+			if queued[matchID] {
+				continue
+			}
+			queued[matchID] = true
+
+			match := models.ReconciliationMatch{
+				ID:          matchID,
+				CanonicalID: canonical.ID,
+				DuplicateID: duplicate.ID,
+				Amount:      canonical.Amount,
+				TimeDelta:   absDuration(canonical.DateTime.Sub(duplicate.DateTime)).String(),
+				Reason:      reviewReason(matchDuplicate(canonical, duplicate), ambiguity),
+				Status:      models.ReconciliationPending,
+				CreatedAt:   time.Now().UTC(),
+			}
+			if err := s.store.SaveReconciliationMatch(match); err != nil {
+				return summary, err
+			}
+			summary.QueuedForReview++
+		}
+	}
+
+	// Matches queued by earlier runs for rows merged now are settled.
+	if err := s.dismissOtherMatches("", linked); err != nil {
+		return summary, err
+	}
+
+	log.Printf("reconciliation completed scanned=%d merged=%d queued=%d", summary.Scanned, summary.Merged, summary.QueuedForReview)
+	return summary, nil
+}
+
+// ListReview returns matches with the given status alongside both transactions.
+func (s *ReconciliationService) ListReview(status string) ([]ReconciliationReviewItem, error) {
+	matches, err := s.store.ListReconciliationMatches(status)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].CreatedAt.After(matches[j].CreatedAt)
+	})
+
+	items := make([]ReconciliationReviewItem, 0, len(matches))
+	for _, match := range matches {
+		item := ReconciliationReviewItem{Match: match}
+		if tx, err := s.dbClient.GetTransaction(match.CanonicalID); err == nil {
+			item.Canonical = tx
+		}
+		if tx, err := s.dbClient.GetTransaction(match.DuplicateID); err == nil {
+			item.Duplicate = tx
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// ResolveMatch applies a review decision: "merge" links the pair and closes
+// the other pending matches of both transactions, "dismiss" keeps both
+// transactions and closes the match. A pair that is trashed or already
+// linked cannot be merged.
+func (s *ReconciliationService) ResolveMatch(id, action string) error {
+	match, err := s.store.GetReconciliationMatch(id)
+	if err != nil {
+		return err
+	}
+	if match.Status != models.ReconciliationPending {
+		return fmt.Errorf("reconciliation match %s is already %s", id, match.Status)
+	}
+
+	switch action {
+	case "merge":
+		canonical, err := s.dbClient.GetTransaction(match.CanonicalID)
+		if err != nil {
+			return err
+		}
+		duplicate, err := s.dbClient.GetTransaction(match.DuplicateID)
+		if err != nil {
+			return err
+		}
+		if canonical.IsTrashed() || duplicate.IsTrashed() {
+			return fmt.Errorf("cannot merge reconciliation match %s: a transaction is in the trash", id)
+		}
+		if canonical.IsDuplicate() || duplicate.IsDuplicate() {
+			return fmt.Errorf("cannot merge reconciliation match %s: a transaction is already linked as a duplicate", id)
+		}
+		if err := s.merge(*canonical, *duplicate); err != nil {
+			return err
+		}
+		if err := s.store.UpdateReconciliationMatchStatus(id, models.ReconciliationMerged); err != nil {
+			return err
+		}
+		return s.dismissOtherMatches(id, map[string]bool{canonical.ID: true, duplicate.ID: true})
+	case "dismiss":
+		return s.store.UpdateReconciliationMatchStatus(id, models.ReconciliationDismissed)
+	default:
+		return fmt.Errorf("unsupported action %q, expected merge or dismiss", action)
+	}
+}
+
+// merge fills gaps in the canonical transaction from its duplicate in one
+// versioned write and links the duplicate to it so reporting counts the
+// payment once. Anything already linked to the duplicate moves to the
+// canonical transaction, so links never chain.
+func (s *ReconciliationService) merge(canonical, duplicate models.Transaction) error {
+	var patch models.TransactionPatch
+	changed := false
+	fill := func(field **string, current, value string) {
+		if current == "" && value != "" {
+			*field = &value
+			changed = true
+		}
+	}
+	fill(&patch.Vendor, canonical.Vendor, duplicate.Vendor)
+	fill(&patch.Merchant, canonical.Merchant, duplicate.Merchant)
+	fill(&patch.CardEnding, canonical.CardEnding, duplicate.CardEnding)
+	fill(&patch.DebitedAccount, canonical.DebitedAccount, duplicate.DebitedAccount)
+	fill(&patch.CreditedAccount, canonical.CreditedAccount, duplicate.CreditedAccount)
+	fill(&patch.Notes, canonical.Notes, duplicate.Notes)
+	if canonical.Category == "Other" && duplicate.Category != "" && duplicate.Category != "Other" {
+		patch.Category = &duplicate.Category
+		changed = true
+	} else {
+		fill(&patch.Category, canonical.Category, duplicate.Category)
+	}
+
+	var missingTags []string
+	for _, tag := range duplicate.Tags {
+		if !canonical.HasTag(tag) {
+			missingTags = append(missingTags, tag)
+		}
+	}
+	if tagger, ok := s.dbClient.(models.TagStore); ok && len(missingTags) > 0 {
+		if err := NewTagService(s.dbClient, tagger).EnsureTags(missingTags); err != nil {
+			return err
+		}
+		tags := append(append([]string{}, canonical.Tags...), missingTags...)
+		patch.Tags = &tags
+		changed = true
+	}
+
+	if changed {
+		enriched, err := s.dbClient.PatchTransaction(canonical.ID, patch, canonical.Version)
+		if err != nil {
+			return err
+		}
+		RecordTransactionChange(s.dbClient, canonical, *enriched, "", models.AuditSourceSystem, models.AuditActionMerge)
+	}
+
+	linked, err := s.store.ListDuplicatesOf(duplicate.ID)
+	if err != nil {
+		return err
+	}
+	for _, tx := range linked {
+		if err := s.store.MarkDuplicate(tx.ID, canonical.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.store.MarkDuplicate(duplicate.ID, canonical.ID); err != nil {
+		return err
+	}
+
+	log.Printf("reconciliation merged duplicate=%s canonical=%s amount=%s", duplicate.ID, canonical.ID, canonical.Amount)
+	return nil
+}
+
+// dismissOtherMatches closes the pending matches other than resolvedID that
+// involve any of ids, since those transactions are now linked.
+func (s *ReconciliationService) dismissOtherMatches(resolvedID string, ids map[string]bool) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	pending, err := s.store.ListReconciliationMatches(models.ReconciliationPending)
+	if err != nil {
+		return err
+	}
+	for _, match := range pending {
+		if match.ID == resolvedID || (!ids[match.CanonicalID] && !ids[match.DuplicateID]) {
+			continue
+		}
+		if err := s.store.UpdateReconciliationMatchStatus(match.ID, models.ReconciliationDismissed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// promoteDuplicates keeps a payment counted when its canonical transaction
+// is trashed or purged. The most detailed live duplicate linked to
+// canonicalID becomes canonical and the other duplicates are linked to it;
+// with no live duplicate left, every link is cleared.
+func promoteDuplicates(dbClient models.DatabaseClient, canonicalID string) error {
+	store, ok := dbClient.(models.ReconciliationStore)
+	if !ok {
+		return nil
+	}
+	duplicates, err := store.ListDuplicatesOf(canonicalID)
+	if err != nil {
+		return err
+	}
+
+	var promoted *models.Transaction
+	for i := range duplicates {
+		if duplicates[i].IsTrashed() {
+			continue
+		}
+		if promoted == nil {
+			promoted = &duplicates[i]
+		} else if best, _ := pickCanonical(*promoted, duplicates[i]); best.ID != promoted.ID {
+			promoted = &duplicates[i]
+		}
+	}
+	for _, tx := range duplicates {
+		link := ""
+		if promoted != nil && tx.ID != promoted.ID {
+			link = promoted.ID
+		}
+		if err := store.MarkDuplicate(tx.ID, link); err != nil {
+			return err
+		}
+	}
+	if promoted != nil {
+		log.Printf("reconciliation promoted duplicate=%s replacing=%s relinked=%d", promoted.ID, canonicalID, len(duplicates)-1)
+	}
+	return nil
+}
+
+// matchDuplicate decides whether two transactions from different sources
+// could be the same payment. Agreeing accounts make it a confirmed match,
+// disagreeing accounts rule it out.
+func matchDuplicate(a, b models.Transaction) duplicateMatch {
+	if a.Type == b.Type || a.Amount != b.Amount {
+		return noMatch
+	}
+
+	manual := a.Type == "Manual" || b.Type == "Manual"
+	if manual {
+		// Manual entries are often entered without a time, so compare calendar days.
+		if utils.DayKey(a.DateTime) != utils.DayKey(b.DateTime) {
+			return noMatch
+		}
+	} else if absDuration(a.DateTime.Sub(b.DateTime)) > reconcileWindow {
+		return noMatch
+	}
+
+	sourceA := accountRefs(a.CardEnding, a.DebitedAccount)
+	sourceB := accountRefs(b.CardEnding, b.DebitedAccount)
+	payeeA := accountRefs(a.CreditedAccount)
+	payeeB := accountRefs(b.CreditedAccount)
+
+	confirmed := false
+	for _, pair := range [][2][]string{{sourceA, sourceB}, {payeeA, payeeB}} {
+		if len(pair[0]) == 0 || len(pair[1]) == 0 {
+			continue
+		}
+		if !sharesRef(pair[0], pair[1]) {
+			return noMatch
+		}
+		confirmed = true
+	}
+
+	if confirmed && !manual {
+		return confirmedMatch
+	}
+	return possibleMatch
+}
+
+// accountRefs normalizes account identifiers: VPAs are compared whole, masked
+// account and card numbers by their last four digits.
+func accountRefs(values ...string) []string {
+	var refs []string
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if strings.Contains(value, "@") {
+			refs = append(refs, value)
+			continue
+		}
+		digits := strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, value)
+		if len(digits) >= 4 {
+			refs = append(refs, digits[len(digits)-4:])
+		}
+	}
+	return refs
+}
+
+func sharesRef(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// pickCanonical keeps the record from the most detailed source: bank and
+// card alerts over Google Pay rows, Google Pay rows over manual entries.
+func pickCanonical(a, b models.Transaction) (models.Transaction, models.Transaction) {
+	rankA, rankB := sourceRank(a.Type), sourceRank(b.Type)
+	if rankA > rankB || (rankA == rankB && !b.DateTime.Before(a.DateTime)) {
+		return a, b
+	}
+	return b, a
+}
+
+func sourceRank(txType string) int {
+	switch txType {
+	case "Manual":
+		return 0
+	case GooglePayTransactionType:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// duplicateCandidates returns the unlinked rows that may duplicate txs[i]
+// and how many of them are confirmed by account details.
+func duplicateCandidates(txs []models.Transaction, i int, linked map[string]bool) ([]int, int) {
+	var candidates []int
+	confirmed := 0
+	for j := range txs {
+		if i == j || linked[txs[j].ID] {
+			continue
+		}
+		kind := matchDuplicate(txs[i], txs[j])
+		if kind == noMatch {
+			continue
+		}
+		candidates = append(candidates, j)
+		if kind == confirmedMatch {
+			confirmed++
+		}
+	}
+	return candidates, confirmed
+}
+
+func reviewReason(kind duplicateMatch, candidates int) string {
+	if candidates > 1 {
+		return fmt.Sprintf("%d transactions match the same amount and time window", candidates)
+	}
+	if kind == confirmedMatch {
+		return "accounts match"
+	}
+	return "same amount and time, no account details to confirm"
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
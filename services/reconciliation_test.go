@@ -0,0 +1,174 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+func TestReconcileMergesGooglePayRowIntoBankAlert(t *testing.T) {
+	paidAt := time.Date(2026, 4, 19, 8, 31, 30, 0, time.UTC)
+	db := newTestDB(
+		models.Transaction{
+			ID:             "gpay-1",
+			Type:           GooglePayTransactionType,
+			Amount:         models.FromRupees(1100),
+			Vendor:         "RAMESHWARAM ENTERPRISES",
+			Category:       "Food",
+			DateTime:       paidAt,
+			DebitedAccount: "XXXXXXXX1234",
+		},
+		models.Transaction{
+			ID:              "bank-1",
+			Type:            "BankTransfer",
+			Amount:          models.FromRupees(1100),
+			DateTime:        paidAt.Add(4 * time.Minute),
+			DebitedAccount:  "XX1234",
+			CreditedAccount: "",
+		},
+	)
+
+	reconciler := NewReconciliationService(db, db)
+	summary, err := reconciler.Reconcile(paidAt.Add(-time.Hour), paidAt.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+
+	if summary.Merged != 1 || summary.QueuedForReview != 0 {
+		t.Fatalf("expected 1 merge and no review items, got %+v", summary)
+	}
+	if duplicateOf := db.find("gpay-1").DuplicateOf; duplicateOf != "bank-1" {
+		t.Fatalf("expected Google Pay row to be linked to bank alert, got %q", duplicateOf)
+	}
+
+	canonical := db.find("bank-1")
+	if canonical.Version == 0 {
+		t.Fatalf("expected canonical transaction to be enriched")
+	}
+	if canonical.Vendor != "RAMESHWARAM ENTERPRISES" || canonical.Category != "Food" {
+		t.Fatalf("expected vendor and category to be copied, got %q / %q", canonical.Vendor, canonical.Category)
+	}
+	if len(db.changes) != 1 || db.changes[0].Action != models.AuditActionMerge || db.changes[0].Version != canonical.Version {
+		t.Fatalf("expected the enrichment in the change log, got %+v", db.changes)
+	}
+}
+
+func TestReconcileQueuesManualEntryForReview(t *testing.T) {
+	day := time.Date(2026, 4, 19, 0, 0, 0, 0, utils.UserLocation())
+	db := newTestDB(
+		models.Transaction{ID: "manual-1", Type: "Manual", Amount: models.FromRupees(450), Vendor: "Pizza", DateTime: day},
+		models.Transaction{ID: "card-1", Type: "HDFCCreditCard", Amount: models.FromRupees(450), Vendor: "DOMINOS", CardEnding: "4207", DateTime: day.Add(20 * time.Hour)},
+	)
+
+	reconciler := NewReconciliationService(db, db)
+	summary, err := reconciler.Reconcile(day, day.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+
+	if summary.Merged != 0 || summary.QueuedForReview != 1 {
+		t.Fatalf("expected 1 review item and no merges, got %+v", summary)
+	}
+	match, ok := db.matches["card-1_manual-1"]
+	if !ok {
+		t.Fatalf("expected card alert to be canonical, got %v", db.matches)
+	}
+
+	if err := reconciler.ResolveMatch(match.ID, "merge"); err != nil {
+		t.Fatalf("ResolveMatch returned error: %v", err)
+	}
+	if duplicateOf := db.find("manual-1").DuplicateOf; duplicateOf != "card-1" {
+		t.Fatalf("expected manual entry to be linked after merge, got %q", duplicateOf)
+	}
+	if db.matches[match.ID].Status != models.ReconciliationMerged {
+		t.Fatalf("expected match status merged, got %q", db.matches[match.ID].Status)
+	}
+}
+
+func TestReconcileIgnoresDifferentAccounts(t *testing.T) {
+	paidAt := time.Date(2026, 4, 19, 8, 31, 30, 0, time.UTC)
+	db := newTestDB(
+		models.Transaction{ID: "gpay-1", Type: GooglePayTransactionType, Amount: models.FromRupees(500), DateTime: paidAt, DebitedAccount: "XXXXXXXX1234"},
+		models.Transaction{ID: "bank-1", Type: "BankTransfer", Amount: models.FromRupees(500), DateTime: paidAt, DebitedAccount: "XX9876"},
+	)
+
+	summary, err := NewReconciliationService(db, db).Reconcile(paidAt.Add(-time.Hour), paidAt.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if summary.Merged != 0 || summary.QueuedForReview != 0 {
+		t.Fatalf("expected no matches for different accounts, got %+v", summary)
+	}
+}
+
+func TestReconcileQueuesMatchThatIsNotOneToOne(t *testing.T) {
+	paidAt := time.Date(2026, 4, 19, 8, 31, 30, 0, time.UTC)
+	// gpay-1 only matches bank-1, but bank-1 also matches gpay-2.
+	db := newTestDB(
+		models.Transaction{ID: "gpay-1", Type: GooglePayTransactionType, Amount: models.FromRupees(500), DateTime: paidAt, DebitedAccount: "XXXXXXXX1234"},
+		models.Transaction{ID: "bank-1", Type: "BankTransfer", Amount: models.FromRupees(500), DateTime: paidAt.Add(4 * time.Minute), DebitedAccount: "XX1234"},
+		models.Transaction{ID: "gpay-2", Type: GooglePayTransactionType, Amount: models.FromRupees(500), DateTime: paidAt.Add(8 * time.Minute), DebitedAccount: "XXXXXXXX1234"},
+	)
+
+	summary, err := NewReconciliationService(db, db).Reconcile(paidAt.Add(-time.Hour), paidAt.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if summary.Merged != 0 || summary.QueuedForReview != 2 {
+		t.Fatalf("expected both pairs queued and nothing merged, got %+v", summary)
+	}
+	for _, tx := range db.transactions {
+		if tx.IsDuplicate() {
+			t.Fatalf("expected nothing to be linked, got %s linked to %s", tx.ID, tx.DuplicateOf)
+		}
+	}
+	for _, id := range []string{"bank-1_gpay-1", "bank-1_gpay-2"} {
+		if match, ok := db.matches[id]; !ok || match.Reason != "2 transactions match the same amount and time window" {
+			t.Fatalf("expected %s queued as ambiguous, got %+v", id, db.matches)
+		}
+	}
+}
+
+func TestResolveMatchClosesSiblingsAndRefusesLinkedRows(t *testing.T) {
+	day := time.Date(2026, 4, 19, 0, 0, 0, 0, utils.UserLocation())
+	deletedAt := day
+	db := newTestDB(
+		models.Transaction{ID: "card-1", Type: "HDFCCreditCard", Amount: models.FromRupees(450), DateTime: day},
+		models.Transaction{ID: "manual-1", Type: "Manual", Amount: models.FromRupees(450), DateTime: day},
+		models.Transaction{ID: "manual-2", Type: "Manual", Amount: models.FromRupees(450), DateTime: day},
+		models.Transaction{ID: "gpay-1", Type: GooglePayTransactionType, Amount: models.FromRupees(450), DateTime: day},
+		models.Transaction{ID: "gpay-2", Type: GooglePayTransactionType, Amount: models.FromRupees(450), DateTime: day, DeletedAt: &deletedAt},
+	)
+	for _, match := range []models.ReconciliationMatch{
+		{ID: "card-1_manual-1", CanonicalID: "card-1", DuplicateID: "manual-1", Status: models.ReconciliationPending},
+		{ID: "card-1_manual-2", CanonicalID: "card-1", DuplicateID: "manual-2", Status: models.ReconciliationPending},
+		{ID: "gpay-1_manual-1", CanonicalID: "gpay-1", DuplicateID: "manual-1", Status: models.ReconciliationPending},
+		{ID: "gpay-2_manual-2", CanonicalID: "gpay-2", DuplicateID: "manual-2", Status: models.ReconciliationPending},
+	} {
+		db.matches[match.ID] = match
+	}
+	reconciler := NewReconciliationService(db, db)
+
+	if err := reconciler.ResolveMatch("gpay-2_manual-2", "merge"); err == nil || !strings.Contains(err.Error(), "trash") {
+		t.Fatalf("expected a trashed transaction to block the merge, got %v", err)
+	}
+	if err := reconciler.ResolveMatch("card-1_manual-1", "merge"); err != nil {
+		t.Fatalf("ResolveMatch returned error: %v", err)
+	}
+	for _, id := range []string{"card-1_manual-2", "gpay-1_manual-1"} {
+		if status := db.matches[id].Status; status != models.ReconciliationDismissed {
+			t.Fatalf("expected %s to be dismissed after the merge, got %q", id, status)
+		}
+	}
+
+	db.matches["gpay-1_manual-1"] = models.ReconciliationMatch{ID: "gpay-1_manual-1", CanonicalID: "gpay-1", DuplicateID: "manual-1", Status: models.ReconciliationPending}
+	if err := reconciler.ResolveMatch("gpay-1_manual-1", "merge"); err == nil || !strings.Contains(err.Error(), "already linked") {
+		t.Fatalf("expected an already linked transaction to block a second merge, got %v", err)
+	}
+	if db.find("manual-1").DuplicateOf != "card-1" {
+		t.Fatalf("expected manual-1 to stay linked to card-1, got %q", db.find("manual-1").DuplicateOf)
+	}
+}
@@ -14,148 +14,226 @@ type ReportingService struct {
 }
 
 type TotalSummary struct {
-	Period             string  `json:"period"`
-	TransactionCount   int     `json:"transaction_count"`
-	TotalAmount        float64 `json:"total_amount"`
-	GrossExpense       float64 `json:"gross_expense"`
-	CreditAmount       float64 `json:"credit_amount"`
-	AverageAmount      float64 `json:"average_amount"`
-	UncategorizedCount int     `json:"uncategorized_count"`
+	Period             string       `json:"period"`
+	TransactionCount   int          `json:"transaction_count"`
+	TotalAmount        models.Money `json:"total_amount"`
+	GrossExpense       models.Money `json:"gross_expense"`
+	CreditAmount       models.Money `json:"credit_amount"`
+	AverageAmount      models.Money `json:"average_amount"`
+	UncategorizedCount int          `json:"uncategorized_count"`
+	PendingFXCount     int          `json:"pending_fx_count"`
+	Currency           string       `json:"currency"`
+	// Timezone is the zone the period was resolved in, e.g. "Asia/Kolkata".
+	Timezone string `json:"timezone"`
 }
 
 type BreakdownItem struct {
-	Label  string  `json:"label"`
-	Amount float64 `json:"amount"`
-	Count  int     `json:"count"`
+	Label  string       `json:"label"`
+	Amount models.Money `json:"amount"`
+	Count  int          `json:"count"`
+	// Path, HasChildren and Children are set for category rollups: Path runs
+	// from the top-level category to this one, and HasChildren tells whether
+	// the category can be drilled into.
+	Path        []string        `json:"path,omitempty"`
+	HasChildren bool            `json:"has_children,omitempty"`
+	Children    []BreakdownItem `json:"children,omitempty"`
 }
 
 type TrendPoint struct {
-	Date   string  `json:"date"`
-	Amount float64 `json:"amount"`
-	Count  int     `json:"count"`
+	Date   string       `json:"date"`
+	Amount models.Money `json:"amount"`
+	Count  int          `json:"count"`
 }
 
 type MonthlyComparison struct {
-	CurrentMonthAmount   float64 `json:"current_month_amount"`
-	LastMonthAmount      float64 `json:"last_month_amount"`
-	CurrentMonthCount    int     `json:"current_month_count"`
-	LastMonthCount       int     `json:"last_month_count"`
-	DeltaAmount          float64 `json:"delta_amount"`
-	DeltaPercent         float64 `json:"delta_percent"`
-	TopMerchantThisMonth string  `json:"top_merchant_this_month"`
-	TopMerchantSpend     float64 `json:"top_merchant_spend"`
+	CurrentMonthAmount   models.Money `json:"current_month_amount"`
+	LastMonthAmount      models.Money `json:"last_month_amount"`
+	CurrentMonthCount    int          `json:"current_month_count"`
+	LastMonthCount       int          `json:"last_month_count"`
+	DeltaAmount          models.Money `json:"delta_amount"`
+	DeltaPercent         float64      `json:"delta_percent"`
+	TopMerchantThisMonth string       `json:"top_merchant_this_month"`
+	TopMerchantSpend     models.Money `json:"top_merchant_spend"`
+	Timezone             string       `json:"timezone"`
 }
 
-func NewReportingService(dbClient models.DatabaseClient) *ReportingService {
-	return &ReportingService{dbClient: dbClient}
+// TransactionFilter narrows transaction listings. Empty fields match everything.
+type TransactionFilter struct {
+	// Category also matches its subcategories.
+	Category string
+	// Vendor is a case-insensitive substring of the vendor or canonical
+	// merchant name.
+	Vendor string
+	Type   string
+	// Query is matched case-insensitively against vendor, notes and the raw
+	// source text.
+	Query string
+	// Tags must all be present on a transaction for it to match.
+	Tags []string
+	// MinAmount and MaxAmount bound the amount, inclusive. Credits are
+	// negative.
+	MinAmount *models.Money
+	MaxAmount *models.Money
+	// Search is a parsed search query; see ParseSearchQuery.
+	Search *SearchQuery
+	// SavedSearch names a saved search, by ID or name, whose query is
+	// applied along with Search.
+	SavedSearch string
+
+	tree  *CategoryTree
+	saved *SearchQuery
 }
 
-func (s *ReportingService) ListTransactions(period string, category string, limit int) ([]models.Transaction, error) {
-	txs, err := s.filteredTransactions(period)
-	if err != nil {
-		return nil, err
+func (f TransactionFilter) Matches(tx models.Transaction) bool {
+	if category := strings.TrimSpace(f.Category); category != "" {
+		matched := false
+		for _, line := range tx.CategoryLines() {
+			if f.tree.Contains(category, line.Category) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
 	}
 
-	category = strings.TrimSpace(category)
-	if category != "" {
-		filteredByCategory := make([]models.Transaction, 0, len(txs))
-		for _, tx := range txs {
-			txCategory := strings.TrimSpace(tx.Category)
-			if txCategory == "" {
-				txCategory = "Other"
-			}
-			if strings.EqualFold(txCategory, category) {
-				filteredByCategory = append(filteredByCategory, tx)
-			}
+	if vendor := strings.ToLower(strings.TrimSpace(f.Vendor)); vendor != "" &&
+		!strings.Contains(strings.ToLower(tx.Vendor), vendor) && !strings.Contains(strings.ToLower(tx.Merchant), vendor) {
+		return false
+	}
+	if txType := strings.TrimSpace(f.Type); txType != "" && !strings.EqualFold(tx.Type, txType) {
+		return false
+	}
+
+	if query := strings.ToLower(strings.TrimSpace(f.Query)); query != "" {
+		if !strings.Contains(strings.ToLower(tx.Vendor), query) &&
+			!strings.Contains(strings.ToLower(tx.Notes), query) &&
+			!strings.Contains(strings.ToLower(tx.RawText), query) {
+			return false
 		}
-		txs = filteredByCategory
 	}
 
-	sort.Slice(txs, func(i, j int) bool {
-		return txs[i].DateTime.After(txs[j].DateTime)
-	})
+	for _, tag := range f.Tags {
+		if !tx.HasTag(tag) {
+			return false
+		}
+	}
 
-	if limit > 0 && len(txs) > limit {
-		txs = txs[:limit]
+	if f.MinAmount != nil && tx.Amount < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && tx.Amount > *f.MaxAmount {
+		return false
+	}
+
+	return f.Search.Matches(tx, f.tree) && f.saved.Matches(tx, f.tree)
+}
+
+// MerchantName is the merchant a transaction's spend is grouped under: the
+// stored canonical merchant, or the vendor resolved through the registry for
+// transactions saved before merchants existed.
+func (s *ReportingService) MerchantName(tx models.Transaction) string {
+	if tx.Merchant != "" {
+		return tx.Merchant
+	}
+	return CanonicalMerchant(tx.Vendor, s.dbClient)
+}
+
+// CategoryAmount returns the part of a transaction's amount that falls in the
+// given category, taking split lines into account.
+func CategoryAmount(tx models.Transaction, category string) models.Money {
+	category = strings.TrimSpace(category)
+	var total models.Money
+	for _, line := range tx.CategoryLines() {
+		if strings.EqualFold(strings.TrimSpace(line.Category), category) {
+			total += line.Amount
+		}
 	}
+	return total
+}
+
+func NewReportingService(dbClient models.DatabaseClient) *ReportingService {
+	return &ReportingService{dbClient: dbClient}
+}
+
+// CategoryTree returns the stored category tree; it is nil, and flat, for
+// backends without one.
+func (s *ReportingService) CategoryTree() *CategoryTree {
+	return categoryTree(s.dbClient)
+}
 
-	return txs, nil
+func (s *ReportingService) ListTransactions(period string, filter TransactionFilter, limit int) ([]models.Transaction, error) {
+	page, err := s.ListTransactionsPage(period, filter, PageRequest{Limit: limit})
+	return page.Transactions, err
 }
 
 func (s *ReportingService) GetTotalSummary(period string) (TotalSummary, error) {
-	txs, err := s.filteredTransactions(period)
+	groups, err := s.aggregatePeriod(period, models.AggregateByNone)
 	if err != nil {
 		return TotalSummary{}, err
 	}
 
-	summary := TotalSummary{Period: normalizePeriod(period)}
-	for _, tx := range txs {
-		summary.TransactionCount++
-		if tx.Amount < 0 {
-			summary.CreditAmount += -tx.Amount
-		} else {
-			summary.GrossExpense += tx.Amount
-		}
-		if strings.TrimSpace(tx.Category) == "" || strings.EqualFold(tx.Category, "Other") {
-			summary.UncategorizedCount++
-		}
+	summary := TotalSummary{Period: normalizePeriod(period), Currency: models.BaseCurrency, Timezone: utils.UserLocation().String()}
+	for _, group := range groups {
+		summary.TransactionCount += group.Count
+		summary.GrossExpense += group.Expense
+		summary.CreditAmount += group.Credit
+		summary.UncategorizedCount += group.Uncategorized
+		summary.PendingFXCount += group.PendingFX
 	}
 	summary.TotalAmount = summary.GrossExpense - summary.CreditAmount
 	if summary.TransactionCount > 0 {
-		summary.AverageAmount = summary.GrossExpense / float64(summary.TransactionCount)
+		summary.AverageAmount = summary.GrossExpense.DivRound(summary.TransactionCount)
 	}
 
 	return summary, nil
 }
 
+// GetCategoryBreakdown totals spend per top-level category, including its
+// subcategories and counting split transactions once for every category line.
 func (s *ReportingService) GetCategoryBreakdown(period string) ([]BreakdownItem, error) {
-	return s.groupBreakdown(period, func(tx models.Transaction) string {
-		if tx.Category == "" {
-			return "Other"
-		}
-		return tx.Category
-	})
-}
-
-func (s *ReportingService) GetSourceBreakdown(period string) ([]BreakdownItem, error) {
-	return s.groupBreakdown(period, func(tx models.Transaction) string {
-		if strings.HasPrefix(tx.Type, "HDFC") {
-			return "HDFC"
-		}
-		if tx.Type == "" {
-			return "Unknown"
-		}
-		return tx.Type
-	})
+	return s.GetCategoryRollup(period, "", 1)
 }
 
-func (s *ReportingService) GetDailyTrend(period string) ([]TrendPoint, error) {
-	txs, err := s.filteredTransactions(period)
+// GetCategoryRollup drills into parent (the top level when empty), returning
+// its subcategories with up to depth levels of children, or every level when
+// depth is 0.
+func (s *ReportingService) GetCategoryRollup(period, parent string, depth int) ([]BreakdownItem, error) {
+	groups, err := s.aggregatePeriod(period, models.AggregateByCategory)
 	if err != nil {
 		return nil, err
 	}
+	return s.CategoryRollup(groups, parent, depth), nil
+}
 
-	byDay := make(map[string]*TrendPoint)
-	for _, tx := range txs {
-		day := tx.DateTime.Format("2006-01-02")
-		point, exists := byDay[day]
-		if !exists {
-			point = &TrendPoint{Date: day}
-			byDay[day] = point
-		}
-		point.Amount += tx.Amount
-		point.Count++
-	}
+// CategoryRollup is GetCategoryRollup over category aggregates already
+// loaded, e.g. by Aggregate with tags.
+func (s *ReportingService) CategoryRollup(groups []models.TransactionAggregate, parent string, depth int) []BreakdownItem {
+	return rollupCategories(groups, s.CategoryTree(), parent, depth)
+}
 
-	points := make([]TrendPoint, 0, len(byDay))
-	for _, point := range byDay {
-		points = append(points, *point)
-	}
-	sort.Slice(points, func(i, j int) bool {
-		return points[i].Date < points[j].Date
-	})
+// GetTagBreakdown totals spend per tag. A transaction with several tags counts
+// towards each of them, so the items do not add up to the period total.
+// Untagged transactions are left out.
+func (s *ReportingService) GetTagBreakdown(period string) ([]BreakdownItem, error) {
+	return s.groupBreakdown(period, models.AggregateByTag, func(tag string) string { return tag })
+}
 
-	return points, nil
+func (s *ReportingService) GetSourceBreakdown(period string) ([]BreakdownItem, error) {
+	return s.groupBreakdown(period, models.AggregateByType, sourceLabel)
+}
+
+// sourceLabel groups transaction types into sources, e.g. every HDFC type
+// under "HDFC".
+func sourceLabel(txType string) string {
+	if strings.HasPrefix(txType, "HDFC") {
+		return "HDFC"
+	}
+	if txType == "" {
+		return "Unknown"
+	}
+	return txType
 }
 
 func (s *ReportingService) GetLastNDaysTrend(days int) ([]TrendPoint, error) {
@@ -163,35 +241,23 @@ func (s *ReportingService) GetLastNDaysTrend(days int) ([]TrendPoint, error) {
 		days = 10
 	}
 
-	cutoff := time.Now().UTC().AddDate(0, 0, -(days - 1))
-	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
-
-	txs, err := s.dbClient.FetchTransactionsByDateRange(cutoff, time.Now().UTC())
+	groups, err := s.Aggregate(models.AggregateQuery{From: lastNDaysCutoff(days), To: time.Now(), GroupBy: models.AggregateByDay})
 	if err != nil {
 		return nil, err
 	}
+	return trendPoints(groups), nil
+}
 
-	byDay := make(map[string]*TrendPoint)
-	for _, tx := range txs {
-		day := tx.DateTime.UTC().Format("2006-01-02")
-		point, exists := byDay[day]
-		if !exists {
-			point = &TrendPoint{Date: day}
-			byDay[day] = point
-		}
-		point.Amount += tx.Amount
-		point.Count++
-	}
-
-	points := make([]TrendPoint, 0, len(byDay))
-	for _, point := range byDay {
-		points = append(points, *point)
+// trendPoints turns day aggregates into trend points, oldest first.
+func trendPoints(groups []models.TransactionAggregate) []TrendPoint {
+	points := make([]TrendPoint, 0, len(groups))
+	for _, group := range groups {
+		points = append(points, TrendPoint{Date: group.Key, Amount: group.Amount, Count: group.Count})
 	}
 	sort.Slice(points, func(i, j int) bool {
 		return points[i].Date < points[j].Date
 	})
-
-	return points, nil
+	return points
 }
 
 func (s *ReportingService) GetLastNDaysTransactions(days int, limit int) ([]models.Transaction, error) {
@@ -199,10 +265,9 @@ func (s *ReportingService) GetLastNDaysTransactions(days int, limit int) ([]mode
 		days = 10
 	}
 
-	cutoff := time.Now().UTC().AddDate(0, 0, -(days - 1))
-	cutoff = time.Date(cutoff.Year(), cutoff.Month(), cutoff.Day(), 0, 0, 0, 0, time.UTC)
+	cutoff := lastNDaysCutoff(days)
 
-	filtered, err := s.dbClient.FetchTransactionsByDateRange(cutoff, time.Now().UTC())
+	filtered, err := s.fetchTransactions(cutoff, time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -218,40 +283,47 @@ func (s *ReportingService) GetLastNDaysTransactions(days int, limit int) ([]mode
 	return filtered, nil
 }
 
+// lastNDaysCutoff returns midnight, in the user's time zone, of the first of
+// the last days days including today.
+func lastNDaysCutoff(days int) time.Time {
+	now := time.Now().In(utils.UserLocation())
+	return time.Date(now.Year(), now.Month(), now.Day()-(days-1), 0, 0, 0, 0, now.Location())
+}
+
 func (s *ReportingService) GetMonthlyComparison() (MonthlyComparison, error) {
-	currentMonthTxs, err := s.filteredTransactions("THIS_MONTH")
+	currentMonth, err := s.aggregatePeriod("THIS_MONTH", models.AggregateByVendor)
 	if err != nil {
 		return MonthlyComparison{}, err
 	}
 
-	lastMonthTxs, err := s.filteredTransactions("LAST_MONTH")
+	lastMonth, err := s.aggregatePeriod("LAST_MONTH", models.AggregateByNone)
 	if err != nil {
 		return MonthlyComparison{}, err
 	}
 
-	comparison := MonthlyComparison{}
-	topMerchantTotals := make(map[string]float64)
+	comparison := MonthlyComparison{Timezone: utils.UserLocation().String()}
+	topMerchantTotals := make(map[string]models.Money)
 
-	for _, tx := range currentMonthTxs {
-		comparison.CurrentMonthAmount += tx.Amount
-		comparison.CurrentMonthCount++
-		if tx.Vendor != "" && tx.Amount > 0 {
-			topMerchantTotals[tx.Vendor] += tx.Amount
+	for _, group := range currentMonth {
+		comparison.CurrentMonthAmount += group.Amount
+		comparison.CurrentMonthCount += group.Count
+		if group.Key != "" && group.Expense > 0 {
+			topMerchantTotals[s.MerchantName(models.Transaction{Vendor: group.Key, Merchant: group.Merchant})] += group.Expense
 		}
 	}
 
-	for _, tx := range lastMonthTxs {
-		comparison.LastMonthAmount += tx.Amount
-		comparison.LastMonthCount++
+	for _, group := range lastMonth {
+		comparison.LastMonthAmount += group.Amount
+		comparison.LastMonthCount += group.Count
 	}
 
 	comparison.DeltaAmount = comparison.CurrentMonthAmount - comparison.LastMonthAmount
 	if comparison.LastMonthAmount > 0 {
-		comparison.DeltaPercent = (comparison.DeltaAmount / comparison.LastMonthAmount) * 100
+		comparison.DeltaPercent = float64(comparison.DeltaAmount) / float64(comparison.LastMonthAmount) * 100
 	}
 
 	for merchant, amount := range topMerchantTotals {
-		if amount > comparison.TopMerchantSpend {
+		if amount > comparison.TopMerchantSpend || amount == comparison.TopMerchantSpend && merchant < comparison.TopMerchantThisMonth {
 			comparison.TopMerchantSpend = amount
 			comparison.TopMerchantThisMonth = merchant
 		}
@@ -260,54 +332,51 @@ func (s *ReportingService) GetMonthlyComparison() (MonthlyComparison, error) {
 	return comparison, nil
 }
 
-func (s *ReportingService) ListTransactionsByDateRange(from, to time.Time, category string, limit int) ([]models.Transaction, error) {
-	filtered, err := s.dbClient.FetchTransactionsByDateRange(from, to)
+// Aggregate totals and groups the transactions query selects, in the
+// database when the backend supports it. Unlike listings it has no row
+// limit.
+func (s *ReportingService) Aggregate(query models.AggregateQuery) ([]models.TransactionAggregate, error) {
+	if store, ok := s.dbClient.(models.AggregateStore); ok {
+		return store.AggregateTransactions(query)
+	}
+	txs, err := s.dbClient.FetchTransactionsByDateRange(query.From, query.To)
 	if err != nil {
 		return nil, err
 	}
+	return models.GroupTransactions(txs, query), nil
+}
 
-	category = strings.TrimSpace(category)
-	if category != "" {
-		byCat := filtered[:0]
-		for _, tx := range filtered {
-			txCategory := strings.TrimSpace(tx.Category)
-			if txCategory == "" {
-				txCategory = "Other"
-			}
-			if strings.EqualFold(txCategory, category) {
-				byCat = append(byCat, tx)
-			}
-		}
-		filtered = byCat
-	}
-
-	sort.Slice(filtered, func(i, j int) bool {
-		return filtered[i].DateTime.After(filtered[j].DateTime)
-	})
-
-	if limit > 0 && len(filtered) > limit {
-		filtered = filtered[:limit]
+func (s *ReportingService) aggregatePeriod(period string, groupBy models.AggregateGroup) ([]models.TransactionAggregate, error) {
+	start, end, err := utils.ResolvePeriod(normalizePeriod(period))
+	if err != nil {
+		return nil, err
 	}
+	return s.Aggregate(models.AggregateQuery{From: start, To: end, GroupBy: groupBy})
+}
 
-	return filtered, nil
+func (s *ReportingService) ListTransactionsByDateRange(from, to time.Time, filter TransactionFilter, limit int) ([]models.Transaction, error) {
+	page, err := s.ListTransactionsByDateRangePage(from, to, filter, PageRequest{Limit: limit})
+	return page.Transactions, err
 }
 
-func (s *ReportingService) groupBreakdown(period string, keyFn func(models.Transaction) string) ([]BreakdownItem, error) {
-	txs, err := s.filteredTransactions(period)
+// groupBreakdown aggregates a period by groupBy and sums the groups under
+// the labels labelFn gives their keys.
+func (s *ReportingService) groupBreakdown(period string, groupBy models.AggregateGroup, labelFn func(string) string) ([]BreakdownItem, error) {
+	groups, err := s.aggregatePeriod(period, groupBy)
 	if err != nil {
 		return nil, err
 	}
 
 	grouped := make(map[string]*BreakdownItem)
-	for _, tx := range txs {
-		key := keyFn(tx)
-		item, exists := grouped[key]
+	for _, group := range groups {
+		label := labelFn(group.Key)
+		item, exists := grouped[label]
 		if !exists {
-			item = &BreakdownItem{Label: key}
-			grouped[key] = item
+			item = &BreakdownItem{Label: label}
+			grouped[label] = item
 		}
-		item.Amount += tx.Amount
-		item.Count++
+		item.Amount += group.Amount
+		item.Count += group.Count
 	}
 
 	items := make([]BreakdownItem, 0, len(grouped))
@@ -321,12 +390,36 @@ func (s *ReportingService) groupBreakdown(period string, keyFn func(models.Trans
 	return items, nil
 }
 
-func (s *ReportingService) filteredTransactions(period string) ([]models.Transaction, error) {
-	start, end, err := utils.ResolvePeriod(normalizePeriod(period))
+// fetchTransactions loads a date range, leaving out transactions that
+// reconciliation linked to a canonical record and those in the trash.
+func (s *ReportingService) fetchTransactions(from, to time.Time) ([]models.Transaction, error) {
+	txs, err := s.dbClient.FetchTransactionsByDateRange(from, to)
 	if err != nil {
 		return nil, err
 	}
-	return s.dbClient.FetchTransactionsByDateRange(start, end)
+
+	filtered := txs[:0]
+	for _, tx := range txs {
+		if !tx.IsDuplicate() && !tx.IsTrashed() {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered, nil
+}
+
+func applyTransactionFilter(txs []models.Transaction, filter TransactionFilter) []models.Transaction {
+	filtered := txs[:0]
+	for _, tx := range txs {
+		if filter.Matches(tx) {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
+}
+
+// isUncategorized reports whether tx is still in the Other bucket.
+func isUncategorized(tx models.Transaction) bool {
+	return models.IsUncategorized(tx.Category)
 }
 
 func normalizePeriod(period string) string {
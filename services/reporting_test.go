@@ -1,10 +1,12 @@
 package services
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
 )
 
 type reportingTestDB struct {
@@ -12,9 +14,15 @@ type reportingTestDB struct {
 }
 
 func (d *reportingTestDB) SaveTransaction(txn models.Transaction) error { return nil }
+func (d *reportingTestDB) GetTransaction(id string) (*models.Transaction, error) {
+	return nil, nil
+}
 func (d *reportingTestDB) UpdateTransaction(id string, txn models.Transaction) error {
 	return nil
 }
+func (d *reportingTestDB) PatchTransaction(id string, patch models.TransactionPatch, expectedVersion int64) (*models.Transaction, error) {
+	return nil, nil
+}
 func (d *reportingTestDB) FetchTransactionsByDateRange(from, to time.Time) ([]models.Transaction, error) {
 	var filtered []models.Transaction
 	for _, tx := range d.transactions {
@@ -45,7 +53,7 @@ func TestGetTotalSummaryDeductsCredits(t *testing.T) {
 		transactions: []models.Transaction{
 			{
 				Type:           GooglePayTransactionType,
-				Amount:         1000,
+				Amount:         models.FromRupees(1000),
 				Vendor:         "Store",
 				Category:       "Shopping",
 				DateTime:       now,
@@ -53,7 +61,7 @@ func TestGetTotalSummaryDeductsCredits(t *testing.T) {
 			},
 			{
 				Type:            GooglePayTransactionType,
-				Amount:          -800,
+				Amount:          models.FromRupees(-800),
 				Vendor:          "Friend",
 				Category:        "Other",
 				DateTime:        now.Add(-time.Minute),
@@ -68,19 +76,193 @@ func TestGetTotalSummaryDeductsCredits(t *testing.T) {
 		t.Fatalf("GetTotalSummary returned error: %v", err)
 	}
 
-	if summary.TotalAmount != 200 {
+	if summary.TotalAmount != models.FromRupees(200) {
 		t.Fatalf("expected total amount 200, got %v", summary.TotalAmount)
 	}
 
-	if summary.GrossExpense != 1000 {
+	if summary.GrossExpense != models.FromRupees(1000) {
 		t.Fatalf("expected gross expense 1000, got %v", summary.GrossExpense)
 	}
 
-	if summary.CreditAmount != 800 {
+	if summary.CreditAmount != models.FromRupees(800) {
 		t.Fatalf("expected credit amount 800, got %v", summary.CreditAmount)
 	}
 
-	if summary.AverageAmount != 500 {
+	if summary.AverageAmount != models.FromRupees(500) {
 		t.Fatalf("expected average amount 500, got %v", summary.AverageAmount)
 	}
 }
+
+func TestGetTotalSummaryIsExactInPaise(t *testing.T) {
+	now := time.Now().UTC()
+	var txs []models.Transaction
+	for i := 0; i < 10; i++ {
+		amount, err := models.ParseMoney("0.10")
+		if err != nil {
+			t.Fatalf("ParseMoney returned error: %v", err)
+		}
+		txs = append(txs, models.Transaction{Amount: amount, DateTime: now.Add(-time.Duration(i) * time.Second)})
+	}
+
+	summary, err := NewReportingService(&reportingTestDB{transactions: txs}).GetTotalSummary("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetTotalSummary returned error: %v", err)
+	}
+
+	if summary.TotalAmount != models.FromRupees(1) {
+		t.Fatalf("expected total amount 1.00, got %s", summary.TotalAmount)
+	}
+}
+
+func TestListTransactionsByDateRangeSearchesNotesAndRawText(t *testing.T) {
+	day := time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC)
+	db := &reportingTestDB{transactions: []models.Transaction{
+		{ID: "gift", Vendor: "TANISHQ", Category: "Shopping", Amount: models.FromRupees(5000), DateTime: day, Notes: "Birthday gift for mom"},
+		{ID: "alert", Vendor: "WWW MYNTRA COM", Category: "Shopping", Amount: models.FromRupees(999), DateTime: day, RawText: "Rs. 999.00 has been debited from your HDFC Bank Credit Card ending 4207"},
+		{ID: "other", Vendor: "SWIGGY", Category: "Food", Amount: models.FromRupees(300), DateTime: day},
+	}}
+	reporting := NewReportingService(db)
+
+	txs, err := reporting.ListTransactionsByDateRange(day, day.Add(time.Hour), TransactionFilter{Query: "birthday"}, 0)
+	if err != nil {
+		t.Fatalf("ListTransactionsByDateRange returned error: %v", err)
+	}
+	if len(txs) != 1 || txs[0].ID != "gift" {
+		t.Fatalf("expected note search to find the gift, got %+v", txs)
+	}
+
+	txs, err = reporting.ListTransactionsByDateRange(day, day.Add(time.Hour), TransactionFilter{Category: "shopping", Query: "ending 4207"}, 0)
+	if err != nil {
+		t.Fatalf("ListTransactionsByDateRange returned error: %v", err)
+	}
+	if len(txs) != 1 || txs[0].ID != "alert" {
+		t.Fatalf("expected raw text search to find the card alert, got %+v", txs)
+	}
+}
+
+func TestResolvePeriodUsesUserTimezone(t *testing.T) {
+	loc := utils.UserLocation()
+	// 1 a.m. in IST is still the previous day in UTC.
+	now := time.Date(2026, 4, 19, 1, 0, 0, 0, loc)
+
+	start, end, err := utils.ResolvePeriodAt("TODAY", now)
+	if err != nil {
+		t.Fatalf("ResolvePeriodAt returned error: %v", err)
+	}
+	if !start.Equal(time.Date(2026, 4, 19, 0, 0, 0, 0, loc)) || !end.Equal(time.Date(2026, 4, 20, 0, 0, 0, 0, loc).Add(-time.Nanosecond)) {
+		t.Fatalf("expected 19 Apr in %s, got %s - %s", loc, start, end)
+	}
+
+	start, _, err = utils.ResolvePeriodAt("LAST_MONTH", time.Date(2026, 5, 1, 2, 0, 0, 0, loc))
+	if err != nil {
+		t.Fatalf("ResolvePeriodAt returned error: %v", err)
+	}
+	if !start.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, loc)) {
+		t.Fatalf("expected last month to start on 1 Apr, got %s", start)
+	}
+}
+
+func TestLastNDaysTrendBucketsByUserDay(t *testing.T) {
+	loc := utils.UserLocation()
+	now := time.Now().In(loc)
+	lateNight := time.Date(now.Year(), now.Month(), now.Day()-1, 1, 0, 0, 0, loc)
+	db := &reportingTestDB{transactions: []models.Transaction{
+		{ID: "dinner", Amount: models.FromRupees(2000), Vendor: "Toit", DateTime: lateNight},
+	}}
+
+	points, err := NewReportingService(db).GetLastNDaysTrend(10)
+	if err != nil {
+		t.Fatalf("GetLastNDaysTrend returned error: %v", err)
+	}
+	if len(points) != 1 || points[0].Date != lateNight.Format("2006-01-02") {
+		t.Fatalf("expected the dinner on %s, got %+v", lateNight.Format("2006-01-02"), points)
+	}
+}
+
+func TestResolvePeriodExpressions(t *testing.T) {
+	loc := utils.UserLocation()
+	now := time.Date(2026, 2, 10, 15, 0, 0, 0, loc)
+	day := func(year int, month time.Month, d int) time.Time { return time.Date(year, month, d, 0, 0, 0, 0, loc) }
+	endOf := func(next time.Time) time.Time { return next.Add(-time.Nanosecond) }
+
+	cases := []struct {
+		period     string
+		start, end time.Time
+	}{
+		{"LAST_30_DAYS", day(2026, 1, 12), now},
+		{"LAST_12_MONTHS", day(2025, 3, 1), now},
+		{"THIS_YEAR", day(2026, 1, 1), now},
+		{"LAST_YEAR", day(2025, 1, 1), endOf(day(2026, 1, 1))},
+		{"THIS_QUARTER", day(2026, 1, 1), now},
+		{"LAST_QUARTER", day(2025, 10, 1), endOf(day(2026, 1, 1))},
+		{"2025-Q3", day(2025, 7, 1), endOf(day(2025, 10, 1))},
+		{"THIS_FY", day(2025, 4, 1), now},
+		{"LAST_FY", day(2024, 4, 1), endOf(day(2025, 4, 1))},
+		{"FY2025-26", day(2025, 4, 1), endOf(day(2026, 4, 1))},
+		{"FY1999-00", day(1999, 4, 1), endOf(day(2000, 4, 1))},
+		{"2026-01", day(2026, 1, 1), endOf(day(2026, 2, 1))},
+		{"2026-01-31", day(2026, 1, 31), endOf(day(2026, 2, 1))},
+		{"2026-01-05..2026-01-20", day(2026, 1, 5), endOf(day(2026, 1, 21))},
+	}
+	for _, c := range cases {
+		start, end, err := utils.ResolvePeriodAt(c.period, now)
+		if err != nil {
+			t.Errorf("%s: ResolvePeriodAt returned error: %v", c.period, err)
+			continue
+		}
+		if !start.Equal(c.start) || !end.Equal(c.end) {
+			t.Errorf("%s: expected %s - %s, got %s - %s", c.period, c.start, c.end, start, end)
+		}
+	}
+
+	for _, period := range []string{"LAST_0_DAYS", "FY2025-27", "2026-13", "2026-01-20..2026-01-05", "2026-Q5", "NEXT_YEAR"} {
+		var invalid *utils.InvalidPeriodError
+		if _, _, err := utils.ResolvePeriodAt(period, now); !errors.As(err, &invalid) {
+			t.Errorf("%s: expected InvalidPeriodError, got %v", period, err)
+		}
+	}
+}
+
+func TestReportingUsesDatabaseAggregates(t *testing.T) {
+	now := time.Now().UTC()
+	db := newTestDB(
+		models.Transaction{ID: "1", Type: "HDFCCreditCard", Category: "Groceries", Amount: models.FromRupees(500), DateTime: now},
+		models.Transaction{ID: "2", Type: "HDFCCreditCard", Category: "Groceries", Amount: models.FromRupees(400), DateTime: now},
+		models.Transaction{ID: "3", Type: "HDFCUPI", Category: "Groceries", Amount: models.FromRupees(300), DateTime: now, Splits: []models.Split{
+			{Category: "Groceries", Amount: models.FromRupees(100)},
+			{Category: "Shopping", Amount: models.FromRupees(200)},
+		}},
+		models.Transaction{ID: "4", Type: "HDFCUPI", Category: "Other", Amount: models.FromRupees(400), DateTime: now},
+	)
+	reporting := NewReportingService(db)
+
+	summary, err := reporting.GetTotalSummary("LAST_12_MONTHS")
+	if err != nil {
+		t.Fatalf("GetTotalSummary returned error: %v", err)
+	}
+	if summary.TransactionCount != 4 || summary.TotalAmount != models.FromRupees(1600) || summary.AverageAmount != models.FromRupees(400) || summary.UncategorizedCount != 1 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	items, err := reporting.GetCategoryBreakdown("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetCategoryBreakdown returned error: %v", err)
+	}
+	if len(items) != 3 || items[0].Label != "Groceries" || items[0].Amount != models.FromRupees(1000) || items[0].Count != 3 {
+		t.Fatalf("unexpected category breakdown %+v", items)
+	}
+
+	sources, err := reporting.GetSourceBreakdown("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetSourceBreakdown returned error: %v", err)
+	}
+	if len(sources) != 1 || sources[0].Label != "HDFC" || sources[0].Count != 4 {
+		t.Fatalf("expected HDFC sources merged, got %+v", sources)
+	}
+	if len(db.aggregates) != 3 || db.aggregates[0].From.IsZero() {
+		t.Fatalf("expected one aggregate query per report, got %+v", db.aggregates)
+	}
+	if db.fetches != 0 {
+		t.Fatalf("expected no rows to be loaded, got %d fetches", db.fetches)
+	}
+}
@@ -0,0 +1,506 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// DefaultUserRulePriority is given to rules saved without a priority. It is
+// above DefaultRulePriority, so user rules win over the seeded defaults.
+const DefaultUserRulePriority = 100
+
+// DefaultRulePriority is the priority of the rules seeded from
+// VendorCategoryMapping. Between them the most specific match wins.
+const DefaultRulePriority = 10
+
+// ruleCacheTTL bounds how long another instance's rule edits can go unseen.
+const ruleCacheTTL = time.Minute
+
+type RuleService struct {
+	store models.RuleStore
+}
+
+func NewRuleService(store models.RuleStore) *RuleService {
+	return &RuleService{store: store}
+}
+
+// ListRules returns the rules in evaluation order, seeding the defaults into
+// an empty rule collection first.
+func (s *RuleService) ListRules() ([]models.CategoryRule, error) {
+	rules, err := loadCategoryRules(s.store)
+	if err != nil {
+		return nil, err
+	}
+	sortCategoryRules(rules)
+	return rules, nil
+}
+
+// SaveRule validates and creates or replaces a rule. Rules without an ID are
+// new; replacing a rule keeps its creation time and source.
+func (s *RuleService) SaveRule(rule models.CategoryRule) (models.CategoryRule, error) {
+	if err := ValidateCategoryRule(&rule); err != nil {
+		return models.CategoryRule{}, err
+	}
+	existing, err := loadCategoryRules(s.store)
+	if err != nil {
+		return models.CategoryRule{}, err
+	}
+
+	now := time.Now().UTC()
+	if rule.ID == "" {
+		id, err := newRecordID()
+		if err != nil {
+			return models.CategoryRule{}, err
+		}
+		rule.ID = id
+		rule.CreatedAt = now
+	} else {
+		found := false
+		for _, stored := range existing {
+			if stored.ID == rule.ID {
+				rule.CreatedAt = stored.CreatedAt
+				rule.Source = stored.Source
+				found = true
+				break
+			}
+		}
+		if !found {
+			return models.CategoryRule{}, fmt.Errorf("category rule %s not found", rule.ID)
+		}
+	}
+	if rule.Source == "" {
+		rule.Source = models.RuleSourceUser
+	}
+	if rule.Priority == 0 {
+		rule.Priority = DefaultUserRulePriority
+	}
+	rule.UpdatedAt = now
+
+	if err := s.store.SaveCategoryRule(rule); err != nil {
+		return models.CategoryRule{}, err
+	}
+	invalidateCategoryRules()
+	return rule, nil
+}
+
+func (s *RuleService) DeleteRule(id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("rule id is required")
+	}
+	if err := s.store.DeleteCategoryRule(id); err != nil {
+		return err
+	}
+	invalidateCategoryRules()
+	return nil
+}
+
+// ValidateCategoryRule trims a rule and checks that it has a category, at
+// least one condition, a valid vendor regex and a sensible amount range.
+func ValidateCategoryRule(rule *models.CategoryRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Category = strings.TrimSpace(rule.Category)
+	rule.VendorPattern = strings.TrimSpace(rule.VendorPattern)
+	rule.Type = strings.TrimSpace(rule.Type)
+	rule.CardEnding = strings.TrimSpace(rule.CardEnding)
+	rule.Account = strings.TrimSpace(rule.Account)
+
+	if rule.Category == "" {
+		return fmt.Errorf("rule category is required")
+	}
+	if rule.VendorPattern == "" && rule.MinAmount == nil && rule.MaxAmount == nil &&
+		rule.Type == "" && rule.CardEnding == "" && rule.Account == "" {
+		return fmt.Errorf("rule needs at least one condition")
+	}
+	if rule.VendorRegex {
+		if rule.VendorPattern == "" {
+			return fmt.Errorf("vendor_regex needs a vendor_pattern")
+		}
+		if _, err := regexp.Compile("(?i)" + rule.VendorPattern); err != nil {
+			return fmt.Errorf("invalid vendor regex: %v", err)
+		}
+	} else if rule.VendorPattern != "" && normalizeVendorWords(rule.VendorPattern) == "" {
+		return fmt.Errorf("vendor_pattern needs at least one letter or digit")
+	}
+	if rule.MinAmount != nil && *rule.MinAmount < 0 || rule.MaxAmount != nil && *rule.MaxAmount < 0 {
+		return fmt.Errorf("amount bounds must not be negative")
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return fmt.Errorf("min_amount is greater than max_amount")
+	}
+	return nil
+}
+
+// DefaultCategoryRules turns VendorCategoryMapping into vendor rules. They
+// share one priority; the longest match decides between them, so "swiggy
+// instamart" wins over "swiggy".
+func DefaultCategoryRules() []models.CategoryRule {
+	rules := make([]models.CategoryRule, 0, len(models.VendorCategoryMapping))
+	for vendor, category := range models.VendorCategoryMapping {
+		rules = append(rules, models.CategoryRule{
+			ID:            "default-" + strings.Join(strings.Fields(vendor), "-"),
+			Category:      category,
+			Priority:      DefaultRulePriority,
+			VendorPattern: vendor,
+			Source:        models.RuleSourceDefault,
+		})
+	}
+	sortCategoryRules(rules)
+	return rules
+}
+
+// RuleMatch is a rule that matched a transaction, with what it matched on.
+type RuleMatch struct {
+	Rule models.CategoryRule `json:"rule"`
+	// MatchedText is the part of the vendor the rule matched, if it has a
+	// vendor condition.
+	MatchedText string   `json:"matched_text,omitempty"`
+	Reasons     []string `json:"reasons"`
+}
+
+// compiledRule is a rule with its vendor regex compiled, or its literal
+// vendor pattern normalized, once.
+type compiledRule struct {
+	rule    models.CategoryRule
+	vendor  *regexp.Regexp
+	pattern string
+}
+
+type ruleSet []compiledRule
+
+func compileRules(rules []models.CategoryRule) ruleSet {
+	sortCategoryRules(rules)
+	set := make(ruleSet, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Disabled {
+			continue
+		}
+		compiled := compiledRule{rule: rule}
+		if rule.VendorRegex {
+			re, err := regexp.Compile("(?i)" + rule.VendorPattern)
+			if err != nil {
+				log.Printf("category rule skipped id=%s err=%v", rule.ID, err)
+				continue
+			}
+			compiled.vendor = re
+		} else if rule.VendorPattern != "" {
+			compiled.pattern = normalizeVendorWords(rule.VendorPattern)
+			if compiled.pattern == "" {
+				log.Printf("category rule skipped id=%s err=empty vendor pattern", rule.ID)
+				continue
+			}
+		}
+		set = append(set, compiled)
+	}
+	return set
+}
+
+// match returns the rule that decides the category of tx.
+func (rs ruleSet) match(tx models.Transaction) (models.CategoryRule, bool) {
+	matches := rs.matches(tx)
+	if len(matches) == 0 {
+		return models.CategoryRule{}, false
+	}
+	return matches[0].Rule, true
+}
+
+// matches returns every rule whose conditions all hold, best first: highest
+// priority, then the longest vendor match, then the most conditions, then ID.
+func (rs ruleSet) matches(tx models.Transaction) []RuleMatch {
+	vendor := normalizeVendorWords(tx.Vendor)
+	merchant := normalizeVendorWords(tx.Merchant)
+	var matches []RuleMatch
+	for _, compiled := range rs {
+		if m, ok := compiled.match(tx, vendor, merchant); ok {
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Rule.Priority != b.Rule.Priority {
+			return a.Rule.Priority > b.Rule.Priority
+		}
+		if len(a.MatchedText) != len(b.MatchedText) {
+			return len(a.MatchedText) > len(b.MatchedText)
+		}
+		if len(a.Reasons) != len(b.Reasons) {
+			return len(a.Reasons) > len(b.Reasons)
+		}
+		return a.Rule.ID < b.Rule.ID
+	})
+	return matches
+}
+
+// match checks every condition of the rule against tx. vendor and merchant
+// are the transaction's vendor and canonical merchant after
+// normalizeVendorWords; the vendor condition holds if either matches.
+func (c compiledRule) match(tx models.Transaction, vendor, merchant string) (RuleMatch, bool) {
+	rule := c.rule
+	m := RuleMatch{Rule: rule}
+	switch {
+	case c.vendor != nil:
+		switch {
+		case c.vendor.MatchString(tx.Vendor):
+			m.MatchedText = c.vendor.FindString(tx.Vendor)
+			m.Reasons = append(m.Reasons, fmt.Sprintf("vendor matches regex %q", rule.VendorPattern))
+		case tx.Merchant != "" && c.vendor.MatchString(tx.Merchant):
+			m.MatchedText = c.vendor.FindString(tx.Merchant)
+			m.Reasons = append(m.Reasons, fmt.Sprintf("merchant %s matches regex %q", tx.Merchant, rule.VendorPattern))
+		default:
+			return RuleMatch{}, false
+		}
+	case c.pattern != "":
+		switch {
+		case containsWords(vendor, c.pattern):
+			m.Reasons = append(m.Reasons, fmt.Sprintf("vendor contains the words %q", c.pattern))
+		case containsWords(merchant, c.pattern):
+			m.Reasons = append(m.Reasons, fmt.Sprintf("merchant %s contains the words %q", tx.Merchant, c.pattern))
+		default:
+			return RuleMatch{}, false
+		}
+		m.MatchedText = c.pattern
+	}
+
+	amount := tx.Amount.Abs()
+	if rule.MinAmount != nil {
+		if amount < *rule.MinAmount {
+			return RuleMatch{}, false
+		}
+		m.Reasons = append(m.Reasons, fmt.Sprintf("amount %s is at least %s", amount, *rule.MinAmount))
+	}
+	if rule.MaxAmount != nil {
+		if amount > *rule.MaxAmount {
+			return RuleMatch{}, false
+		}
+		m.Reasons = append(m.Reasons, fmt.Sprintf("amount %s is at most %s", amount, *rule.MaxAmount))
+	}
+
+	if rule.Type != "" {
+		if !strings.EqualFold(tx.Type, rule.Type) {
+			return RuleMatch{}, false
+		}
+		m.Reasons = append(m.Reasons, fmt.Sprintf("type is %s", rule.Type))
+	}
+	if rule.CardEnding != "" {
+		if tx.CardEnding != rule.CardEnding {
+			return RuleMatch{}, false
+		}
+		m.Reasons = append(m.Reasons, fmt.Sprintf("card ends in %s", rule.CardEnding))
+	}
+	if rule.Account != "" {
+		if !strings.EqualFold(tx.DebitedAccount, rule.Account) && !strings.EqualFold(tx.CreditedAccount, rule.Account) {
+			return RuleMatch{}, false
+		}
+		m.Reasons = append(m.Reasons, fmt.Sprintf("account is %s", rule.Account))
+	}
+	return m, true
+}
+
+// containsWords reports whether the normalized words of pattern appear as a
+// run of whole words in the normalized text.
+func containsWords(text, pattern string) bool {
+	return text != "" && strings.Contains(" "+text+" ", " "+pattern+" ")
+}
+
+// normalizeVendorWords lowercases s and reduces it to words of letters and
+// digits separated by single spaces, so literal vendor patterns only match
+// whole words: "bar" matches "Sky Bar" but not "Malabar Gold".
+func normalizeVendorWords(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// CategoryExplanation says which rule or mapping produced a category.
+type CategoryExplanation struct {
+	Vendor   string `json:"vendor"`
+	Merchant string `json:"merchant,omitempty"`
+	Category string `json:"category"`
+	// Source is "mapping", "rule", "merchant" or "fallback".
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+	// Rule is the deciding rule when Source is "rule".
+	Rule *models.CategoryRule `json:"rule,omitempty"`
+	// Matches lists every matching rule, best first.
+	Matches []RuleMatch `json:"matches"`
+}
+
+// ExplainCategory categorizes tx the way CategorizeTransaction does and
+// reports why: a manual vendor mapping, the deciding rule and every other
+// rule that matched, the merchant's default category, a learned vendor
+// mapping, or the Other fallback.
+func ExplainCategory(tx models.Transaction, dbClient models.DatabaseClient) CategoryExplanation {
+	return explainCategory(tx, dbClient, func(vendor string) *models.CategoryMapping {
+		if dbClient == nil {
+			return nil
+		}
+		mapping, err := dbClient.GetCategoryMapping(vendor)
+		if err != nil {
+			return nil
+		}
+		return mapping
+	})
+}
+
+// explainCategory is ExplainCategory with the vendor mapping read through
+// mappingFor, which gets the lower-cased, trimmed vendor.
+func explainCategory(tx models.Transaction, dbClient models.DatabaseClient, mappingFor func(vendor string) *models.CategoryMapping) CategoryExplanation {
+	merchant, isMerchant := merchantRegistry(dbClient).resolve(tx.Vendor)
+	if tx.Merchant == "" {
+		tx.Merchant = CanonicalMerchant(tx.Vendor, dbClient)
+	}
+	explanation := CategoryExplanation{
+		Vendor:   tx.Vendor,
+		Merchant: tx.Merchant,
+		Matches:  categoryRules(dbClient).matches(tx),
+	}
+	if explanation.Matches == nil {
+		explanation.Matches = []RuleMatch{}
+	}
+
+	var mapping *models.CategoryMapping
+	if tx.Vendor != "" {
+		mapping = mappingFor(strings.ToLower(strings.TrimSpace(tx.Vendor)))
+	}
+
+	// A manual mapping is a correction the user made for this exact vendor,
+	// so it beats any rule.
+	if mapping != nil && mapping.Source == models.MappingSourceManual {
+		explanation.Category = mapping.Category
+		explanation.Source = "mapping"
+		explanation.Reason = fmt.Sprintf("manual vendor mapping for %q, which overrides rules", mapping.Vendor)
+		return explanation
+	}
+
+	if len(explanation.Matches) > 0 {
+		best := explanation.Matches[0]
+		explanation.Category = best.Rule.Category
+		explanation.Source = "rule"
+		explanation.Rule = &best.Rule
+		explanation.Reason = fmt.Sprintf("rule %s (priority %d): %s", best.Rule.ID, best.Rule.Priority, strings.Join(best.Reasons, ", "))
+		if len(explanation.Matches) > 1 {
+			explanation.Reason += fmt.Sprintf("; chosen over %d other matching rules by priority, then longest vendor match", len(explanation.Matches)-1)
+		}
+		return explanation
+	}
+
+	if isMerchant && merchant.DefaultCategory != "" {
+		explanation.Category = merchant.DefaultCategory
+		explanation.Source = "merchant"
+		explanation.Reason = fmt.Sprintf("no rule matched; default category of merchant %s", merchant.Name)
+		return explanation
+	}
+
+	if mapping != nil {
+		explanation.Category = mapping.Category
+		explanation.Source = "mapping"
+		explanation.Reason = fmt.Sprintf("no rule matched; stored %s vendor mapping for %q", mapping.Source, mapping.Vendor)
+		return explanation
+	}
+	explanation.Category = "Other"
+	explanation.Source = "fallback"
+	explanation.Reason = "no rule or vendor mapping matched"
+	return explanation
+}
+
+// sortCategoryRules orders rules by priority, highest first, and by ID for
+// equal priorities so evaluation is deterministic.
+func sortCategoryRules(rules []models.CategoryRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return rules[i].ID < rules[j].ID
+	})
+}
+
+var (
+	ruleCacheMu       sync.Mutex
+	ruleCache         ruleSet
+	ruleCacheLoadedAt time.Time
+
+	defaultRulesOnce sync.Once
+	defaultRules     ruleSet
+)
+
+// categoryRules returns the compiled rules for a database client: the stored
+// rules when the backend keeps them, the built-in defaults otherwise.
+func categoryRules(dbClient models.DatabaseClient) ruleSet {
+	store, ok := dbClient.(models.RuleStore)
+	if !ok {
+		defaultRulesOnce.Do(func() {
+			defaultRules = compileRules(DefaultCategoryRules())
+		})
+		return defaultRules
+	}
+
+	ruleCacheMu.Lock()
+	defer ruleCacheMu.Unlock()
+	if ruleCache != nil && time.Since(ruleCacheLoadedAt) < ruleCacheTTL {
+		return ruleCache
+	}
+	rules, err := loadCategoryRules(store)
+	if err != nil {
+		log.Printf("category rules load failed, using defaults err=%v", err)
+		return compileRules(DefaultCategoryRules())
+	}
+	ruleCache = compileRules(rules)
+	ruleCacheLoadedAt = time.Now()
+	return ruleCache
+}
+
+func invalidateCategoryRules() {
+	ruleCacheMu.Lock()
+	ruleCache = nil
+	ruleCacheMu.Unlock()
+}
+
+// loadCategoryRules lists the stored rules, seeding the defaults the first
+// time. Seeding is recorded in the settings, so deleted default rules stay
+// deleted even once every rule is gone. Backends without settings seed
+// whenever the collection is empty.
+func loadCategoryRules(store models.RuleStore) ([]models.CategoryRule, error) {
+	rules, err := store.ListCategoryRules()
+	if err != nil {
+		return nil, err
+	}
+
+	settings, _ := store.(models.SettingsStore)
+	seeded := len(rules) > 0
+	if settings != nil {
+		marker, err := settings.GetSetting(models.SettingCategoryRulesSeeded)
+		if err != nil {
+			return nil, err
+		}
+		if marker != nil {
+			return rules, nil
+		}
+	}
+
+	if !seeded {
+		now := time.Now().UTC()
+		rules = DefaultCategoryRules()
+		for i := range rules {
+			rules[i].CreatedAt = now
+			rules[i].UpdatedAt = now
+			if err := store.SaveCategoryRule(rules[i]); err != nil {
+				return nil, err
+			}
+		}
+		log.Printf("category rules seeded count=%d", len(rules))
+	}
+	if settings != nil {
+		// Rules stored before the marker existed were seeded too.
+		if err := settings.SaveSetting(models.Setting{Key: models.SettingCategoryRulesSeeded, Value: "true", UpdatedAt: time.Now().UTC()}); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestCategorizeTransactionSeedsDefaultRules(t *testing.T) {
+	db := newTestDB()
+	defer invalidateCategoryRules()
+
+	got := CategorizeTransaction(models.Transaction{Vendor: "SWIGGY INSTAMART BANGALORE"}, db)
+	if got != "Grocery" {
+		t.Fatalf("expected Grocery, got %q", got)
+	}
+	if len(db.rules) != len(models.VendorCategoryMapping) {
+		t.Fatalf("expected %d seeded rules, got %d", len(models.VendorCategoryMapping), len(db.rules))
+	}
+	if got := CategorizeTransaction(models.Transaction{Vendor: "SWIGGY"}, db); got != "Food" {
+		t.Fatalf("expected Food, got %q", got)
+	}
+}
+
+func TestDeletingEveryRuleDoesNotReseedDefaults(t *testing.T) {
+	db := newTestDB()
+	defer invalidateCategoryRules()
+	service := NewRuleService(db)
+
+	rules, err := service.ListRules()
+	if err != nil || len(rules) == 0 {
+		t.Fatalf("expected the defaults to be seeded, got %d rules err=%v", len(rules), err)
+	}
+	for _, rule := range rules {
+		if err := service.DeleteRule(rule.ID); err != nil {
+			t.Fatalf("DeleteRule returned error: %v", err)
+		}
+	}
+
+	rules, err = service.ListRules()
+	if err != nil || len(rules) != 0 {
+		t.Fatalf("expected deleted defaults to stay deleted, got %d rules err=%v", len(rules), err)
+	}
+}
+
+func TestUserRuleOverridesDefaultsAndMatchesConditions(t *testing.T) {
+	db := newTestDB()
+	defer invalidateCategoryRules()
+	service := NewRuleService(db)
+
+	min := models.FromRupees(1000)
+	if _, err := service.SaveRule(models.CategoryRule{
+		Name:          "Big Swiggy orders are parties",
+		Category:      "Entertainment",
+		VendorPattern: "swiggy",
+		MinAmount:     &min,
+		CardEnding:    "1234",
+	}); err != nil {
+		t.Fatalf("SaveRule returned error: %v", err)
+	}
+	if _, err := service.SaveRule(models.CategoryRule{
+		Category: "Rent",
+		Type:     "UPI",
+		Account:  "landlord@okhdfc",
+	}); err != nil {
+		t.Fatalf("SaveRule returned error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		tx   models.Transaction
+		want string
+	}{
+		{"all conditions hold", models.Transaction{Vendor: "SWIGGY", Amount: models.FromRupees(1500), CardEnding: "1234"}, "Entertainment"},
+		{"amount below minimum", models.Transaction{Vendor: "SWIGGY", Amount: models.FromRupees(200), CardEnding: "1234"}, "Food"},
+		{"other card", models.Transaction{Vendor: "SWIGGY", Amount: models.FromRupees(1500), CardEnding: "9999"}, "Food"},
+		{"account and type", models.Transaction{Type: "upi", Vendor: "Ramesh", CreditedAccount: "LANDLORD@okhdfc"}, "Rent"},
+		{"no rule", models.Transaction{Type: "UPI", Vendor: "Ramesh"}, "Other"},
+	}
+	for _, tt := range tests {
+		if got := CategorizeTransaction(tt.tx, db); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSaveRuleValidation(t *testing.T) {
+	db := newTestDB()
+	defer invalidateCategoryRules()
+	service := NewRuleService(db)
+
+	min, max := models.FromRupees(500), models.FromRupees(100)
+	invalid := []models.CategoryRule{
+		{VendorPattern: "swiggy"},
+		{Category: "Food"},
+		{Category: "Food", VendorPattern: "swiggy(", VendorRegex: true},
+		{Category: "Food", MinAmount: &min, MaxAmount: &max},
+		{ID: "missing", Category: "Food", VendorPattern: "swiggy"},
+	}
+	for _, rule := range invalid {
+		if _, err := service.SaveRule(rule); err == nil {
+			t.Errorf("expected error for rule %+v", rule)
+		}
+	}
+
+	saved, err := service.SaveRule(models.CategoryRule{Category: "Travel", VendorPattern: `^(ola|rapido)\b`, VendorRegex: true})
+	if err != nil {
+		t.Fatalf("SaveRule returned error: %v", err)
+	}
+	if saved.Priority != DefaultUserRulePriority || saved.Source != models.RuleSourceUser {
+		t.Fatalf("expected user rule defaults, got %+v", saved)
+	}
+	if got := CategorizeTransaction(models.Transaction{Vendor: "Rapido Bike"}, db); got != "Travel" {
+		t.Fatalf("expected regex rule to match, got %q", got)
+	}
+}
+
+func TestDefaultRulesMatchWholeWordsAndLongestKey(t *testing.T) {
+	tests := []struct {
+		vendor string
+		want   string
+	}{
+		{"SWIGGY INSTAMART", "Grocery"},
+		{"Swiggy-Instamart Bangalore", "Grocery"},
+		{"SWIGGY", "Food"},
+		{"MALABAR GOLD AND DIAMONDS", "Shopping"},
+		{"BARBEQUE NATION", "Other"},
+		{"SKY BAR", "Food"},
+		{"SHREE STATIONERY", "Other"},
+		{"SHELL PETROL STATION", "Petrol"},
+		{"BUSINESS CENTRE", "Other"},
+		{"KSRTC BUS TICKET", "Travel"},
+	}
+	for _, tt := range tests {
+		for run := 0; run < 5; run++ {
+			if got := CategorizeTransaction(models.Transaction{Vendor: tt.vendor}, nil); got != tt.want {
+				t.Fatalf("%s: expected %q, got %q on run %d", tt.vendor, tt.want, got, run)
+			}
+		}
+	}
+}
+
+func TestExplainCategoryReportsDecidingRule(t *testing.T) {
+	explanation := ExplainCategory(models.Transaction{Vendor: "SWIGGY INSTAMART"}, nil)
+	if explanation.Source != "rule" || explanation.Rule == nil || explanation.Rule.ID != "default-swiggy-instamart" {
+		t.Fatalf("expected the swiggy instamart rule to decide, got %+v", explanation)
+	}
+	if len(explanation.Matches) != 2 || explanation.Matches[1].Rule.ID != "default-swiggy" {
+		t.Fatalf("expected the swiggy rule as runner-up, got %+v", explanation.Matches)
+	}
+
+	explanation = ExplainCategory(models.Transaction{Vendor: "BARBEQUE NATION"}, nil)
+	if explanation.Source != "fallback" || explanation.Category != "Other" || len(explanation.Matches) != 0 {
+		t.Fatalf("expected fallback to Other, got %+v", explanation)
+	}
+}
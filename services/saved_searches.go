@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+type SavedSearchService struct {
+	store models.SavedSearchStore
+}
+
+func NewSavedSearchService(store models.SavedSearchStore) *SavedSearchService {
+	return &SavedSearchService{store: store}
+}
+
+func (s *SavedSearchService) ListSavedSearches() ([]models.SavedSearch, error) {
+	return s.store.ListSavedSearches()
+}
+
+// SaveSavedSearch validates and creates or replaces a saved search. Names are
+// unique ignoring case and the query must parse. Searches without an ID are
+// new.
+func (s *SavedSearchService) SaveSavedSearch(search models.SavedSearch) (models.SavedSearch, error) {
+	search.Name = strings.TrimSpace(search.Name)
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Name == "" {
+		return models.SavedSearch{}, fmt.Errorf("saved search name is required")
+	}
+	if search.Query == "" {
+		return models.SavedSearch{}, fmt.Errorf("saved search query is required")
+	}
+	if _, err := ParseSearchQuery(search.Query); err != nil {
+		return models.SavedSearch{}, err
+	}
+
+	existing, err := s.store.ListSavedSearches()
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	for _, stored := range existing {
+		if stored.ID != search.ID && strings.EqualFold(stored.Name, search.Name) {
+			return models.SavedSearch{}, fmt.Errorf("saved search %q already exists", stored.Name)
+		}
+	}
+
+	now := time.Now().UTC()
+	if search.ID == "" {
+		id, err := newRecordID()
+		if err != nil {
+			return models.SavedSearch{}, err
+		}
+		search.ID = id
+		search.CreatedAt = now
+	} else {
+		found := false
+		for _, stored := range existing {
+			if stored.ID == search.ID {
+				search.CreatedAt = stored.CreatedAt
+				found = true
+				break
+			}
+		}
+		if !found {
+			return models.SavedSearch{}, fmt.Errorf("saved search %s not found", search.ID)
+		}
+	}
+	search.UpdatedAt = now
+
+	if err := s.store.SaveSavedSearch(search); err != nil {
+		return models.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+func (s *SavedSearchService) DeleteSavedSearch(id string) error {
+	if strings.TrimSpace(id) == "" {
+		return fmt.Errorf("saved search id is required")
+	}
+	return s.store.DeleteSavedSearch(id)
+}
+
+// FindSavedSearch returns the saved search with the given ID or name.
+func (s *SavedSearchService) FindSavedSearch(idOrName string) (models.SavedSearch, error) {
+	idOrName = strings.TrimSpace(idOrName)
+	searches, err := s.store.ListSavedSearches()
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	for _, search := range searches {
+		if search.ID == idOrName || strings.EqualFold(search.Name, idOrName) {
+			return search, nil
+		}
+	}
+	return models.SavedSearch{}, fmt.Errorf("saved search %s not found", idOrName)
+}
+
+// savedSearchQuery parses the query of a saved search given by ID or name.
+func (s *ReportingService) savedSearchQuery(idOrName string) (*SearchQuery, error) {
+	store, ok := s.dbClient.(models.SavedSearchStore)
+	if !ok {
+		return nil, fmt.Errorf("saved searches not supported for this database backend")
+	}
+	search, err := NewSavedSearchService(store).FindSavedSearch(idOrName)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSearchQuery(search.Query)
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+// SearchSyntaxError reports where a search query could not be parsed. Pos is
+// the 1-based character position of the offending term.
+type SearchSyntaxError struct {
+	Pos     int
+	Message string
+}
+
+func (e *SearchSyntaxError) Error() string {
+	return fmt.Sprintf("search syntax error at %d: %s", e.Pos, e.Message)
+}
+
+// SearchQuery is a parsed transaction search such as
+//
+//	category:Food amount>500 weekday:sat,sun vendor~swiggy -tag:reimbursable after:2026-01-01
+//
+// Terms are separated by spaces and must all match. A term is field, operator
+// and value; a leading "-" negates it and values with spaces are quoted.
+// Comma separated values match any of them. Words without a field are
+// matched against vendor, notes and the raw source text.
+//
+//	category:  (also matches subcategories)
+//	vendor:    vendor or merchant is exactly the value
+//	vendor~    vendor or merchant contains the value
+//	type: type~
+//	tag:
+//	amount: amount> amount>= amount< amount<=   (rupees, credits are negative)
+//	weekday:   sun, mon, ... in the user's time zone
+//	on: after: before:   YYYY-MM-DD; after is inclusive, before exclusive
+//	note~ note:          notes contain the value
+type SearchQuery struct {
+	terms []searchTerm
+	text  string
+}
+
+type searchTerm struct {
+	field  string
+	op     string
+	values []string
+	negate bool
+
+	amount   models.Money
+	date     time.Time
+	weekdays []time.Weekday
+}
+
+// searchOperators lists the operators each field accepts.
+var searchOperators = map[string][]string{
+	"category": {":"},
+	"vendor":   {":", "~"},
+	"type":     {":", "~"},
+	"tag":      {":"},
+	"amount":   {":", ">", ">=", "<", "<="},
+	"weekday":  {":"},
+	"on":       {":"},
+	"after":    {":"},
+	"before":   {":"},
+	"note":     {":", "~"},
+}
+
+var searchFieldAliases = map[string]string{
+	"cat":      "category",
+	"merchant": "vendor",
+	"tags":     "tag",
+	"day":      "weekday",
+	"notes":    "note",
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// ParseSearchQuery parses a search. An empty query returns nil, which
+// matches everything.
+func ParseSearchQuery(q string) (*SearchQuery, error) {
+	if strings.TrimSpace(q) == "" {
+		return nil, nil
+	}
+
+	query := &SearchQuery{text: strings.TrimSpace(q)}
+	runes := []rune(q)
+	i := 0
+	for {
+		for i < len(runes) && isSearchSpace(runes[i]) {
+			i++
+		}
+		if i >= len(runes) {
+			break
+		}
+		start := i
+
+		term := searchTerm{}
+		if runes[i] == '-' && i+1 < len(runes) && !isSearchSpace(runes[i+1]) {
+			term.negate = true
+			i++
+		}
+
+		nameStart := i
+		for i < len(runes) && isSearchFieldRune(runes[i]) {
+			i++
+		}
+		name := strings.ToLower(string(runes[nameStart:i]))
+		op := ""
+		if i < len(runes) && i > nameStart {
+			switch runes[i] {
+			case ':', '~', '=':
+				op = string(runes[i])
+			case '>', '<':
+				op = string(runes[i])
+				if i+1 < len(runes) && runes[i+1] == '=' {
+					op += "="
+				}
+			}
+		}
+
+		if op == "" {
+			// A bare word or quoted phrase searched as free text.
+			i = nameStart
+			value, next, err := readSearchValue(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			i = next
+			if value == "" {
+				return nil, &SearchSyntaxError{Pos: start + 1, Message: "expected a search term after -"}
+			}
+			term.field = "text"
+			term.values = []string{value}
+			query.terms = append(query.terms, term)
+			continue
+		}
+
+		field := name
+		if alias, ok := searchFieldAliases[field]; ok {
+			field = alias
+		}
+		allowed, ok := searchOperators[field]
+		if !ok {
+			return nil, &SearchSyntaxError{Pos: nameStart + 1, Message: fmt.Sprintf("unknown field %q, expected one of category, vendor, type, tag, amount, weekday, on, after, before or note", name)}
+		}
+		if op == "=" {
+			op = ":"
+		}
+		if !containsString(allowed, op) {
+			return nil, &SearchSyntaxError{Pos: i + 1, Message: fmt.Sprintf("%s does not support %q, expected %s", field, op, strings.Join(allowed, " "))}
+		}
+		i += len([]rune(op))
+		if op == ":" && i < len(runes) && runes[i] == '=' {
+			return nil, &SearchSyntaxError{Pos: i + 1, Message: "unexpected ="}
+		}
+
+		valuePos := i + 1
+		value, next, err := readSearchValue(runes, i)
+		if err != nil {
+			return nil, err
+		}
+		i = next
+		if strings.TrimSpace(value) == "" {
+			return nil, &SearchSyntaxError{Pos: valuePos, Message: fmt.Sprintf("missing value for %s", field)}
+		}
+
+		term.field = field
+		term.op = op
+		if err := term.parseValue(value); err != nil {
+			return nil, &SearchSyntaxError{Pos: valuePos, Message: err.Error()}
+		}
+		query.terms = append(query.terms, term)
+	}
+	return query, nil
+}
+
+// readSearchValue reads a quoted or space-terminated value starting at i and
+// returns it with the position after it.
+func readSearchValue(runes []rune, i int) (string, int, error) {
+	if i < len(runes) && runes[i] == '"' {
+		start := i
+		i++
+		var value strings.Builder
+		for i < len(runes) && runes[i] != '"' {
+			value.WriteRune(runes[i])
+			i++
+		}
+		if i >= len(runes) {
+			return "", i, &SearchSyntaxError{Pos: start + 1, Message: "unterminated quote"}
+		}
+		return value.String(), i + 1, nil
+	}
+
+	start := i
+	for i < len(runes) && !isSearchSpace(runes[i]) {
+		i++
+	}
+	return string(runes[start:i]), i, nil
+}
+
+func (t *searchTerm) parseValue(value string) error {
+	switch t.field {
+	case "amount":
+		amount, err := models.ParseMoney(value)
+		if err != nil {
+			return fmt.Errorf("invalid amount %q", value)
+		}
+		t.amount = amount
+	case "on", "after", "before":
+		date, err := utils.ParseDate(value)
+		if err != nil {
+			return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+		}
+		t.date = date
+	case "weekday":
+		for _, name := range splitSearchList(value) {
+			day, ok := weekdayNames[strings.ToLower(name)]
+			if !ok {
+				return fmt.Errorf("invalid weekday %q, expected sun, mon, tue, wed, thu, fri or sat", name)
+			}
+			t.weekdays = append(t.weekdays, day)
+		}
+	case "tag":
+		tags, err := NormalizeTags(splitSearchList(value))
+		if err != nil {
+			return err
+		}
+		t.values = tags
+	case "category", "type", "vendor":
+		t.values = splitSearchList(value)
+	default:
+		t.values = []string{value}
+	}
+	if len(t.values) == 0 && len(t.weekdays) == 0 && t.field != "amount" && t.date.IsZero() {
+		return fmt.Errorf("missing value for %s", t.field)
+	}
+	return nil
+}
+
+// String returns the query as it was written.
+func (q *SearchQuery) String() string {
+	if q == nil {
+		return ""
+	}
+	return q.text
+}
+
+// Matches reports whether tx matches every term. tree resolves
+// subcategories and may be nil.
+func (q *SearchQuery) Matches(tx models.Transaction, tree *CategoryTree) bool {
+	if q == nil {
+		return true
+	}
+	for _, term := range q.terms {
+		if term.matches(tx, tree) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (t searchTerm) matches(tx models.Transaction, tree *CategoryTree) bool {
+	switch t.field {
+	case "category":
+		for _, line := range tx.CategoryLines() {
+			for _, category := range t.values {
+				if tree.Contains(category, line.Category) {
+					return true
+				}
+			}
+		}
+		return false
+	case "vendor":
+		for _, value := range t.values {
+			if t.op == "~" {
+				if containsFold(tx.Vendor, value) || containsFold(tx.Merchant, value) {
+					return true
+				}
+			} else if strings.EqualFold(strings.TrimSpace(tx.Vendor), value) || strings.EqualFold(tx.Merchant, value) {
+				return true
+			}
+		}
+		return false
+	case "type":
+		for _, value := range t.values {
+			if (t.op == "~" && containsFold(tx.Type, value)) || strings.EqualFold(tx.Type, value) {
+				return true
+			}
+		}
+		return false
+	case "tag":
+		for _, tag := range t.values {
+			if tx.HasTag(tag) {
+				return true
+			}
+		}
+		return false
+	case "amount":
+		switch t.op {
+		case ">":
+			return tx.Amount > t.amount
+		case ">=":
+			return tx.Amount >= t.amount
+		case "<":
+			return tx.Amount < t.amount
+		case "<=":
+			return tx.Amount <= t.amount
+		default:
+			return tx.Amount == t.amount
+		}
+	case "weekday":
+		day := tx.DateTime.In(utils.UserLocation()).Weekday()
+		for _, weekday := range t.weekdays {
+			if day == weekday {
+				return true
+			}
+		}
+		return false
+	case "on":
+		return utils.DayKey(tx.DateTime) == utils.DayKey(t.date)
+	case "after":
+		return !tx.DateTime.Before(t.date)
+	case "before":
+		return tx.DateTime.Before(t.date)
+	case "note":
+		return containsFold(tx.Notes, t.values[0])
+	default:
+		value := t.values[0]
+		return containsFold(tx.Vendor, value) || containsFold(tx.Notes, value) || containsFold(tx.RawText, value)
+	}
+}
+
+func splitSearchList(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isSearchSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+func isSearchFieldRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+func TestSearchQueryMatchesEveryTerm(t *testing.T) {
+	query, err := ParseSearchQuery(`category:Food amount>500 weekday:sat,sun vendor~swiggy type:HDFCCreditCard -tag:reimbursable after:2026-01-01`)
+	if err != nil {
+		t.Fatalf("ParseSearchQuery returned error: %v", err)
+	}
+
+	loc := utils.UserLocation()
+	saturday := time.Date(2026, 1, 3, 20, 0, 0, 0, loc)
+	match := models.Transaction{Vendor: "Swiggy Instamart", Category: "Food", Type: "HDFCCreditCard", Amount: models.FromRupees(640), DateTime: saturday}
+	if !query.Matches(match, nil) {
+		t.Fatalf("expected %+v to match", match)
+	}
+
+	cases := map[string]func(tx *models.Transaction){
+		"weekday":  func(tx *models.Transaction) { tx.DateTime = saturday.AddDate(0, 0, 2) },
+		"amount":   func(tx *models.Transaction) { tx.Amount = models.FromRupees(500) },
+		"vendor":   func(tx *models.Transaction) { tx.Vendor = "Zomato" },
+		"type":     func(tx *models.Transaction) { tx.Type = "ICICI" },
+		"category": func(tx *models.Transaction) { tx.Category = "Travel" },
+		"tag":      func(tx *models.Transaction) { tx.Tags = []string{"reimbursable"} },
+		"after":    func(tx *models.Transaction) { tx.DateTime = time.Date(2025, 12, 27, 20, 0, 0, 0, loc) },
+	}
+	for name, change := range cases {
+		tx := match
+		change(&tx)
+		if query.Matches(tx, nil) {
+			t.Fatalf("%s: expected %+v not to match", name, tx)
+		}
+	}
+}
+
+func TestSearchQueryFreeTextAndQuotes(t *testing.T) {
+	query, err := ParseSearchQuery(`"ending 4207" note~"birthday gift"`)
+	if err != nil {
+		t.Fatalf("ParseSearchQuery returned error: %v", err)
+	}
+	tx := models.Transaction{Vendor: "Amazon", RawText: "Card ending 4207 used", Notes: "Birthday gift for mom"}
+	if !query.Matches(tx, nil) {
+		t.Fatalf("expected %+v to match", tx)
+	}
+	tx.Notes = "groceries"
+	if query.Matches(tx, nil) {
+		t.Fatalf("expected %+v not to match", tx)
+	}
+
+	if empty, err := ParseSearchQuery("   "); err != nil || empty != nil || !empty.Matches(tx, nil) {
+		t.Fatalf("expected an empty query to match everything, got %v, %v", empty, err)
+	}
+}
+
+func TestParseSearchQueryReportsPosition(t *testing.T) {
+	cases := map[string]int{
+		`colour:red`:           1,
+		`food amount~500`:      12,
+		`amount>abc`:           8,
+		`weekday:sat,caturday`: 9,
+		`vendor:"swiggy`:       8,
+		`after:2026-13-01`:     7,
+		`category:`:            10,
+	}
+	for q, pos := range cases {
+		_, err := ParseSearchQuery(q)
+		var syntaxErr *SearchSyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("%q: expected a syntax error, got %v", q, err)
+		}
+		if syntaxErr.Pos != pos {
+			t.Fatalf("%q: expected position %d, got %d (%v)", q, pos, syntaxErr.Pos, err)
+		}
+	}
+}
+
+func TestSavedSearchFiltersListings(t *testing.T) {
+	now := time.Now().Add(-time.Hour)
+	db := newTestDB(
+		models.Transaction{ID: "big", Vendor: "Swiggy", Amount: models.FromRupees(900), DateTime: now},
+		models.Transaction{ID: "small", Vendor: "Swiggy", Amount: models.FromRupees(90), DateTime: now},
+		models.Transaction{ID: "other", Vendor: "Uber", Amount: models.FromRupees(900), DateTime: now},
+	)
+
+	service := NewSavedSearchService(db)
+	if _, err := service.SaveSavedSearch(models.SavedSearch{Name: "Broken", Query: "amount>"}); err == nil {
+		t.Fatalf("expected an invalid query to be rejected")
+	}
+	saved, err := service.SaveSavedSearch(models.SavedSearch{Name: "Big Swiggy", Query: "vendor~swiggy amount>=500"})
+	if err != nil {
+		t.Fatalf("SaveSavedSearch returned error: %v", err)
+	}
+	if _, err := service.SaveSavedSearch(models.SavedSearch{Name: "big swiggy", Query: "swiggy"}); err == nil {
+		t.Fatalf("expected a duplicate name to be rejected")
+	}
+
+	reporting := NewReportingService(db)
+	for _, ref := range []string{saved.ID, "BIG SWIGGY"} {
+		txs, err := reporting.ListTransactions("LAST_30_DAYS", TransactionFilter{SavedSearch: ref}, 0)
+		if err != nil {
+			t.Fatalf("ListTransactions returned error: %v", err)
+		}
+		if len(txs) != 1 || txs[0].ID != "big" {
+			t.Fatalf("expected only the big Swiggy order for %q, got %+v", ref, txs)
+		}
+	}
+	if _, err := reporting.ListTransactions("LAST_30_DAYS", TransactionFilter{SavedSearch: "missing"}, 0); err == nil {
+		t.Fatalf("expected an unknown saved search to fail")
+	}
+}
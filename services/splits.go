@@ -0,0 +1,73 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+type SplitService struct {
+	dbClient models.DatabaseClient
+	store    models.SplitStore
+}
+
+func NewSplitService(dbClient models.DatabaseClient, store models.SplitStore) *SplitService {
+	return &SplitService{dbClient: dbClient, store: store}
+}
+
+// SetSplits validates and stores the split lines of a transaction. Passing no
+// lines removes an existing split.
+func (s *SplitService) SetSplits(id string, splits []models.Split) (*models.Transaction, error) {
+	tx, err := s.dbClient.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized, err := ValidateSplits(*tx, splits)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SetTransactionSplits(id, normalized); err != nil {
+		return nil, err
+	}
+
+	tx.Splits = normalized
+	tx.Version++
+	return tx, nil
+}
+
+// ValidateSplits checks that split lines can replace the single category of
+// tx: at least two lines, each with a category and an amount of the same sign
+// as the transaction, adding up exactly to its amount.
+func ValidateSplits(tx models.Transaction, splits []models.Split) ([]models.Split, error) {
+	if len(splits) == 0 {
+		return nil, nil
+	}
+	if len(splits) < 2 {
+		return nil, fmt.Errorf("a split needs at least two lines")
+	}
+	if tx.Amount == 0 {
+		return nil, fmt.Errorf("cannot split a transaction without a rupee amount")
+	}
+
+	normalized := make([]models.Split, len(splits))
+	var total models.Money
+	for i, split := range splits {
+		split.Category = strings.TrimSpace(split.Category)
+		split.Note = strings.TrimSpace(split.Note)
+		if split.Category == "" {
+			return nil, fmt.Errorf("split line %d has no category", i+1)
+		}
+		if split.Amount == 0 || (split.Amount < 0) != (tx.Amount < 0) {
+			return nil, fmt.Errorf("split line %d amount %s must be non-zero and have the same sign as the transaction", i+1, split.Amount)
+		}
+		total += split.Amount
+		normalized[i] = split
+	}
+
+	if total != tx.Amount {
+		return nil, fmt.Errorf("split lines add up to %s but the transaction amount is %s", total, tx.Amount)
+	}
+	return normalized, nil
+}
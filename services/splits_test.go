@@ -0,0 +1,70 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestValidateSplitsRequiresExactTotal(t *testing.T) {
+	tx := models.Transaction{Vendor: "AMAZON", Amount: models.FromRupees(1500)}
+
+	_, err := ValidateSplits(tx, []models.Split{
+		{Amount: models.FromRupees(1000), Category: "Grocery"},
+		{Amount: models.FromRupees(499.99), Category: "Gifts"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "add up to 1499.99") {
+		t.Fatalf("expected sum mismatch error, got %v", err)
+	}
+
+	splits, err := ValidateSplits(tx, []models.Split{
+		{Amount: models.FromRupees(1000), Category: " Grocery "},
+		{Amount: models.FromRupees(500), Category: "Gifts", Note: "birthday gift for mom"},
+	})
+	if err != nil {
+		t.Fatalf("ValidateSplits returned error: %v", err)
+	}
+	if splits[0].Category != "Grocery" {
+		t.Fatalf("expected trimmed category, got %q", splits[0].Category)
+	}
+
+	if _, err := ValidateSplits(tx, []models.Split{{Amount: tx.Amount, Category: "Grocery"}}); err == nil {
+		t.Fatalf("expected error for a single split line")
+	}
+}
+
+func TestGetCategoryBreakdownUsesSplitLines(t *testing.T) {
+	now := time.Now().UTC()
+	db := &reportingTestDB{transactions: []models.Transaction{
+		{
+			Vendor:   "RELIANCE RETAIL LIMITED",
+			Category: "Shopping",
+			Amount:   models.FromRupees(3000),
+			DateTime: now,
+			Splits: []models.Split{
+				{Amount: models.FromRupees(1800), Category: "Grocery"},
+				{Amount: models.FromRupees(1200), Category: "Shopping"},
+			},
+		},
+		{Vendor: "BLINKIT", Category: "Grocery", Amount: models.FromRupees(200), DateTime: now},
+	}}
+
+	items, err := NewReportingService(db).GetCategoryBreakdown("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetCategoryBreakdown returned error: %v", err)
+	}
+
+	totals := make(map[string]models.Money)
+	for _, item := range items {
+		totals[item.Label] = item.Amount
+	}
+	if totals["Grocery"] != models.FromRupees(2000) || totals["Shopping"] != models.FromRupees(1200) {
+		t.Fatalf("expected split amounts per category, got %v", totals)
+	}
+
+	if amount := CategoryAmount(db.transactions[0], "grocery"); amount != models.FromRupees(1800) {
+		t.Fatalf("expected grocery share 1800.00, got %s", amount)
+	}
+}
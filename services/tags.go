@@ -0,0 +1,182 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+const maxTagLength = 50
+
+var (
+	tagSpaceRe   = regexp.MustCompile(`\s+`)
+	tagInvalidRe = regexp.MustCompile(`[^a-z0-9_-]`)
+)
+
+type TagService struct {
+	dbClient models.DatabaseClient
+	store    models.TagStore
+}
+
+func NewTagService(dbClient models.DatabaseClient, store models.TagStore) *TagService {
+	return &TagService{dbClient: dbClient, store: store}
+}
+
+// NormalizeTag lower-cases a tag and joins words with dashes, so "Goa Trip
+// 2026" and "goa-trip-2026" are the same tag.
+func NormalizeTag(name string) (string, error) {
+	tag := strings.ToLower(strings.TrimSpace(name))
+	tag = tagSpaceRe.ReplaceAllString(tag, "-")
+	if tag == "" {
+		return "", fmt.Errorf("tag name is required")
+	}
+	if len(tag) > maxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", name, maxTagLength)
+	}
+	if tagInvalidRe.MatchString(tag) {
+		return "", fmt.Errorf("tag %q may only contain letters, digits, '-' and '_'", name)
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes and de-duplicates a list of tags.
+func NormalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	tags := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (s *TagService) ListTags() ([]models.Tag, error) {
+	return s.store.ListTags()
+}
+
+func (s *TagService) CreateTag(name, description string) (models.Tag, error) {
+	tag, err := NormalizeTag(name)
+	if err != nil {
+		return models.Tag{}, err
+	}
+	saved := models.Tag{Name: tag, Description: strings.TrimSpace(description), CreatedAt: time.Now().UTC()}
+	if err := s.store.SaveTag(saved); err != nil {
+		return models.Tag{}, err
+	}
+	return saved, nil
+}
+
+// RenameTag renames a tag on the tag list and on every transaction carrying
+// it. Each changed transaction gets an entry in its change log.
+func (s *TagService) RenameTag(oldName, newName, actor, source string) (int, error) {
+	from, err := NormalizeTag(oldName)
+	if err != nil {
+		return 0, err
+	}
+	to, err := NormalizeTag(newName)
+	if err != nil {
+		return 0, err
+	}
+	if from == to {
+		return 0, nil
+	}
+	tagged, err := s.store.RenameTag(from, to)
+	s.recordTagChanges(tagged, []string{to}, []string{from, to}, actor, source)
+	return len(tagged), err
+}
+
+// DeleteTag removes a tag from the tag list and from every transaction
+// carrying it. Each changed transaction gets an entry in its change log.
+func (s *TagService) DeleteTag(name, actor, source string) (int, error) {
+	tag, err := NormalizeTag(name)
+	if err != nil {
+		return 0, err
+	}
+	tagged, err := s.store.DeleteTag(tag)
+	s.recordTagChanges(tagged, nil, []string{tag}, actor, source)
+	return len(tagged), err
+}
+
+// recordTagChanges records the change log entries for transactions whose
+// tags were rewritten by removing removed and then appending added.
+func (s *TagService) recordTagChanges(txs []models.Transaction, added, removed []string, actor, source string) {
+	audit, ok := s.dbClient.(models.AuditStore)
+	if !ok {
+		return
+	}
+	auditService := NewAuditService(s.dbClient, audit)
+	for _, before := range txs {
+		after := before
+		after.Tags = applyTagDiff(before.Tags, added, removed)
+		after.Version = before.Version + 1
+		if err := auditService.Record(before, after, actor, source, models.AuditActionTag); err != nil {
+			log.Printf("audit record failed id=%s action=%s err=%v", before.ID, models.AuditActionTag, err)
+		}
+	}
+}
+
+// TagTransactions adds tags to every listed transaction, creating tags that
+// do not exist yet.
+func (s *TagService) TagTransactions(ids []string, names []string) (int, error) {
+	tags, err := s.validateBulk(ids, names)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.EnsureTags(tags); err != nil {
+		return 0, err
+	}
+	return s.store.AddTransactionTags(ids, tags)
+}
+
+func (s *TagService) UntagTransactions(ids []string, names []string) (int, error) {
+	tags, err := s.validateBulk(ids, names)
+	if err != nil {
+		return 0, err
+	}
+	return s.store.RemoveTransactionTags(ids, tags)
+}
+
+// EnsureTags creates any of the given (already normalized) tags that are not
+// in the tag list yet.
+func (s *TagService) EnsureTags(tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	existing, err := s.store.ListTags()
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, tag := range existing {
+		known[tag.Name] = true
+	}
+	for _, tag := range tags {
+		if known[tag] {
+			continue
+		}
+		if err := s.store.SaveTag(models.Tag{Name: tag, CreatedAt: time.Now().UTC()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *TagService) validateBulk(ids []string, names []string) ([]string, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one transaction id is required")
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one tag is required")
+	}
+	return NormalizeTags(names)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	cases := map[string]string{
+		"Goa Trip 2026":  "goa-trip-2026",
+		" reimbursable ": "reimbursable",
+		"kids":           "kids",
+	}
+	for input, want := range cases {
+		got, err := NormalizeTag(input)
+		if err != nil {
+			t.Fatalf("NormalizeTag(%q) returned error: %v", input, err)
+		}
+		if got != want {
+			t.Fatalf("NormalizeTag(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if _, err := NormalizeTag("food/drinks"); err == nil {
+		t.Fatalf("expected error for tag with a slash")
+	}
+}
+
+func TestTagTransactionsCreatesMissingTags(t *testing.T) {
+	db := newTestDB(models.Transaction{ID: "tx-1", Vendor: "DECATHLON"}, models.Transaction{ID: "tx-2", Vendor: "HAMLEYS"})
+	db.tags["kids"] = models.Tag{Name: "kids", Description: "School and toys"}
+
+	updated, err := NewTagService(db, db).TagTransactions([]string{"tx-1", "tx-2"}, []string{"Kids", "Goa Trip 2026", "kids"})
+	if err != nil {
+		t.Fatalf("TagTransactions returned error: %v", err)
+	}
+
+	if updated != 2 {
+		t.Fatalf("expected 2 updated transactions, got %d", updated)
+	}
+	if tags := db.find("tx-1").Tags; len(tags) != 2 {
+		t.Fatalf("expected de-duplicated tags, got %v", tags)
+	}
+	if _, ok := db.tags["goa-trip-2026"]; !ok {
+		t.Fatalf("expected new tag to be created, got %v", db.tags)
+	}
+	if db.tags["kids"].Description != "School and toys" {
+		t.Fatalf("expected existing tag to be left alone, got %+v", db.tags["kids"])
+	}
+}
+
+func TestGetTagBreakdownCountsEachTag(t *testing.T) {
+	now := time.Now().UTC()
+	db := &reportingTestDB{transactions: []models.Transaction{
+		{Vendor: "INDIGO", Amount: models.FromRupees(8000), DateTime: now, Tags: []string{"goa-trip-2026", "reimbursable"}},
+		{Vendor: "TAJ", Amount: models.FromRupees(12000), DateTime: now, Tags: []string{"goa-trip-2026"}},
+		{Vendor: "SWIGGY", Amount: models.FromRupees(400), DateTime: now},
+	}}
+	reporting := NewReportingService(db)
+
+	items, err := reporting.GetTagBreakdown("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetTagBreakdown returned error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 tags, got %+v", items)
+	}
+	if items[0].Label != "goa-trip-2026" || items[0].Amount != models.FromRupees(20000) || items[0].Count != 2 {
+		t.Fatalf("unexpected first item %+v", items[0])
+	}
+
+	txs, err := reporting.ListTransactions("THIS_MONTH", TransactionFilter{Tags: []string{"goa-trip-2026", "reimbursable"}}, 0)
+	if err != nil {
+		t.Fatalf("ListTransactions returned error: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Vendor != "INDIGO" {
+		t.Fatalf("expected only the reimbursable flight, got %+v", txs)
+	}
+}
+
+func TestRenameAndDeleteTagRecordEachTransaction(t *testing.T) {
+	db := newTestDB(
+		models.Transaction{ID: "tx-1", Vendor: "INDIGO", Tags: []string{"goa-trip", "reimbursable"}},
+		models.Transaction{ID: "tx-2", Vendor: "TAJ", Tags: []string{"goa-trip"}},
+		models.Transaction{ID: "tx-3", Vendor: "SWIGGY"},
+	)
+	db.tags["goa-trip"] = models.Tag{Name: "goa-trip"}
+	db.tags["reimbursable"] = models.Tag{Name: "reimbursable"}
+	tags := NewTagService(db, db)
+
+	renamed, err := tags.RenameTag("goa-trip", "Goa Trip 2026", "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("RenameTag returned error: %v", err)
+	}
+	if renamed != 2 || len(db.changes) != 2 {
+		t.Fatalf("expected two renamed and recorded transactions, got %d and %d changes", renamed, len(db.changes))
+	}
+	change := db.changes[0]
+	if change.Action != models.AuditActionTag || change.Actor != "me@example.com" || change.Version != 1 {
+		t.Fatalf("unexpected change metadata: %+v", change)
+	}
+	if !change.Before.HasTag("goa-trip") || !change.After.HasTag("goa-trip-2026") || change.After.HasTag("goa-trip") {
+		t.Fatalf("expected the change to swap the tag, got %v -> %v", change.Before.Tags, change.After.Tags)
+	}
+
+	deleted, err := tags.DeleteTag("reimbursable", "me@example.com", models.AuditSourceUI)
+	if err != nil {
+		t.Fatalf("DeleteTag returned error: %v", err)
+	}
+	if deleted != 1 || len(db.changes) != 3 {
+		t.Fatalf("expected one more recorded transaction, got %d and %d changes", deleted, len(db.changes))
+	}
+	if last := db.changes[2]; last.Version != 2 || last.After.HasTag("reimbursable") {
+		t.Fatalf("expected the delete to be recorded at version 2, got %+v", last)
+	}
+}
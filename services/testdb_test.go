@@ -0,0 +1,627 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// testDB is an in-memory database for service tests. It implements
+// models.DatabaseClient and the optional stores the way the real backends
+// do: rows are copied in and out and writes bump the version.
+type testDB struct {
+	transactions []models.Transaction
+	mappings     map[string]models.CategoryMapping
+	memories     []models.Memory
+	matches      map[string]models.ReconciliationMatch
+	rates        []models.FXRate
+	tags         map[string]models.Tag
+	changes      []models.TransactionChange
+	deleted      []string // IDs removed by DeleteTransaction
+	rules        map[string]models.CategoryRule
+	settings     map[string]models.Setting
+	categories   map[string]models.Category
+	batches      [][]string // IDs of each bulk write
+	changeLogs   int        // calls to ListTransactionChangesFor
+	suggestions  map[string]models.CategorySuggestion
+	merchants    map[string]models.Merchant
+	fetches      int // calls to FetchTransactionsByDateRange
+	mappingReads int // calls to GetCategoryMapping
+	aggregates   []models.AggregateQuery
+	pages        []models.TransactionPageQuery
+	searches     map[string]models.SavedSearch
+}
+
+// newTestDB returns a database holding txs. Cached categorization
+// state is reset so nothing leaks in from an earlier test.
+func newTestDB(txs ...models.Transaction) *testDB {
+	invalidateCategoryRules()
+	invalidateCategoryTree()
+	invalidateMerchants()
+	return &testDB{
+		transactions: txs,
+		mappings:     map[string]models.CategoryMapping{},
+		matches:      map[string]models.ReconciliationMatch{},
+		tags:         map[string]models.Tag{},
+		rules:        map[string]models.CategoryRule{},
+		settings:     map[string]models.Setting{},
+		categories:   map[string]models.Category{},
+		suggestions:  map[string]models.CategorySuggestion{},
+		merchants:    map[string]models.Merchant{},
+		searches:     map[string]models.SavedSearch{},
+	}
+}
+
+// find returns the stored row with id, or nil.
+func (d *testDB) find(id string) *models.Transaction {
+	for i := range d.transactions {
+		if d.transactions[i].ID == id {
+			return &d.transactions[i]
+		}
+	}
+	return nil
+}
+
+func (d *testDB) SaveTransaction(txn models.Transaction) error {
+	if txn.ID == "" {
+		txn.ID = fmt.Sprintf("tx-%d", len(d.transactions)+1)
+	}
+	d.transactions = append(d.transactions, txn)
+	return nil
+}
+
+func (d *testDB) GetTransaction(id string) (*models.Transaction, error) {
+	tx := d.find(id)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s not found", id)
+	}
+	copied := *tx
+	return &copied, nil
+}
+
+func (d *testDB) UpdateTransaction(id string, txn models.Transaction) error {
+	tx := d.find(id)
+	if tx == nil {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	txn.ID, txn.Version = id, tx.Version+1
+	*tx = txn
+	return nil
+}
+
+func (d *testDB) PatchTransaction(id string, patch models.TransactionPatch, expectedVersion int64) (*models.Transaction, error) {
+	tx := d.find(id)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s not found", id)
+	}
+	if tx.Version != expectedVersion {
+		return nil, models.ErrVersionConflict
+	}
+	patch.Apply(tx)
+	tx.Version++
+	copied := *tx
+	return &copied, nil
+}
+
+func (d *testDB) DeleteTransaction(id string) error {
+	for i := range d.transactions {
+		if d.transactions[i].ID == id {
+			d.transactions = append(d.transactions[:i], d.transactions[i+1:]...)
+			d.deleted = append(d.deleted, id)
+			return nil
+		}
+	}
+	return fmt.Errorf("transaction %s not found", id)
+}
+
+func (d *testDB) FetchTransactionsByDateRange(from, to time.Time) ([]models.Transaction, error) {
+	d.fetches++
+	var txs []models.Transaction
+	for _, tx := range d.transactions {
+		if !tx.DateTime.Before(from) && !tx.DateTime.After(to) {
+			txs = append(txs, tx)
+		}
+	}
+	return txs, nil
+}
+
+func (d *testDB) GetLatestTransactionTimeByType(txType string) (*time.Time, error) {
+	var latest *time.Time
+	for _, tx := range d.transactions {
+		if tx.Type == txType && (latest == nil || tx.DateTime.After(*latest)) {
+			t := tx.DateTime
+			latest = &t
+		}
+	}
+	return latest, nil
+}
+
+func (d *testDB) SaveUnparsedEmail(body string, headers map[string]string) error { return nil }
+
+func (d *testDB) GetCategoryMapping(vendor string) (*models.CategoryMapping, error) {
+	d.mappingReads++
+	mapping, ok := d.mappings[vendor]
+	if !ok {
+		return nil, nil
+	}
+	return &mapping, nil
+}
+
+func (d *testDB) SaveCategoryMapping(mapping *models.CategoryMapping) error {
+	d.mappings[mapping.Vendor] = *mapping
+	return nil
+}
+
+func (d *testDB) SaveMemory(mem models.Memory) error {
+	d.memories = append(d.memories, mem)
+	return nil
+}
+
+func (d *testDB) GetAllMemories() ([]models.Memory, error) { return d.memories, nil }
+
+func (d *testDB) Close() error { return nil }
+
+func (d *testDB) MarkDuplicate(id, canonicalID string) error {
+	tx := d.find(id)
+	if tx == nil {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	tx.DuplicateOf = canonicalID
+	return nil
+}
+
+func (d *testDB) ListDuplicatesOf(canonicalID string) ([]models.Transaction, error) {
+	var duplicates []models.Transaction
+	for _, tx := range d.transactions {
+		if tx.DuplicateOf == canonicalID {
+			duplicates = append(duplicates, tx)
+		}
+	}
+	return duplicates, nil
+}
+
+func (d *testDB) SaveReconciliationMatch(match models.ReconciliationMatch) error {
+	if _, exists := d.matches[match.ID]; !exists {
+		d.matches[match.ID] = match
+	}
+	return nil
+}
+
+func (d *testDB) GetReconciliationMatch(id string) (*models.ReconciliationMatch, error) {
+	match, ok := d.matches[id]
+	if !ok {
+		return nil, fmt.Errorf("reconciliation match %s not found", id)
+	}
+	return &match, nil
+}
+
+func (d *testDB) ListReconciliationMatches(status string) ([]models.ReconciliationMatch, error) {
+	var matches []models.ReconciliationMatch
+	for _, match := range d.matches {
+		if status == "" || match.Status == status {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+func (d *testDB) UpdateReconciliationMatchStatus(id, status string) error {
+	match, ok := d.matches[id]
+	if !ok {
+		return fmt.Errorf("reconciliation match %s not found", id)
+	}
+	match.Status = status
+	d.matches[id] = match
+	return nil
+}
+
+// update applies fn to every stored row in ids, bumping its version, and
+// returns how many it found.
+func (d *testDB) update(ids []string, fn func(tx *models.Transaction)) int {
+	updated := 0
+	for _, id := range ids {
+		if tx := d.find(id); tx != nil {
+			fn(tx)
+			tx.Version++
+			updated++
+		}
+	}
+	return updated
+}
+
+func (d *testDB) SaveFXRates(rates []models.FXRate) error {
+	d.rates = append(d.rates, rates...)
+	return nil
+}
+
+func (d *testDB) GetFXRate(currency string, on time.Time) (*models.FXRate, error) {
+	var best *models.FXRate
+	for i, rate := range d.rates {
+		if rate.Currency != currency || rate.Date.After(on) {
+			continue
+		}
+		if best == nil || rate.Date.After(best.Date) {
+			best = &d.rates[i]
+		}
+	}
+	return best, nil
+}
+
+func (d *testDB) ListFXRates(currency string) ([]models.FXRate, error) {
+	var rates []models.FXRate
+	for _, rate := range d.rates {
+		if currency == "" || rate.Currency == currency {
+			rates = append(rates, rate)
+		}
+	}
+	return rates, nil
+}
+
+func (d *testDB) SetConvertedAmount(id string, amount models.Money, rate float64) error {
+	if d.update([]string{id}, func(tx *models.Transaction) { tx.Amount, tx.FXRate = amount, rate }) == 0 {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	return nil
+}
+
+func (d *testDB) SaveTag(tag models.Tag) error {
+	d.tags[tag.Name] = tag
+	return nil
+}
+
+func (d *testDB) ListTags() ([]models.Tag, error) {
+	var tags []models.Tag
+	for _, tag := range d.tags {
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+func (d *testDB) RenameTag(oldName, newName string) ([]models.Transaction, error) {
+	tag, ok := d.tags[oldName]
+	if !ok {
+		return nil, fmt.Errorf("tag %s not found", oldName)
+	}
+	delete(d.tags, oldName)
+	tag.Name = newName
+	d.tags[newName] = tag
+	tagged, ids := d.taggedWith(oldName)
+	d.update(ids, func(tx *models.Transaction) {
+		tx.Tags = applyTagDiff(tx.Tags, []string{newName}, []string{oldName, newName})
+	})
+	return tagged, nil
+}
+
+func (d *testDB) DeleteTag(name string) ([]models.Transaction, error) {
+	delete(d.tags, name)
+	tagged, ids := d.taggedWith(name)
+	d.update(ids, func(tx *models.Transaction) {
+		tx.Tags = applyTagDiff(tx.Tags, nil, []string{name})
+	})
+	return tagged, nil
+}
+
+func (d *testDB) taggedWith(tag string) ([]models.Transaction, []string) {
+	var tagged []models.Transaction
+	var ids []string
+	for _, tx := range d.transactions {
+		if tx.HasTag(tag) {
+			before := tx
+			before.Tags = append([]string(nil), tx.Tags...)
+			tagged = append(tagged, before)
+			ids = append(ids, tx.ID)
+		}
+	}
+	return tagged, ids
+}
+
+func (d *testDB) AddTransactionTags(ids []string, tags []string) (int, error) {
+	return d.update(ids, func(tx *models.Transaction) {
+		for _, tag := range tags {
+			if !tx.HasTag(tag) {
+				tx.Tags = append(tx.Tags, tag)
+			}
+		}
+	}), nil
+}
+
+func (d *testDB) RemoveTransactionTags(ids []string, tags []string) (int, error) {
+	return d.update(ids, func(tx *models.Transaction) { tx.Tags = applyTagDiff(tx.Tags, nil, tags) }), nil
+}
+
+func (d *testDB) SaveTransactionChange(change models.TransactionChange) error {
+	d.changes = append(d.changes, change)
+	return nil
+}
+
+func (d *testDB) ListTransactionChanges(transactionID string) ([]models.TransactionChange, error) {
+	var changes []models.TransactionChange
+	for _, change := range d.changes {
+		if change.TransactionID == transactionID {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (d *testDB) ListTransactionChangesFor(transactionIDs []string) (map[string][]models.TransactionChange, error) {
+	d.changeLogs++
+	byID := map[string][]models.TransactionChange{}
+	for _, id := range transactionIDs {
+		byID[id], _ = d.ListTransactionChanges(id)
+	}
+	return byID, nil
+}
+
+func (d *testDB) AddTransactionAttachment(id string, attachment models.Attachment) error {
+	if d.update([]string{id}, func(tx *models.Transaction) { tx.Attachments = append(tx.Attachments, attachment) }) == 0 {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	return nil
+}
+
+func (d *testDB) RemoveTransactionAttachment(id, attachmentID string) error {
+	updated := d.update([]string{id}, func(tx *models.Transaction) {
+		var remaining []models.Attachment
+		for _, attachment := range tx.Attachments {
+			if attachment.ID != attachmentID {
+				remaining = append(remaining, attachment)
+			}
+		}
+		tx.Attachments = remaining
+	})
+	if updated == 0 {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	return nil
+}
+
+func (d *testDB) TrashTransaction(id string, deletedAt time.Time) error {
+	if d.update([]string{id}, func(tx *models.Transaction) { tx.DeletedAt = &deletedAt }) == 0 {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	return nil
+}
+
+func (d *testDB) RestoreTransaction(id string) error {
+	if d.update([]string{id}, func(tx *models.Transaction) { tx.DeletedAt = nil }) == 0 {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	return nil
+}
+
+func (d *testDB) ListTrashedTransactions() ([]models.Transaction, error) {
+	var trashed []models.Transaction
+	for _, tx := range d.transactions {
+		if tx.IsTrashed() {
+			trashed = append(trashed, tx)
+		}
+	}
+	return trashed, nil
+}
+
+func (d *testDB) GetSetting(key string) (*models.Setting, error) {
+	setting, ok := d.settings[key]
+	if !ok {
+		return nil, nil
+	}
+	return &setting, nil
+}
+
+func (d *testDB) SaveSetting(setting models.Setting) error {
+	d.settings[setting.Key] = setting
+	return nil
+}
+
+func (d *testDB) ListCategoryRules() ([]models.CategoryRule, error) {
+	rules := make([]models.CategoryRule, 0, len(d.rules))
+	for _, rule := range d.rules {
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (d *testDB) SaveCategoryRule(rule models.CategoryRule) error {
+	d.rules[rule.ID] = rule
+	return nil
+}
+
+func (d *testDB) DeleteCategoryRule(id string) error {
+	if _, ok := d.rules[id]; !ok {
+		return fmt.Errorf("category rule %s not found", id)
+	}
+	delete(d.rules, id)
+	return nil
+}
+
+func (d *testDB) ListCategories() ([]models.Category, error) {
+	var categories []models.Category
+	for _, category := range d.categories {
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+func (d *testDB) SaveCategory(category models.Category) error {
+	d.categories[category.ID] = category
+	return nil
+}
+
+func (d *testDB) DeleteCategory(id string) error {
+	if _, ok := d.categories[id]; !ok {
+		return fmt.Errorf("category %s not found", id)
+	}
+	delete(d.categories, id)
+	return nil
+}
+
+func (d *testDB) ListCategoryMappings() ([]models.CategoryMapping, error) {
+	var mappings []models.CategoryMapping
+	for _, mapping := range d.mappings {
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+func (d *testDB) SetTransactionSplits(id string, splits []models.Split) error {
+	if d.update([]string{id}, func(tx *models.Transaction) { tx.Splits = splits }) == 0 {
+		return fmt.Errorf("transaction %s not found", id)
+	}
+	return nil
+}
+
+func (d *testDB) SetTransactionsCategory(ids []string, category string) (int, error) {
+	d.batches = append(d.batches, ids)
+	return d.update(ids, func(tx *models.Transaction) { tx.Category = category }), nil
+}
+
+func (d *testDB) TrashTransactions(ids []string, deletedAt time.Time) (int, error) {
+	d.batches = append(d.batches, ids)
+	return d.update(ids, func(tx *models.Transaction) { tx.DeletedAt = &deletedAt }), nil
+}
+
+func (d *testDB) SaveCategorySuggestion(suggestion models.CategorySuggestion) error {
+	if _, ok := d.suggestions[suggestion.ID]; !ok {
+		d.suggestions[suggestion.ID] = suggestion
+	}
+	return nil
+}
+
+func (d *testDB) GetCategorySuggestion(id string) (*models.CategorySuggestion, error) {
+	suggestion, ok := d.suggestions[id]
+	if !ok {
+		return nil, fmt.Errorf("category suggestion %s not found", id)
+	}
+	return &suggestion, nil
+}
+
+func (d *testDB) ListCategorySuggestions(status string) ([]models.CategorySuggestion, error) {
+	var suggestions []models.CategorySuggestion
+	for _, suggestion := range d.suggestions {
+		if status == "" || suggestion.Status == status {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions, nil
+}
+
+func (d *testDB) UpdateCategorySuggestionStatus(id, status string) error {
+	suggestion, ok := d.suggestions[id]
+	if !ok {
+		return fmt.Errorf("category suggestion %s not found", id)
+	}
+	suggestion.Status = status
+	d.suggestions[id] = suggestion
+	return nil
+}
+
+func (d *testDB) ListMerchants() ([]models.Merchant, error) {
+	var merchants []models.Merchant
+	for _, merchant := range d.merchants {
+		merchants = append(merchants, merchant)
+	}
+	return merchants, nil
+}
+
+func (d *testDB) SaveMerchant(merchant models.Merchant) error {
+	d.merchants[merchant.ID] = merchant
+	return nil
+}
+
+func (d *testDB) DeleteMerchant(id string) error {
+	if _, ok := d.merchants[id]; !ok {
+		return fmt.Errorf("merchant %s not found", id)
+	}
+	delete(d.merchants, id)
+	return nil
+}
+
+func (d *testDB) SetTransactionsMerchant(ids []string, merchant string) (int, error) {
+	d.batches = append(d.batches, ids)
+	written := 0
+	for _, id := range ids {
+		if tx := d.find(id); tx != nil {
+			tx.Merchant = merchant
+			written++
+		}
+	}
+	return written, nil
+}
+
+func (d *testDB) AggregateTransactions(query models.AggregateQuery) ([]models.TransactionAggregate, error) {
+	d.aggregates = append(d.aggregates, query)
+	return models.GroupTransactions(d.transactions, query), nil
+}
+
+func (d *testDB) ListTransactionPage(query models.TransactionPageQuery) ([]models.Transaction, error) {
+	d.pages = append(d.pages, query)
+	sorting := TransactionSort{Field: SortByDate, Desc: query.Desc}
+	switch query.SortField {
+	case models.PageSortAmount:
+		sorting.Field = SortByAmount
+	case models.PageSortVendor:
+		sorting.Field = SortByVendor
+	}
+
+	var txs []models.Transaction
+	for _, tx := range d.transactions {
+		if !pageQueryMatches(query, tx) || (query.After != nil && !sorting.less(*query.After, tx)) {
+			continue
+		}
+		txs = append(txs, tx)
+	}
+	sort.Slice(txs, func(i, j int) bool { return sorting.less(txs[i], txs[j]) })
+	if query.Limit > 0 && len(txs) > query.Limit {
+		txs = txs[:query.Limit]
+	}
+	return txs, nil
+}
+
+func (d *testDB) CountTransactions(query models.TransactionPageQuery) (int, error) {
+	count := 0
+	for _, tx := range d.transactions {
+		if pageQueryMatches(query, tx) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func pageQueryMatches(query models.TransactionPageQuery, tx models.Transaction) bool {
+	if tx.DateTime.Before(query.From) || tx.DateTime.After(query.To) || tx.IsDuplicate() || tx.IsTrashed() {
+		return false
+	}
+	if query.Type != "" && !strings.EqualFold(tx.Type, query.Type) {
+		return false
+	}
+	for _, tag := range query.Tags {
+		if !tx.HasTag(tag) {
+			return false
+		}
+	}
+	if query.MinAmount != nil && tx.Amount < *query.MinAmount {
+		return false
+	}
+	return query.MaxAmount == nil || tx.Amount <= *query.MaxAmount
+}
+
+func (d *testDB) ListSavedSearches() ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	for _, search := range d.searches {
+		searches = append(searches, search)
+	}
+	return searches, nil
+}
+
+func (d *testDB) SaveSavedSearch(search models.SavedSearch) error {
+	d.searches[search.ID] = search
+	return nil
+}
+
+func (d *testDB) DeleteSavedSearch(id string) error {
+	delete(d.searches, id)
+	return nil
+}
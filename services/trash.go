@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+// DefaultTrashRetention is how long a deleted transaction can be restored
+// before it is purged for good.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+type TrashService struct {
+	dbClient models.DatabaseClient
+	store    models.TrashStore
+	blobs    models.BlobStore
+}
+
+// NewTrashService creates the trash service. blobs is only used by Purge to
+// remove attachment files and may be nil otherwise.
+func NewTrashService(dbClient models.DatabaseClient, store models.TrashStore, blobs models.BlobStore) *TrashService {
+	return &TrashService{dbClient: dbClient, store: store, blobs: blobs}
+}
+
+// Trash moves a transaction to the trash, where reporting ignores it. If it
+// was the canonical record of a reconciled payment, one of its duplicates
+// takes its place so the payment is still counted.
+func (s *TrashService) Trash(id, actor, source string) (*models.Transaction, error) {
+	tx, err := s.dbClient.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if tx.IsTrashed() {
+		return nil, fmt.Errorf("transaction %s is already in the trash", id)
+	}
+	if err := promoteDuplicates(s.dbClient, id); err != nil {
+		return nil, err
+	}
+	deletedAt := time.Now().UTC()
+	if err := s.store.TrashTransaction(id, deletedAt); err != nil {
+		return nil, err
+	}
+	trashed := *tx
+	trashed.DeletedAt = &deletedAt
+	trashed.Version++
+	RecordTransactionChange(s.dbClient, *tx, trashed, actor, source, models.AuditActionDelete)
+	return &trashed, nil
+}
+
+// Restore takes a transaction out of the trash. A transaction whose
+// duplicates were promoted when it was trashed comes back unlinked, for the
+// next reconciliation run to pair up again.
+func (s *TrashService) Restore(id, actor, source string) (*models.Transaction, error) {
+	tx, err := s.dbClient.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if !tx.IsTrashed() {
+		return nil, fmt.Errorf("transaction %s is not in the trash", id)
+	}
+	if err := s.store.RestoreTransaction(id); err != nil {
+		return nil, err
+	}
+	restored := *tx
+	restored.DeletedAt = nil
+	restored.Version++
+	RecordTransactionChange(s.dbClient, *tx, restored, actor, source, models.AuditActionRestore)
+	return &restored, nil
+}
+
+func (s *TrashService) List() ([]models.Transaction, error) {
+	return s.store.ListTrashedTransactions()
+}
+
+// Purge permanently deletes transactions that have been in the trash for
+// longer than retention, together with their attachment files.
+func (s *TrashService) Purge(retention time.Duration, now time.Time) (int, error) {
+	trashed, err := s.store.ListTrashedTransactions()
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := now.Add(-retention)
+	purged := 0
+	for _, tx := range trashed {
+		if tx.DeletedAt == nil || tx.DeletedAt.After(cutoff) {
+			continue
+		}
+		// Rows trashed before duplicates were promoted on trash may still
+		// have duplicates linked to them.
+		if err := promoteDuplicates(s.dbClient, tx.ID); err != nil {
+			return purged, fmt.Errorf("failed to purge transaction %s: %v", tx.ID, err)
+		}
+		if err := s.dbClient.DeleteTransaction(tx.ID); err != nil {
+			return purged, fmt.Errorf("failed to purge transaction %s: %v", tx.ID, err)
+		}
+		purged++
+		for _, attachment := range tx.Attachments {
+			if s.blobs == nil {
+				log.Printf("trash purge left attachment blob id=%s key=%s err=no blob store", tx.ID, attachment.Key)
+				continue
+			}
+			if err := s.blobs.Delete(attachment.Key); err != nil {
+				log.Printf("trash purge attachment delete failed id=%s key=%s err=%v", tx.ID, attachment.Key, err)
+			}
+		}
+	}
+	return purged, nil
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+)
+
+func TestTrashedTransactionsAreLeftOutOfReportsUntilRestored(t *testing.T) {
+	now := time.Now().UTC()
+	db := newTestDB(
+		models.Transaction{ID: "tx-1", Vendor: "ZOMATO", Category: "Food", Amount: models.FromRupees(300), DateTime: now},
+		models.Transaction{ID: "tx-2", Vendor: "SWIGGY", Category: "Food", Amount: models.FromRupees(200), DateTime: now},
+	)
+	trash := NewTrashService(db, db, nil)
+	reporting := NewReportingService(db)
+
+	if _, err := trash.Trash("tx-1", "me@example.com", models.AuditSourceUI); err != nil {
+		t.Fatalf("Trash returned error: %v", err)
+	}
+	if _, err := trash.Trash("tx-1", "me@example.com", models.AuditSourceUI); err == nil || !strings.Contains(err.Error(), "already in the trash") {
+		t.Fatalf("expected error when trashing twice, got %v", err)
+	}
+
+	summary, err := reporting.GetTotalSummary("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetTotalSummary returned error: %v", err)
+	}
+	if summary.TotalAmount != models.FromRupees(200) {
+		t.Fatalf("expected trashed spend to be excluded, got %s", summary.TotalAmount)
+	}
+
+	if _, err := trash.Restore("tx-1", "me@example.com", models.AuditSourceUI); err != nil {
+		t.Fatalf("Restore returned error: %v", err)
+	}
+	summary, err = reporting.GetTotalSummary("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetTotalSummary returned error: %v", err)
+	}
+	if summary.TotalAmount != models.FromRupees(500) {
+		t.Fatalf("expected restored spend to count again, got %s", summary.TotalAmount)
+	}
+}
+
+func TestPurgeDeletesOnlyExpiredTrashWithAttachments(t *testing.T) {
+	blobs, err := models.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalBlobStore returned error: %v", err)
+	}
+	if _, err := blobs.Put("tx-old/receipt", "application/pdf", strings.NewReader("%PDF-1.4")); err != nil {
+		t.Fatalf("Put returned error: %v", err)
+	}
+
+	now := time.Date(2026, 6, 30, 12, 0, 0, 0, time.UTC)
+	old, recent := now.AddDate(0, 0, -31), now.AddDate(0, 0, -3)
+	db := newTestDB(
+		models.Transaction{ID: "tx-old", Vendor: "CROMA", DeletedAt: &old, Attachments: []models.Attachment{{ID: "receipt", Key: "tx-old/receipt"}}},
+		models.Transaction{ID: "tx-recent", Vendor: "IKEA", DeletedAt: &recent},
+		models.Transaction{ID: "tx-live", Vendor: "DMART"},
+	)
+
+	purged, err := NewTrashService(db, db, blobs).Purge(DefaultTrashRetention, now)
+	if err != nil {
+		t.Fatalf("Purge returned error: %v", err)
+	}
+	if purged != 1 || len(db.deleted) != 1 || db.deleted[0] != "tx-old" {
+		t.Fatalf("expected only tx-old to be purged, got %d %v", purged, db.deleted)
+	}
+	if _, err := blobs.Get("tx-old/receipt"); err != models.ErrBlobNotFound {
+		t.Fatalf("expected attachment blob to be deleted, got %v", err)
+	}
+}
+
+func TestTrashPromotesDuplicateOfReconciledPayment(t *testing.T) {
+	now := time.Now().UTC()
+	db := newTestDB(
+		models.Transaction{ID: "bank-1", Type: "BankTransfer", Amount: models.FromRupees(1100), DateTime: now},
+		models.Transaction{ID: "manual-1", Type: "Manual", Amount: models.FromRupees(1100), DateTime: now, DuplicateOf: "bank-1"},
+		models.Transaction{ID: "gpay-1", Type: GooglePayTransactionType, Amount: models.FromRupees(1100), DateTime: now, DuplicateOf: "bank-1"},
+	)
+
+	if _, err := NewTrashService(db, db, nil).Trash("bank-1", "me@example.com", models.AuditSourceUI); err != nil {
+		t.Fatalf("Trash returned error: %v", err)
+	}
+	if db.find("gpay-1").IsDuplicate() || db.find("manual-1").DuplicateOf != "gpay-1" {
+		t.Fatalf("expected the Google Pay row to become canonical, got gpay-1=%q manual-1=%q", db.find("gpay-1").DuplicateOf, db.find("manual-1").DuplicateOf)
+	}
+	summary, err := NewReportingService(db).GetTotalSummary("THIS_MONTH")
+	if err != nil {
+		t.Fatalf("GetTotalSummary returned error: %v", err)
+	}
+	if summary.TransactionCount != 1 || summary.TotalAmount != models.FromRupees(1100) {
+		t.Fatalf("expected the payment to be counted once, got %+v", summary)
+	}
+}
+
+func TestPurgeUnlinksDuplicatesOfPurgedTransaction(t *testing.T) {
+	now := time.Date(2026, 6, 30, 12, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -31)
+	db := newTestDB(
+		models.Transaction{ID: "bank-1", Type: "BankTransfer", DeletedAt: &old},
+		models.Transaction{ID: "gpay-1", Type: GooglePayTransactionType, DuplicateOf: "bank-1"},
+	)
+
+	if _, err := NewTrashService(db, db, nil).Purge(DefaultTrashRetention, now); err != nil {
+		t.Fatalf("Purge returned error: %v", err)
+	}
+	if db.find("bank-1") != nil || db.find("gpay-1").IsDuplicate() {
+		t.Fatalf("expected bank-1 purged and gpay-1 unlinked, got %+v", db.transactions)
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+// Trend splits for GetTrend.
+const (
+	TrendSplitCategory = "category"
+	TrendSplitSource   = "source"
+)
+
+// maxTrendBuckets keeps a long range at a fine granularity, such as years of
+// days, from producing an unusable response.
+const maxTrendBuckets = 1000
+
+// Trend is spend over a period with a point for every day, week, month or
+// year bucket, including empty ones, so charts get a continuous axis. Points
+// are dated by the first day of their bucket; weeks start on Monday.
+type Trend struct {
+	Period      string       `json:"period"`
+	Granularity string       `json:"granularity"`
+	Split       string       `json:"split,omitempty"`
+	Timezone    string       `json:"timezone"`
+	Points      []TrendPoint `json:"items"`
+	// Series are one per top-level category or source, largest first; their
+	// amounts stack up to Points. A split transaction counts in each of its
+	// categories, so series counts can add up to more than the point count.
+	Series []TrendSeries `json:"series,omitempty"`
+}
+
+type TrendSeries struct {
+	Name   string       `json:"name"`
+	Amount models.Money `json:"amount"`
+	Points []TrendPoint `json:"items"`
+}
+
+// GetTrend buckets a period by granularity (day, week, month or year; day
+// when empty), optionally split by category or source.
+func (s *ReportingService) GetTrend(period, granularity, split string) (Trend, error) {
+	groupBy, err := parseTrendGranularity(granularity)
+	if err != nil {
+		return Trend{}, err
+	}
+	trend := Trend{
+		Period:      normalizePeriod(period),
+		Granularity: string(groupBy),
+		Split:       strings.ToLower(strings.TrimSpace(split)),
+		Timezone:    utils.UserLocation().String(),
+	}
+
+	var splitBy models.AggregateGroup
+	var seriesName func(string) string
+	switch trend.Split {
+	case "":
+	case TrendSplitCategory:
+		tree := s.CategoryTree()
+		splitBy = models.AggregateByCategory
+		seriesName = func(category string) string { return tree.Path(category)[0] }
+	case TrendSplitSource:
+		splitBy = models.AggregateByType
+		seriesName = sourceLabel
+	default:
+		return Trend{}, fmt.Errorf("invalid split %q, expected category or source", split)
+	}
+
+	start, end, err := utils.ResolvePeriod(trend.Period)
+	if err != nil {
+		return Trend{}, err
+	}
+	keys, err := trendBuckets(groupBy, start, end)
+	if err != nil {
+		return Trend{}, err
+	}
+
+	// Totals come from an unsplit aggregate: a category split counts a split
+	// transaction once per line, which would inflate the point counts.
+	groups, err := s.Aggregate(models.AggregateQuery{From: start, To: end, GroupBy: groupBy})
+	if err != nil {
+		return Trend{}, err
+	}
+	total := map[string]*TrendPoint{}
+	for _, group := range groups {
+		addTrendGroup(total, group)
+	}
+	trend.Points = fillTrendPoints(keys, total)
+	if seriesName == nil {
+		return trend, nil
+	}
+
+	groups, err = s.Aggregate(models.AggregateQuery{From: start, To: end, GroupBy: groupBy, Split: splitBy})
+	if err != nil {
+		return Trend{}, err
+	}
+	series := map[string]map[string]*TrendPoint{}
+	var names []string
+	for _, group := range groups {
+		name := seriesName(group.Series)
+		if series[name] == nil {
+			series[name] = map[string]*TrendPoint{}
+			names = append(names, name)
+		}
+		addTrendGroup(series[name], group)
+	}
+
+	for _, name := range names {
+		filled := fillTrendPoints(keys, series[name])
+		var amount models.Money
+		for _, point := range filled {
+			amount += point.Amount
+		}
+		trend.Series = append(trend.Series, TrendSeries{Name: name, Amount: amount, Points: filled})
+	}
+	sort.Slice(trend.Series, func(i, j int) bool {
+		if trend.Series[i].Amount != trend.Series[j].Amount {
+			return trend.Series[i].Amount > trend.Series[j].Amount
+		}
+		return trend.Series[i].Name < trend.Series[j].Name
+	})
+	return trend, nil
+}
+
+func parseTrendGranularity(granularity string) (models.AggregateGroup, error) {
+	switch groupBy := models.AggregateGroup(strings.ToLower(strings.TrimSpace(granularity))); groupBy {
+	case "":
+		return models.AggregateByDay, nil
+	case models.AggregateByDay, models.AggregateByWeek, models.AggregateByMonth, models.AggregateByYear:
+		return groupBy, nil
+	default:
+		return "", fmt.Errorf("invalid granularity %q, expected day, week, month or year", granularity)
+	}
+}
+
+// trendBuckets returns the keys of every bucket from start to end.
+func trendBuckets(groupBy models.AggregateGroup, start, end time.Time) ([]string, error) {
+	loc := utils.UserLocation()
+	var keys []string
+	for bucket := groupBy.BucketStart(start, loc); !bucket.After(end); bucket = groupBy.NextBucket(bucket) {
+		if len(keys) == maxTrendBuckets {
+			return nil, fmt.Errorf("period has more than %d %s buckets, use a coarser granularity", maxTrendBuckets, groupBy)
+		}
+		keys = append(keys, bucket.Format("2006-01-02"))
+	}
+	return keys, nil
+}
+
+func addTrendGroup(points map[string]*TrendPoint, group models.TransactionAggregate) {
+	point, ok := points[group.Key]
+	if !ok {
+		point = &TrendPoint{Date: group.Key}
+		points[group.Key] = point
+	}
+	point.Amount += group.Amount
+	point.Count += group.Count
+}
+
+// fillTrendPoints lists a point for every key, zero where there is none.
+func fillTrendPoints(keys []string, points map[string]*TrendPoint) []TrendPoint {
+	filled := make([]TrendPoint, len(keys))
+	for i, key := range keys {
+		filled[i] = TrendPoint{Date: key}
+		if point, ok := points[key]; ok {
+			filled[i] = *point
+		}
+	}
+	return filled
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yourusername/expense-tracker/models"
+	"github.com/yourusername/expense-tracker/utils"
+)
+
+func TestGetTrendZeroFillsWeeks(t *testing.T) {
+	loc := utils.UserLocation()
+	db := &reportingTestDB{transactions: []models.Transaction{
+		{ID: "thu", Amount: models.FromRupees(100), DateTime: time.Date(2026, 1, 1, 12, 0, 0, 0, loc)},
+		{ID: "sun", Amount: models.FromRupees(50), DateTime: time.Date(2026, 1, 4, 23, 30, 0, 0, loc)},
+		{ID: "late", Amount: models.FromRupees(70), DateTime: time.Date(2026, 1, 27, 9, 0, 0, 0, loc)},
+	}}
+
+	trend, err := NewReportingService(db).GetTrend("2026-01-01..2026-01-31", "week", "")
+	if err != nil {
+		t.Fatalf("GetTrend returned error: %v", err)
+	}
+	want := []TrendPoint{
+		{Date: "2025-12-29", Amount: models.FromRupees(150), Count: 2},
+		{Date: "2026-01-05"},
+		{Date: "2026-01-12"},
+		{Date: "2026-01-19"},
+		{Date: "2026-01-26", Amount: models.FromRupees(70), Count: 1},
+	}
+	if len(trend.Points) != len(want) {
+		t.Fatalf("expected %d weeks, got %+v", len(want), trend.Points)
+	}
+	for i := range want {
+		if trend.Points[i] != want[i] {
+			t.Fatalf("week %d: expected %+v, got %+v", i, want[i], trend.Points[i])
+		}
+	}
+
+	if _, err := NewReportingService(db).GetTrend("2026-01-01..2026-01-31", "fortnight", ""); err == nil {
+		t.Fatalf("expected an invalid granularity to fail")
+	}
+}
+
+func TestGetTrendSplitsByTopLevelCategory(t *testing.T) {
+	loc := utils.UserLocation()
+	db := newTestDB(
+		models.Transaction{ID: "dinner", Category: "Dining Out", Amount: models.FromRupees(800), DateTime: time.Date(2026, 2, 10, 20, 0, 0, 0, loc)},
+		models.Transaction{ID: "mall", Category: "Food", Amount: models.FromRupees(1000), DateTime: time.Date(2026, 1, 5, 20, 0, 0, 0, loc), Splits: []models.Split{
+			{Category: "Food", Amount: models.FromRupees(300)},
+			{Category: "Shopping", Amount: models.FromRupees(700)},
+		}},
+	)
+	db.categories["food"] = models.Category{ID: "food", Name: "Food"}
+	db.categories["dining"] = models.Category{ID: "dining", Name: "Dining Out", Parent: "Food"}
+
+	trend, err := NewReportingService(db).GetTrend("2025-12-15..2026-02-28", "month", "category")
+	if err != nil {
+		t.Fatalf("GetTrend returned error: %v", err)
+	}
+	if len(trend.Points) != 3 || trend.Points[1].Amount != models.FromRupees(1000) || trend.Points[2].Amount != models.FromRupees(800) {
+		t.Fatalf("expected December to February totals, got %+v", trend.Points)
+	}
+	if len(trend.Series) != 2 {
+		t.Fatalf("expected Food and Shopping series, got %+v", trend.Series)
+	}
+	food, shopping := trend.Series[0], trend.Series[1]
+	if food.Name != "Food" || food.Amount != models.FromRupees(1100) || len(food.Points) != 3 || food.Points[0].Amount != 0 || food.Points[2].Amount != models.FromRupees(800) {
+		t.Fatalf("unexpected Food series %+v", food)
+	}
+	if shopping.Name != "Shopping" || shopping.Amount != models.FromRupees(700) || shopping.Points[1].Date != "2026-01-01" {
+		t.Fatalf("unexpected Shopping series %+v", shopping)
+	}
+}
+
+func TestGetTrendCountsMatchWithAndWithoutSplit(t *testing.T) {
+	loc := utils.UserLocation()
+	db := &reportingTestDB{transactions: []models.Transaction{
+		{ID: "mall", Category: "Food", Amount: models.FromRupees(1000), DateTime: time.Date(2026, 1, 5, 20, 0, 0, 0, loc), Splits: []models.Split{
+			{Category: "Food", Amount: models.FromRupees(300)},
+			{Category: "Shopping", Amount: models.FromRupees(700)},
+		}},
+		{ID: "cab", Category: "Travel", Amount: models.FromRupees(250), DateTime: time.Date(2026, 1, 6, 9, 0, 0, 0, loc)},
+	}}
+	reporting := NewReportingService(db)
+
+	plain, err := reporting.GetTrend("2026-01-01..2026-01-31", "month", "")
+	if err != nil {
+		t.Fatalf("GetTrend returned error: %v", err)
+	}
+	split, err := reporting.GetTrend("2026-01-01..2026-01-31", "month", "category")
+	if err != nil {
+		t.Fatalf("GetTrend returned error: %v", err)
+	}
+	if len(plain.Points) != 1 || plain.Points[0].Count != 2 || plain.Points[0].Amount != models.FromRupees(1250) {
+		t.Fatalf("expected 2 transactions worth 1250, got %+v", plain.Points)
+	}
+	if len(split.Points) != 1 || split.Points[0] != plain.Points[0] {
+		t.Fatalf("expected the split trend to report the same totals %+v, got %+v", plain.Points, split.Points)
+	}
+	if len(split.Series) != 3 {
+		t.Fatalf("expected Food, Shopping and Travel series, got %+v", split.Series)
+	}
+}
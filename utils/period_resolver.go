@@ -1,23 +1,47 @@
 package utils
 
 import (
+	"regexp"
+	"strconv"
 	"time"
 )
 
-// ResolvePeriod converts a Period enum string to start and end UTC timestamps
+var (
+	lastNDaysRe   = regexp.MustCompile(`^LAST_(\d+)_DAYS$`)
+	lastNMonthsRe = regexp.MustCompile(`^LAST_(\d+)_MONTHS$`)
+	quarterRe     = regexp.MustCompile(`^(\d{4})-Q([1-4])$`)
+	fyRe          = regexp.MustCompile(`^FY(\d{4})-(\d{2})$`)
+	monthRe       = regexp.MustCompile(`^\d{4}-\d{2}$`)
+	dayRe         = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	dateRangeRe   = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\.\.(\d{4}-\d{2}-\d{2})$`)
+)
+
+// ResolvePeriod converts a period to start and end timestamps. Besides the
+// named periods (TODAY, THIS_WEEK, LAST_MONTH, THIS_QUARTER, THIS_YEAR,
+// THIS_FY, ...) it accepts rolling windows (LAST_30_DAYS, LAST_12_MONTHS), a
+// calendar quarter (2026-Q1), an Indian financial year running April to March
+// (FY2025-26), a month (2026-04), a day (2026-04-19) and a date range
+// (2026-04-01..2026-04-15). Days and weeks begin at midnight in the user's
+// time zone (see UserLocation).
 func ResolvePeriod(period string) (time.Time, time.Time, error) {
-	now := time.Now().UTC()
+	return ResolvePeriodAt(period, time.Now())
+}
+
+// ResolvePeriodAt resolves period relative to now.
+func ResolvePeriodAt(period string, now time.Time) (time.Time, time.Time, error) {
+	loc := UserLocation()
+	now = now.In(loc)
 	var start, end time.Time
 
 	switch period {
 	case "TODAY":
-		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-		end = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, time.UTC)
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		end = time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 999999999, loc)
 
 	case "YESTERDAY":
 		yesterday := now.AddDate(0, 0, -1)
-		start = time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, time.UTC)
-		end = time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 23, 59, 59, 999999999, time.UTC)
+		start = time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, loc)
+		end = time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 23, 59, 59, 999999999, loc)
 
 	case "THIS_WEEK":
 		// Week starts on Monday (ISO 8601)
@@ -27,7 +51,7 @@ func ResolvePeriod(period string) (time.Time, time.Time, error) {
 		}
 		daysFromMonday := weekday - 1
 		startOfWeek := now.AddDate(0, 0, -daysFromMonday)
-		start = time.Date(startOfWeek.Year(), startOfWeek.Month(), startOfWeek.Day(), 0, 0, 0, 0, time.UTC)
+		start = time.Date(startOfWeek.Year(), startOfWeek.Month(), startOfWeek.Day(), 0, 0, 0, 0, loc)
 		end = now
 
 	case "LAST_WEEK":
@@ -40,32 +64,152 @@ func ResolvePeriod(period string) (time.Time, time.Time, error) {
 		startOfThisWeek := now.AddDate(0, 0, -daysFromMonday)
 		startOfLastWeek := startOfThisWeek.AddDate(0, 0, -7)
 		endOfLastWeek := startOfThisWeek.AddDate(0, 0, -1)
-		start = time.Date(startOfLastWeek.Year(), startOfLastWeek.Month(), startOfLastWeek.Day(), 0, 0, 0, 0, time.UTC)
-		end = time.Date(endOfLastWeek.Year(), endOfLastWeek.Month(), endOfLastWeek.Day(), 23, 59, 59, 999999999, time.UTC)
+		start = time.Date(startOfLastWeek.Year(), startOfLastWeek.Month(), startOfLastWeek.Day(), 0, 0, 0, 0, loc)
+		end = time.Date(endOfLastWeek.Year(), endOfLastWeek.Month(), endOfLastWeek.Day(), 23, 59, 59, 999999999, loc)
 
 	case "THIS_MONTH":
-		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 		end = now
 
 	case "LAST_MONTH":
-		firstOfThisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		firstOfThisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 		lastOfLastMonth := firstOfThisMonth.AddDate(0, 0, -1)
-		firstOfLastMonth := time.Date(lastOfLastMonth.Year(), lastOfLastMonth.Month(), 1, 0, 0, 0, 0, time.UTC)
+		firstOfLastMonth := time.Date(lastOfLastMonth.Year(), lastOfLastMonth.Month(), 1, 0, 0, 0, 0, loc)
 		start = firstOfLastMonth
-		end = time.Date(lastOfLastMonth.Year(), lastOfLastMonth.Month(), lastOfLastMonth.Day(), 23, 59, 59, 999999999, time.UTC)
+		end = time.Date(lastOfLastMonth.Year(), lastOfLastMonth.Month(), lastOfLastMonth.Day(), 23, 59, 59, 999999999, loc)
+
+	case "THIS_QUARTER":
+		start = quarterStart(now)
+		end = now
+
+	case "LAST_QUARTER":
+		start = quarterStart(now).AddDate(0, -3, 0)
+		end = quarterStart(now).Add(-time.Nanosecond)
+
+	case "THIS_YEAR":
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
+		end = now
+
+	case "LAST_YEAR":
+		start = time.Date(now.Year()-1, 1, 1, 0, 0, 0, 0, loc)
+		end = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+
+	case "THIS_FY":
+		start = financialYearStart(now)
+		end = now
+
+	case "LAST_FY":
+		start = financialYearStart(now).AddDate(-1, 0, 0)
+		end = financialYearStart(now).Add(-time.Nanosecond)
 
 	default:
-		return time.Time{}, time.Time{}, &InvalidPeriodError{Period: period}
+		return resolvePeriodExpression(period, now)
 	}
 
 	return start, end, nil
 }
 
+// resolvePeriodExpression handles the periods that carry a number or a date.
+// Rolling windows end now; LAST_30_DAYS is today and the 29 days before it,
+// LAST_12_MONTHS this month and the 11 whole months before it.
+func resolvePeriodExpression(period string, now time.Time) (time.Time, time.Time, error) {
+	loc := now.Location()
+	invalid := func(reason string) (time.Time, time.Time, error) {
+		return time.Time{}, time.Time{}, &InvalidPeriodError{Period: period, Reason: reason}
+	}
+
+	if m := lastNDaysRe.FindStringSubmatch(period); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 {
+			return invalid("the window must be at least one day")
+		}
+		return time.Date(now.Year(), now.Month(), now.Day()-(n-1), 0, 0, 0, 0, loc), now, nil
+	}
+
+	if m := lastNMonthsRe.FindStringSubmatch(period); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 {
+			return invalid("the window must be at least one month")
+		}
+		return time.Date(now.Year(), now.Month()-time.Month(n-1), 1, 0, 0, 0, 0, loc), now, nil
+	}
+
+	if m := quarterRe.FindStringSubmatch(period); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		quarter, _ := strconv.Atoi(m[2])
+		start := time.Date(year, time.Month(3*(quarter-1)+1), 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(0, 3, 0).Add(-time.Nanosecond), nil
+	}
+
+	if m := fyRe.FindStringSubmatch(period); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		next, _ := strconv.Atoi(m[2])
+		if (year+1)%100 != next {
+			return invalid("a financial year spans two consecutive years, e.g. FY2025-26")
+		}
+		start := time.Date(year, time.April, 1, 0, 0, 0, 0, loc)
+		return start, start.AddDate(1, 0, 0).Add(-time.Nanosecond), nil
+	}
+
+	if monthRe.MatchString(period) {
+		start, err := time.ParseInLocation("2006-01", period, loc)
+		if err != nil {
+			return invalid("expected YYYY-MM")
+		}
+		return start, start.AddDate(0, 1, 0).Add(-time.Nanosecond), nil
+	}
+
+	if dayRe.MatchString(period) {
+		start, err := time.ParseInLocation("2006-01-02", period, loc)
+		if err != nil {
+			return invalid("expected YYYY-MM-DD")
+		}
+		return start, start.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	if m := dateRangeRe.FindStringSubmatch(period); m != nil {
+		start, err := time.ParseInLocation("2006-01-02", m[1], loc)
+		if err != nil {
+			return invalid("expected YYYY-MM-DD..YYYY-MM-DD")
+		}
+		last, err := time.ParseInLocation("2006-01-02", m[2], loc)
+		if err != nil {
+			return invalid("expected YYYY-MM-DD..YYYY-MM-DD")
+		}
+		if last.Before(start) {
+			return invalid("the range ends before it starts")
+		}
+		return start, last.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+
+	return invalid("")
+}
+
+// quarterStart returns the first day of the calendar quarter t falls in.
+func quarterStart(t time.Time) time.Time {
+	month := time.Month(3*((int(t.Month())-1)/3) + 1)
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+}
+
+// financialYearStart returns 1 April of the Indian financial year t falls in.
+func financialYearStart(t time.Time) time.Time {
+	year := t.Year()
+	if t.Month() < time.April {
+		year--
+	}
+	return time.Date(year, time.April, 1, 0, 0, 0, 0, t.Location())
+}
+
 // InvalidPeriodError represents an invalid period error
 type InvalidPeriodError struct {
 	Period string
+	// Reason, when set, says what is wrong with a period that has a known form.
+	Reason string
 }
 
 func (e *InvalidPeriodError) Error() string {
+	if e.Reason != "" {
+		return "invalid period: " + e.Period + ": " + e.Reason
+	}
 	return "invalid period: " + e.Period
 }
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	// Embed the zone database so USER_TIMEZONE works in images without tzdata.
+	_ "time/tzdata"
+)
+
+// DefaultTimezone is used when USER_TIMEZONE is not set.
+const DefaultTimezone = "Asia/Kolkata"
+
+var (
+	userLocation     *time.Location
+	userLocationOnce sync.Once
+)
+
+// UserLocation returns the time zone periods, day and month buckets are
+// computed in, read once from USER_TIMEZONE (an IANA name such as
+// "Asia/Kolkata"). An invalid name falls back to the default.
+func UserLocation() *time.Location {
+	userLocationOnce.Do(func() {
+		name := os.Getenv("USER_TIMEZONE")
+		if name == "" {
+			name = DefaultTimezone
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			log.Printf("invalid USER_TIMEZONE=%q, using %s: %v", name, DefaultTimezone, err)
+			loc, err = time.LoadLocation(DefaultTimezone)
+			if err != nil {
+				loc = time.FixedZone("IST", 5*3600+30*60)
+			}
+		}
+		userLocation = loc
+	})
+	return userLocation
+}
+
+// ParseDate parses a YYYY-MM-DD date as midnight in the user's time zone.
+func ParseDate(value string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", value, UserLocation())
+}
+
+// EndOfDay returns the last instant of the user's day that t falls on.
+func EndOfDay(t time.Time) time.Time {
+	t = t.In(UserLocation())
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1).Add(-time.Nanosecond)
+}
+
+// DayKey formats the user's calendar day of t as YYYY-MM-DD, the key daily
+// trends are bucketed by.
+func DayKey(t time.Time) string {
+	return t.In(UserLocation()).Format("2006-01-02")
+}